@@ -1,19 +1,51 @@
 package bbusb
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"go.bug.st/serial/enumerator"
 )
 
-// FTDI vendor/product for BitBabbler
+// FTDI vendor for BitBabbler
 // Based on vendor's specification: FTDI VID with BitBabbler-specific PID
-const (
-	ftdiVendorID = 0x0403 // FTDI Vendor ID
-	bbProductID  = 0x7840 // BitBabbler Product ID
+const ftdiVendorID = 0x0403 // FTDI Vendor ID
+
+// bbProductIDs lists the BitBabbler product IDs under the FTDI vendor ID.
+// More can be added with RegisterBitBabblerPID.
+var (
+	bbPIDMu      sync.RWMutex
+	bbProductIDs = []uint16{0x7840} // BitBabbler White/Black
 )
 
+// RegisterBitBabblerPID adds pid to the product IDs recognized as a
+// BitBabbler by Detect, FindDevice, EnumerateDevices and OpenBitBabbler, for
+// units newer than this package. Registering a known PID is a no-op.
+func RegisterBitBabblerPID(pid uint16) {
+	bbPIDMu.Lock()
+	defer bbPIDMu.Unlock()
+	if !slices.Contains(bbProductIDs, pid) {
+		bbProductIDs = append(bbProductIDs, pid)
+	}
+}
+
+// isBitBabblerPID reports whether pid is a registered BitBabbler product ID.
+func isBitBabblerPID(pid uint16) bool {
+	bbPIDMu.RLock()
+	defer bbPIDMu.RUnlock()
+	return slices.Contains(bbProductIDs, pid)
+}
+
+// hardwareID formats the Windows-style hardware ID for a BitBabbler PID.
+func hardwareID(pid uint16) string {
+	return fmt.Sprintf("USB\\VID_%04X&PID_%04X", ftdiVendorID, pid)
+}
+
 // mpsse constants mirrors
 const (
 	mpsseNoClkDiv5     = 0x8A
@@ -69,8 +101,37 @@ type DeviceInfo struct {
 	HardwareIDs []string
 	// FriendlyName is a human-friendly device label if present
 	FriendlyName string
+	// ProductID is the USB product ID that matched, or 0 if the device was
+	// recognized by its product or serial string alone.
+	ProductID uint16
+	// Speed is the negotiated USB speed as libusb names it ("full", "high",
+	// ...). It is empty when the device was found by serial enumeration.
+	Speed string
+}
+
+// SlowBus reports whether the negotiated link is too slow for a BitBabbler
+// clocked at bitrate (DefaultBitrate if 0). A low-speed link has no bulk
+// transfers; a full-speed one carries at most 19 64-byte packets per 1 ms
+// frame, about 9.7 Mbit/s, which the FT232H exceeds when clocked faster.
+// It is false when the speed is unknown.
+func (d DeviceInfo) SlowBus(bitrate uint) bool {
+	if bitrate == 0 {
+		bitrate = DefaultBitrate
+	}
+	switch d.Speed {
+	case "low":
+		return true
+	case "full":
+		return bitrate > fullSpeedBulkRate
+	default:
+		return false
+	}
 }
 
+// fullSpeedBulkRate is the most bulk payload a full-speed USB link carries
+// in bits per second.
+const fullSpeedBulkRate = 19 * 64 * 8 * 1000
+
 // Detect checks if a BitBabbler device (VID 0x0403, PID 0x7840) is present.
 // Uses serial port enumeration to find FTDI devices with BitBabbler characteristics.
 func Detect() (bool, error) {
@@ -89,28 +150,38 @@ func Detect() (bool, error) {
 		if p == nil {
 			continue
 		}
-		if hasBitBabblerVIDPID(p) {
+		if _, ok := matchBitBabbler(p); ok {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
-// hasBitBabblerVIDPID checks if a port has BitBabbler VID/PID
-// Based on vendor's code: VID 0x0403 (FTDI), PID 0x7840 (BitBabbler)
-func hasBitBabblerVIDPID(p *enumerator.PortDetails) bool {
+// serialDeviceInfo describes a BitBabbler found by serial enumeration.
+func serialDeviceInfo(p *enumerator.PortDetails, pid uint16) DeviceInfo {
+	info := DeviceInfo{DevicePath: p.Name, FriendlyName: p.Product, ProductID: pid}
+	if pid != 0 {
+		info.HardwareIDs = []string{hardwareID(pid)}
+	}
+	return info
+}
+
+// matchBitBabbler checks if a port is a BitBabbler and returns the matched
+// product ID (0 when only the product or serial string matched).
+// Based on vendor's code: VID 0x0403 (FTDI), PIDs from bbProductIDs
+func matchBitBabbler(p *enumerator.PortDetails) (uint16, bool) {
 	if p == nil {
-		return false
+		return 0, false
 	}
 
 	// Primary check: VID/PID from vendor's specification
 	if p.IsUSB {
-		vid := strings.ToUpper(p.VID)
-		pid := strings.ToUpper(p.PID)
+		vid, verr := strconv.ParseUint(p.VID, 16, 16)
+		pid, perr := strconv.ParseUint(p.PID, 16, 16)
 
 		// BitBabbler uses FTDI VID with specific PID
-		if vid == "0403" && pid == "7840" {
-			return true
+		if verr == nil && perr == nil && vid == ftdiVendorID && isBitBabblerPID(uint16(pid)) {
+			return uint16(pid), true
 		}
 	}
 
@@ -122,7 +193,7 @@ func hasBitBabblerVIDPID(p *enumerator.PortDetails) bool {
 			if strings.Contains(productUpper, "BITBABBLER") ||
 				strings.Contains(productUpper, "BIT BABBLER") ||
 				strings.Contains(productUpper, "BB ") {
-				return true
+				return 0, true
 			}
 		}
 
@@ -131,10 +202,22 @@ func hasBitBabblerVIDPID(p *enumerator.PortDetails) bool {
 			serialUpper := strings.ToUpper(p.SerialNumber)
 			if strings.HasPrefix(serialUpper, "BB") ||
 				strings.Contains(serialUpper, "BITBABBLER") {
-				return true
+				return 0, true
 			}
 		}
 	}
 
-	return false
+	return 0, false
+}
+
+// sleepContext pauses for d, returning early with ctx.Err() if ctx ends.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
 }
@@ -18,12 +18,9 @@ func FindDevice() (*DeviceInfo, error) {
 		if p == nil {
 			continue
 		}
-		if hasBitBabblerVIDPID(p) {
-			return &DeviceInfo{
-				DevicePath:   p.Name,
-				HardwareIDs:  []string{fmt.Sprintf("USB\\VID_%04X&PID_%04X", ftdiVendorID, bbProductID)},
-				FriendlyName: p.Product,
-			}, nil
+		if pid, ok := matchBitBabbler(p); ok {
+			info := serialDeviceInfo(p, pid)
+			return &info, nil
 		}
 	}
 	return nil, errors.New("BitBabbler device not found")
@@ -39,12 +36,8 @@ func EnumerateDevices() ([]DeviceInfo, error) {
 		if p == nil {
 			continue
 		}
-		if hasBitBabblerVIDPID(p) {
-			devices = append(devices, DeviceInfo{
-				DevicePath:   p.Name,
-				HardwareIDs:  []string{fmt.Sprintf("USB\\VID_%04X&PID_%04X", ftdiVendorID, bbProductID)},
-				FriendlyName: p.Product,
-			})
+		if pid, ok := matchBitBabbler(p); ok {
+			devices = append(devices, serialDeviceInfo(p, pid))
 		}
 	}
 	return devices, nil
@@ -8,21 +8,48 @@ import (
 	"github.com/google/gousb"
 )
 
+// isBitBabblerDesc matches USB descriptors against the FTDI vendor ID and the
+// registered BitBabbler product IDs.
+func isBitBabblerDesc(desc *gousb.DeviceDesc) bool {
+	return desc.Vendor == gousb.ID(ftdiVendorID) && isBitBabblerPID(uint16(desc.Product))
+}
+
+// openFirstBitBabbler opens the first USB device matching a registered
+// BitBabbler PID and returns it with the matched PID. It returns a nil device
+// when none is present.
+func openFirstBitBabbler(ctx *gousb.Context) (*gousb.Device, uint16, error) {
+	devs, err := ctx.OpenDevices(isBitBabblerDesc)
+	if len(devs) == 0 {
+		return nil, 0, err
+	}
+	for _, d := range devs[1:] {
+		_ = d.Close()
+	}
+	return devs[0], uint16(devs[0].Desc.Product), nil
+}
+
+// usbDeviceInfo describes a BitBabbler found via libusb.
+func usbDeviceInfo(desc *gousb.DeviceDesc) DeviceInfo {
+	pid := uint16(desc.Product)
+	return DeviceInfo{
+		DevicePath:  fmt.Sprintf("usb:%04x:%04x", ftdiVendorID, pid),
+		HardwareIDs: []string{hardwareID(pid)},
+		ProductID:   pid,
+		Speed:       desc.Speed.String(),
+	}
+}
+
 // FindDevice (Linux) uses libusb first, then falls back to serial enumeration.
 func FindDevice() (*DeviceInfo, error) {
 	// libusb path
 	ctx := gousb.NewContext()
 	defer ctx.Close()
 
-	dev, err := ctx.OpenDeviceWithVIDPID(gousb.ID(ftdiVendorID), gousb.ID(bbProductID))
+	dev, _, err := openFirstBitBabbler(ctx)
 	if err == nil && dev != nil {
-		name := ""
+		info := usbDeviceInfo(dev.Desc)
 		_ = dev.Close()
-		return &DeviceInfo{
-			DevicePath:   fmt.Sprintf("usb:%04x:%04x", ftdiVendorID, bbProductID),
-			HardwareIDs:  []string{fmt.Sprintf("USB\\VID_%04X&PID_%04X", ftdiVendorID, bbProductID)},
-			FriendlyName: name,
-		}, nil
+		return &info, nil
 	}
 
 	// Fallback to the non-Linux impl (serial enumeration)
@@ -35,17 +62,10 @@ func EnumerateDevices() ([]DeviceInfo, error) {
 	ctx := gousb.NewContext()
 	defer ctx.Close()
 
-	devs, err := ctx.OpenDevices(func(desc *gousb.DeviceDesc) bool {
-		return desc.Vendor == gousb.ID(ftdiVendorID) && desc.Product == gousb.ID(bbProductID)
-	})
+	devs, err := ctx.OpenDevices(isBitBabblerDesc)
 	if err == nil {
 		for _, d := range devs {
-			name := ""
-			out = append(out, DeviceInfo{
-				DevicePath:   fmt.Sprintf("usb:%04x:%04x", ftdiVendorID, bbProductID),
-				HardwareIDs:  []string{fmt.Sprintf("USB\\VID_%04X&PID_%04X", ftdiVendorID, bbProductID)},
-				FriendlyName: name,
-			})
+			out = append(out, usbDeviceInfo(d.Desc))
 			_ = d.Close()
 		}
 		if len(out) > 0 {
@@ -0,0 +1,63 @@
+package bbusb
+
+import (
+	"slices"
+	"testing"
+
+	"go.bug.st/serial/enumerator"
+)
+
+func TestRegisterBitBabblerPID(t *testing.T) {
+	bbPIDMu.Lock()
+	old := slices.Clone(bbProductIDs)
+	bbPIDMu.Unlock()
+	t.Cleanup(func() {
+		bbPIDMu.Lock()
+		bbProductIDs = old
+		bbPIDMu.Unlock()
+	})
+
+	port := func(pid string) *enumerator.PortDetails {
+		return &enumerator.PortDetails{Name: "/dev/ttyUSB0", IsUSB: true, VID: "0403", PID: pid}
+	}
+	if pid, ok := matchBitBabbler(port("7840")); !ok || pid != 0x7840 {
+		t.Errorf("built-in PID: match = %#x, %v; want 0x7840, true", pid, ok)
+	}
+	if _, ok := matchBitBabbler(port("7841")); ok {
+		t.Fatal("unregistered PID matched")
+	}
+
+	RegisterBitBabblerPID(0x7841)
+	RegisterBitBabblerPID(0x7841)
+	if pid, ok := matchBitBabbler(port("7841")); !ok || pid != 0x7841 {
+		t.Errorf("registered PID: match = %#x, %v; want 0x7841, true", pid, ok)
+	}
+	if n := len(bbProductIDs); n != len(old)+1 {
+		t.Errorf("registering twice left %d PIDs, want %d", n, len(old)+1)
+	}
+	if _, ok := matchBitBabbler(&enumerator.PortDetails{IsUSB: true, VID: "0404", PID: "7841"}); ok {
+		t.Error("registered PID matched under a non-FTDI vendor")
+	}
+	if got := hardwareID(0x7841); got != `USB\VID_0403&PID_7841` {
+		t.Errorf("hardwareID = %q", got)
+	}
+}
+
+func TestSlowBus(t *testing.T) {
+	for _, tc := range []struct {
+		speed   string
+		bitrate uint
+		want    bool
+	}{
+		{"low", 0, true},
+		{"full", 0, false},
+		{"full", 7_500_000, false},
+		{"full", 15_000_000, true},
+		{"high", 30_000_000, false},
+		{"", 30_000_000, false},
+	} {
+		if got := (DeviceInfo{Speed: tc.speed}).SlowBus(tc.bitrate); got != tc.want {
+			t.Errorf("SlowBus(%d) at %q speed = %v, want %v", tc.bitrate, tc.speed, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package bbusb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// mpsseClock is the MPSSE bit clock with the divide-by-5 prescaler off; the
+// clock divisor d gives a rate of mpsseClock/(d+1).
+const mpsseClock = 30_000_000
+
+// DefaultBitrate is used when OpenBitBabbler is given a bitrate of 0.
+const DefaultBitrate = 2_500_000
+
+// ValidateBitrate reports the rate the BitBabbler actually runs at when
+// asked for bitrate. The clock divisor is an integer, so rates that do not
+// divide 30 MHz evenly are rounded up to the next achievable one (e.g.
+// 7 MHz becomes 7.5 MHz). Rates above 30 MHz, or below the 458 Hz the 16-bit
+// divisor can reach, are rejected.
+func ValidateBitrate(bitrate uint) (actual uint, err error) {
+	div, err := clockDivisor(bitrate)
+	if err != nil {
+		return 0, err
+	}
+	return mpsseClock / (uint(div) + 1), nil
+}
+
+// clockDivisor returns the MPSSE clock divisor for bitrate.
+func clockDivisor(bitrate uint) (uint16, error) {
+	if bitrate == 0 {
+		return 0, errors.New("bitrate must be positive")
+	}
+	if bitrate > mpsseClock {
+		return 0, fmt.Errorf("bitrate %d above the %d Hz MPSSE clock", bitrate, mpsseClock)
+	}
+	div := mpsseClock/bitrate - 1
+	if div > 0xFFFF {
+		return 0, fmt.Errorf("bitrate %d too low: the clock divisor cannot exceed 65535", bitrate)
+	}
+	return uint16(div), nil
+}
+
+// Bitrate returns the rate the device is clocked at, as ValidateBitrate
+// works it out from the bitrate the session was opened with.
+func (s *DeviceSession) Bitrate() uint {
+	actual, _ := ValidateBitrate(s.bitrate)
+	return actual
+}
@@ -0,0 +1,26 @@
+package bbusb
+
+import "testing"
+
+func TestValidateBitrate(t *testing.T) {
+	for _, tc := range []struct {
+		bitrate, want uint
+	}{
+		{30_000_000, 30_000_000},
+		{15_000_000, 15_000_000},
+		{DefaultBitrate, DefaultBitrate},
+		{7_000_000, 7_500_000}, // divisor 3, rounded up
+		{1_000, 1_000},
+		{458, 458},
+	} {
+		got, err := ValidateBitrate(tc.bitrate)
+		if err != nil || got != tc.want {
+			t.Errorf("ValidateBitrate(%d) = %d, %v; want %d", tc.bitrate, got, err, tc.want)
+		}
+	}
+	for _, bitrate := range []uint{0, 30_000_001, 457} {
+		if _, err := ValidateBitrate(bitrate); err == nil {
+			t.Errorf("ValidateBitrate(%d) accepted", bitrate)
+		}
+	}
+}
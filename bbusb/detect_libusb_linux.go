@@ -7,12 +7,12 @@ import (
 )
 
 // detectUSBViaLibusb checks for the BitBabbler device using libusb via gousb.
-// It returns true if a device with the expected VID/PID is present.
+// It returns true if a device with the FTDI VID and a registered BitBabbler PID is present.
 func detectUSBViaLibusb() bool {
 	ctx := gousb.NewContext()
 	defer ctx.Close()
 
-	dev, err := ctx.OpenDeviceWithVIDPID(gousb.ID(ftdiVendorID), gousb.ID(bbProductID))
+	dev, _, err := openFirstBitBabbler(ctx)
 	if err != nil {
 		return false
 	}
@@ -3,6 +3,7 @@
 package bbusb
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -13,11 +14,30 @@ import (
 type DeviceSession struct {
 	port     serial.Port
 	portName string
+
+	// bitrate and latencyMs are kept for Reconnect.
+	bitrate   uint
+	latencyMs uint8
 }
 
 // OpenBitBabbler opens the first BitBabbler device as a serial device.
 // This uses the FTDI serial driver that should be loaded by our udev rules.
 func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
+	return OpenBitBabblerContext(context.Background(), bitrate, latencyMs)
+}
+
+// OpenBitBabblerContext is OpenBitBabbler with cancellation: ctx is checked
+// between initialization steps and interrupts the settle delay.
+func OpenBitBabblerContext(ctx context.Context, bitrate uint, latencyMs uint8) (*DeviceSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if bitrate == 0 {
+		bitrate = DefaultBitrate
+	}
+	if _, err := ValidateBitrate(bitrate); err != nil {
+		return nil, err
+	}
 	// Find the BitBabbler device
 	device, err := FindDevice()
 	if err != nil {
@@ -39,8 +59,10 @@ func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
 	}
 
 	session := &DeviceSession{
-		port:     port,
-		portName: device.DevicePath,
+		port:      port,
+		portName:  device.DevicePath,
+		bitrate:   bitrate,
+		latencyMs: latencyMs,
 	}
 
 	// Basic initialization - set DTR and flush
@@ -49,7 +71,10 @@ func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
 		return nil, fmt.Errorf("failed to set DTR: %w", err)
 	}
 
-	time.Sleep(100 * time.Millisecond)
+	if err := sleepContext(ctx, 100*time.Millisecond); err != nil {
+		session.Close()
+		return nil, err
+	}
 
 	if err := port.ResetInputBuffer(); err != nil {
 		session.Close()
@@ -59,19 +84,29 @@ func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
 	return session, nil
 }
 
-// Close releases the serial port.
+// Close releases the serial port. Closing twice is harmless.
 func (s *DeviceSession) Close() {
 	if s != nil && s.port != nil {
 		s.port.Close()
+		s.port = nil
 	}
 }
 
+// takeHandles moves the serial port of fresh, a newly opened session, into
+// s, keeping s's own settings.
+func (s *DeviceSession) takeHandles(fresh *DeviceSession) {
+	s.port, s.portName = fresh.port, fresh.portName
+}
+
 // ReadRandom reads random data from the BitBabbler device.
 // This is a simplified implementation that works with the serial interface.
 func (s *DeviceSession) ReadRandom(buf []byte) (int, error) {
 	if len(buf) == 0 {
 		return 0, nil
 	}
+	if s.port == nil {
+		return 0, errSessionClosed
+	}
 
 	// For BitBabbler devices, we can read data directly from the serial port
 	// The device should provide random data continuously
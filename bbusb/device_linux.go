@@ -3,6 +3,7 @@
 package bbusb
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -15,29 +16,59 @@ type DeviceSession struct {
 	dev       *gousb.Device
 	cfg       *gousb.Config
 	intf      *gousb.Interface
-	inEp      *gousb.InEndpoint
-	outEp     *gousb.OutEndpoint
+	inEp      inEndpoint
+	outEp     outEndpoint
 	maxPacket int
+
+	// bitrate and latencyMs are kept for Reconnect.
+	bitrate   uint
+	latencyMs uint8
+}
+
+// inEndpoint and outEndpoint are the parts of gousb's bulk endpoints a
+// session uses, so the MPSSE exchanges can run against a fake device.
+type inEndpoint interface {
+	Read(buf []byte) (int, error)
+	ReadContext(ctx context.Context, buf []byte) (int, error)
+}
+
+type outEndpoint interface {
+	Write(buf []byte) (int, error)
+	WriteContext(ctx context.Context, buf []byte) (int, error)
 }
 
 // OpenBitBabbler opens the BitBabbler device and initializes MPSSE like the Windows implementation.
+// The bitrate is checked with ValidateBitrate; Bitrate reports the rate it
+// was rounded to.
 func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
+	return OpenBitBabblerContext(context.Background(), bitrate, latencyMs)
+}
+
+// OpenBitBabblerContext is OpenBitBabbler with cancellation: ctx is checked
+// between initialization steps, interrupts the settle delays and bounds the
+// MPSSE sync exchange. On cancellation the partly opened device is released
+// and ctx.Err() is returned.
+func OpenBitBabblerContext(ctx context.Context, bitrate uint, latencyMs uint8) (*DeviceSession, error) {
 	if bitrate == 0 {
-		bitrate = 2_500_000
+		bitrate = DefaultBitrate
 	}
 	if latencyMs == 0 {
 		latencyMs = 1
 	}
+	clkDiv, err := clockDivisor(bitrate)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx := gousb.NewContext()
+	usbCtx := gousb.NewContext()
 
-	dev, err := ctx.OpenDeviceWithVIDPID(gousb.ID(ftdiVendorID), gousb.ID(bbProductID))
+	dev, _, err := openFirstBitBabbler(usbCtx)
 	if err != nil {
-		ctx.Close()
+		usbCtx.Close()
 		return nil, err
 	}
 	if dev == nil {
-		ctx.Close()
+		usbCtx.Close()
 		return nil, fmt.Errorf("BitBabbler device not found")
 	}
 
@@ -46,14 +77,14 @@ func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
 	cfg, err := dev.Config(1)
 	if err != nil {
 		dev.Close()
-		ctx.Close()
+		usbCtx.Close()
 		return nil, err
 	}
 	intf, err := cfg.Interface(0, 0)
 	if err != nil {
 		cfg.Close()
 		dev.Close()
-		ctx.Close()
+		usbCtx.Close()
 		return nil, err
 	}
 
@@ -66,7 +97,7 @@ func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
 				intf.Close()
 				cfg.Close()
 				dev.Close()
-				ctx.Close()
+				usbCtx.Close()
 				return nil, err
 			}
 		}
@@ -76,7 +107,7 @@ func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
 				intf.Close()
 				cfg.Close()
 				dev.Close()
-				ctx.Close()
+				usbCtx.Close()
 				return nil, err
 			}
 		}
@@ -85,53 +116,39 @@ func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
 		intf.Close()
 		cfg.Close()
 		dev.Close()
-		ctx.Close()
+		usbCtx.Close()
 		return nil, fmt.Errorf("bulk endpoints not found")
 	}
 
-	s := &DeviceSession{ctx: ctx, dev: dev, cfg: cfg, intf: intf, inEp: inEp, outEp: outEp, maxPacket: int(inEp.Desc.MaxPacketSize)}
+	s := &DeviceSession{ctx: usbCtx, dev: dev, cfg: cfg, intf: intf, inEp: inEp, outEp: outEp, maxPacket: int(inEp.Desc.MaxPacketSize), bitrate: bitrate, latencyMs: latencyMs}
 
 	// FTDI/MPSSE init
-	if err := s.ftdiReset(); err != nil {
-		s.Close()
-		return nil, err
-	}
-	if err := s.purgeRead(); err != nil {
-		s.Close()
-		return nil, err
-	}
-	if err := s.ftdiSetSpecialChars(0, false, 0, false); err != nil {
-		s.Close()
-		return nil, err
-	}
-	if err := s.ftdiSetLatencyTimer(latencyMs); err != nil {
-		s.Close()
-		return nil, err
-	}
-	if err := s.ftdiSetFlowControl(ftdiFlowRtsCts); err != nil {
-		s.Close()
-		return nil, err
-	}
-	if err := s.ftdiSetBitmode(ftdiBitmodeReset, 0); err != nil {
-		s.Close()
-		return nil, err
-	}
-	if err := s.ftdiSetBitmode(ftdiBitmodeMpsse, 0); err != nil {
-		s.Close()
-		return nil, err
+	steps := []func() error{
+		s.ftdiReset,
+		s.purgeRead,
+		func() error { return s.ftdiSetSpecialChars(0, false, 0, false) },
+		func() error { return s.ftdiSetLatencyTimer(latencyMs) },
+		func() error { return s.ftdiSetFlowControl(ftdiFlowRtsCts) },
+		func() error { return s.ftdiSetBitmode(ftdiBitmodeReset, 0) },
+		func() error { return s.ftdiSetBitmode(ftdiBitmodeMpsse, 0) },
+		func() error { return sleepContext(ctx, 50*time.Millisecond) },
+	}
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			s.Close()
+			return nil, err
+		}
+		if err := step(); err != nil {
+			s.Close()
+			return nil, err
+		}
 	}
-	time.Sleep(50 * time.Millisecond)
 
-	ok := s.checkSync(0xAA) && s.checkSync(0xAB)
-	if !ok {
-		ok = s.checkSync(0xAA) && s.checkSync(0xAB)
-	}
-	if !ok {
+	if err := s.syncMPSSE(ctx); err != nil {
 		s.Close()
-		return nil, fmt.Errorf("MPSSE sync failed")
+		return nil, err
 	}
 
-	clkDiv := uint16(30000000/bitrate - 1)
 	cmd := []byte{
 		mpsseNoClkDiv5,
 		mpsseNoAdaptiveClk,
@@ -147,17 +164,23 @@ func OpenBitBabbler(bitrate uint, latencyMs uint8) (*DeviceSession, error) {
 		byte(clkDiv >> 8),
 		0x85,
 	}
-	if _, err := s.outEp.Write(cmd); err != nil {
+	if _, err := s.outEp.WriteContext(ctx, cmd); err != nil {
+		s.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if err := sleepContext(ctx, 30*time.Millisecond); err != nil {
 		s.Close()
 		return nil, err
 	}
-	time.Sleep(30 * time.Millisecond)
 	_ = s.purgeRead()
 
 	return s, nil
 }
 
-// Close releases USB resources.
+// Close releases USB resources. Closing twice is harmless.
 func (s *DeviceSession) Close() {
 	if s == nil {
 		return
@@ -174,6 +197,14 @@ func (s *DeviceSession) Close() {
 	if s.ctx != nil {
 		s.ctx.Close()
 	}
+	s.ctx, s.dev, s.cfg, s.intf, s.inEp, s.outEp = nil, nil, nil, nil, nil, nil
+}
+
+// takeHandles moves the USB handles of fresh, a newly opened session, into
+// s, keeping s's own settings.
+func (s *DeviceSession) takeHandles(fresh *DeviceSession) {
+	s.ctx, s.dev, s.cfg, s.intf = fresh.ctx, fresh.dev, fresh.cfg, fresh.intf
+	s.inEp, s.outEp, s.maxPacket = fresh.inEp, fresh.outEp, fresh.maxPacket
 }
 
 // ReadRandom issues an MPSSE read and strips FTDI status headers.
@@ -181,6 +212,9 @@ func (s *DeviceSession) ReadRandom(buf []byte) (int, error) {
 	if len(buf) == 0 {
 		return 0, nil
 	}
+	if s.outEp == nil {
+		return 0, errSessionClosed
+	}
 	n := len(buf)
 	cmd := []byte{
 		mpsseDataByteInPosMSB,
@@ -274,14 +308,30 @@ func (s *DeviceSession) purgeRead() error {
 	}
 	return nil
 }
-func (s *DeviceSession) checkSync(cmd byte) bool {
+
+// syncMPSSE checks that the MPSSE engine echoes bad-command bytes, trying
+// twice. It returns ctx.Err() if ctx ends during the exchange.
+func (s *DeviceSession) syncMPSSE(ctx context.Context) error {
+	ok := s.checkSync(ctx, 0xAA) && s.checkSync(ctx, 0xAB)
+	if !ok && ctx.Err() == nil {
+		ok = s.checkSync(ctx, 0xAA) && s.checkSync(ctx, 0xAB)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("MPSSE sync failed")
+	}
+	return nil
+}
+func (s *DeviceSession) checkSync(ctx context.Context, cmd byte) bool {
 	msg := []byte{cmd, mpsseSendImmediate}
-	if _, err := s.outEp.Write(msg); err != nil {
+	if _, err := s.outEp.WriteContext(ctx, msg); err != nil {
 		return false
 	}
 	buf := make([]byte, 512)
-	for i := 0; i < 10; i++ {
-		n, _ := s.inEp.Read(buf)
+	for i := 0; i < 10 && ctx.Err() == nil; i++ {
+		n, _ := s.inEp.ReadContext(ctx, buf)
 		if n == 4 && buf[2] == 0xFA && buf[3] == cmd {
 			return true
 		}
@@ -0,0 +1,65 @@
+//go:build linux
+
+package bbusb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeMPSSE is a pair of bulk endpoints standing in for the FTDI chip. It
+// answers the sync check with the 0xFA echo when echo is set; otherwise reads
+// block until their context ends, like a device that never responds.
+type fakeMPSSE struct {
+	echo bool
+	last byte
+}
+
+func (f *fakeMPSSE) Write(buf []byte) (int, error) {
+	return f.WriteContext(context.Background(), buf)
+}
+
+func (f *fakeMPSSE) WriteContext(ctx context.Context, buf []byte) (int, error) {
+	if len(buf) > 0 {
+		f.last = buf[0]
+	}
+	return len(buf), nil
+}
+
+func (f *fakeMPSSE) Read(buf []byte) (int, error) {
+	return f.ReadContext(context.Background(), buf)
+}
+
+func (f *fakeMPSSE) ReadContext(ctx context.Context, buf []byte) (int, error) {
+	if !f.echo {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	return copy(buf, []byte{0x32, 0x60, 0xFA, f.last}), nil
+}
+
+func TestSyncMPSSECancelled(t *testing.T) {
+	dev := &fakeMPSSE{}
+	s := &DeviceSession{inEp: dev, outEp: dev, maxPacket: 64}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := s.syncMPSSE(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("sync took %v to notice the cancellation", elapsed)
+	}
+}
+
+func TestSyncMPSSE(t *testing.T) {
+	dev := &fakeMPSSE{echo: true}
+	s := &DeviceSession{inEp: dev, outEp: dev, maxPacket: 64}
+	if err := s.syncMPSSE(context.Background()); err != nil {
+		t.Errorf("sync with an echoing device: %v", err)
+	}
+}
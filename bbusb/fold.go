@@ -0,0 +1,32 @@
+package bbusb
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReadRandomFolded fills buf with the XOR of foldN consecutive raw blocks of
+// len(buf) bytes, the N-fold whitening of the vendor's bit-babbler daemon.
+// Folding shrinks any bias left in the raw stream, at the cost of dividing
+// the throughput by foldN. A foldN of 0 or 1 reads unfolded, as ReadRandom
+// does. Unlike ReadRandom it only succeeds with a full buffer: a short read
+// fails with nothing returned, since a partly folded block is not whitened.
+func (s *DeviceSession) ReadRandomFolded(buf []byte, foldN uint) (int, error) {
+	if foldN <= 1 {
+		return s.ReadRandom(buf)
+	}
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	out := make([]byte, len(buf))
+	raw := make([]byte, len(buf))
+	for i := range foldN {
+		if _, err := io.ReadFull(s, raw); err != nil {
+			return 0, fmt.Errorf("fold %d of %d: %w", i+1, foldN, err)
+		}
+		for j, b := range raw {
+			out[j] ^= b
+		}
+	}
+	return copy(buf, out), nil
+}
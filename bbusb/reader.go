@@ -0,0 +1,54 @@
+package bbusb
+
+import (
+	"errors"
+	"io"
+)
+
+// maxTransfer is the most ReadRandom is asked for at once: the MPSSE read
+// command carries the length in 16 bits.
+const maxTransfer = 1 << 16
+
+// errNoData reports a read that timed out without delivering anything.
+var errNoData = errors.New("BitBabbler read returned no data")
+
+// Read implements io.Reader, so a session can feed io.CopyN, bufio, hashes
+// and the like. It fills p with ReadRandom, split into transfers of at most
+// 64 KiB, and stops early only if the device stops delivering. A read that
+// gets nothing at all fails rather than returning 0, nil.
+func (s *DeviceSession) Read(p []byte) (int, error) {
+	total := 0
+	for total < len(p) {
+		n, err := s.ReadRandom(p[total:min(len(p), total+maxTransfer)])
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if total == 0 && len(p) > 0 {
+		return 0, errNoData
+	}
+	return total, nil
+}
+
+// Reader returns the session as an io.ReadCloser whose Read is the
+// session's Read and whose Close closes the session.
+func (s *DeviceSession) Reader() io.ReadCloser {
+	return sessionReader{s}
+}
+
+type sessionReader struct {
+	s *DeviceSession
+}
+
+func (r sessionReader) Read(p []byte) (int, error) {
+	return r.s.Read(p)
+}
+
+func (r sessionReader) Close() error {
+	r.s.Close()
+	return nil
+}
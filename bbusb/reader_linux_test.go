@@ -0,0 +1,78 @@
+package bbusb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// fakeStream is a pair of bulk endpoints answering MPSSE read commands with
+// a counting byte sequence, split into 64-byte packets that each start with
+// the two FTDI status bytes.
+type fakeStream struct {
+	next    byte
+	pending int
+	cmds    []int // lengths requested by each read command
+}
+
+func (f *fakeStream) Write(buf []byte) (int, error) {
+	return f.WriteContext(context.Background(), buf)
+}
+
+func (f *fakeStream) WriteContext(ctx context.Context, buf []byte) (int, error) {
+	if len(buf) >= 3 && buf[0] == mpsseDataByteInPosMSB {
+		n := int(buf[1]) | int(buf[2])<<8 + 1
+		f.cmds = append(f.cmds, n)
+		f.pending += n
+	}
+	return len(buf), nil
+}
+
+func (f *fakeStream) Read(buf []byte) (int, error) {
+	return f.ReadContext(context.Background(), buf)
+}
+
+func (f *fakeStream) ReadContext(ctx context.Context, buf []byte) (int, error) {
+	off := 0
+	for off+2 <= len(buf) {
+		buf[off], buf[off+1] = 0x32, 0x60
+		off += 2
+		for i := 0; i < 62 && f.pending > 0 && off < len(buf); i++ {
+			buf[off] = f.next
+			f.next++
+			f.pending--
+			off++
+		}
+		if f.pending == 0 {
+			break
+		}
+	}
+	return off, nil
+}
+
+func TestSessionReader(t *testing.T) {
+	dev := &fakeStream{}
+	s := &DeviceSession{inEp: dev, outEp: dev, maxPacket: 64}
+	r := s.Reader()
+
+	buf := make([]byte, maxTransfer+1000)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range buf {
+		if b != byte(i) {
+			t.Fatalf("byte %d = %#x, want %#x: status bytes leaked into the data", i, b, byte(i))
+		}
+	}
+	if len(dev.cmds) != 2 || dev.cmds[0] != maxTransfer || dev.cmds[1] != 1000 {
+		t.Errorf("read commands %v, want [%d 1000]", dev.cmds, maxTransfer)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(buf[:1]); !errors.Is(err, errSessionClosed) {
+		t.Errorf("read after Close: err = %v, want errSessionClosed", err)
+	}
+}
@@ -0,0 +1,67 @@
+package bbusb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errSessionClosed is returned by reads on a closed session.
+var errSessionClosed = errors.New("BitBabbler session is closed")
+
+// readReconnects is how many times ReadRandomWithReconnect reconnects before
+// giving up.
+const readReconnects = 3
+
+// reconnectSettle is how long the device is left alone between closing and
+// reopening it. It is a variable so tests can skip the wait.
+var reconnectSettle = 500 * time.Millisecond
+
+// openBitBabbler opens a fresh session for Reconnect. It is a variable so
+// reconnection can run against a fake device.
+var openBitBabbler = OpenBitBabblerContext
+
+// Reconnect closes the device and opens the first BitBabbler again with the
+// session's bitrate and latency, re-running the full initialization. It is
+// the recovery for a unit that was unplugged or reset under a running
+// session. ctx interrupts the settle delay and the reopening. If reopening
+// fails the session is left closed; a later Reconnect may still succeed.
+func (s *DeviceSession) Reconnect(ctx context.Context) error {
+	s.Close()
+	if err := sleepContext(ctx, reconnectSettle); err != nil {
+		return err
+	}
+	fresh, err := openBitBabbler(ctx, s.bitrate, s.latencyMs)
+	if err != nil {
+		return fmt.Errorf("reconnection failed: %w", err)
+	}
+	s.takeHandles(fresh)
+	return nil
+}
+
+// ReadRandomWithReconnect is ReadRandom for long-running use: when a read
+// fails with an I/O error, the session is reconnected and the read retried
+// from scratch, so whatever the failed read delivered is discarded. It gives
+// up after 3 reconnects, or as soon as ctx ends. Reads on a session the
+// caller has closed fail without reconnecting.
+func (s *DeviceSession) ReadRandomWithReconnect(ctx context.Context, buf []byte) (int, error) {
+	var lastErr error
+	for attempt := 0; attempt <= readReconnects; attempt++ {
+		if attempt > 0 {
+			if err := s.Reconnect(ctx); err != nil {
+				if ctx.Err() != nil {
+					return 0, err
+				}
+				lastErr = err
+				continue
+			}
+		}
+		n, err := s.ReadRandom(buf)
+		if err == nil || (attempt == 0 && errors.Is(err, errSessionClosed)) {
+			return n, err
+		}
+		lastErr = err
+	}
+	return 0, fmt.Errorf("read failed after %d reconnects: %w", readReconnects, lastErr)
+}
@@ -0,0 +1,95 @@
+package bbusb
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// deadEndpoints fails every transfer, like a unit that was unplugged.
+type deadEndpoints struct{}
+
+var errUnplugged = errors.New("no such device")
+
+func (deadEndpoints) Write(buf []byte) (int, error) {
+	return 0, errUnplugged
+}
+
+func (deadEndpoints) WriteContext(ctx context.Context, buf []byte) (int, error) {
+	return 0, errUnplugged
+}
+
+func (deadEndpoints) Read(buf []byte) (int, error) {
+	return 0, errUnplugged
+}
+
+func (deadEndpoints) ReadContext(ctx context.Context, buf []byte) (int, error) {
+	return 0, errUnplugged
+}
+
+// fakeReopen makes Reconnect open sessions on fresh fakeStreams for the rest
+// of the test and returns the number of opens so far.
+func fakeReopen(t *testing.T) *int {
+	t.Helper()
+	opens := 0
+	oldOpen, oldSettle := openBitBabbler, reconnectSettle
+	openBitBabbler = func(ctx context.Context, bitrate uint, latencyMs uint8) (*DeviceSession, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if bitrate != 7_500_000 || latencyMs != 4 {
+			t.Errorf("reopened with bitrate %d, latency %d; want the session's 7500000 and 4", bitrate, latencyMs)
+		}
+		opens++
+		dev := &fakeStream{}
+		return &DeviceSession{inEp: dev, outEp: dev, maxPacket: 64, bitrate: DefaultBitrate, latencyMs: 1}, nil
+	}
+	reconnectSettle = 0
+	t.Cleanup(func() { openBitBabbler, reconnectSettle = oldOpen, oldSettle })
+	return &opens
+}
+
+func TestReadRandomWithReconnect(t *testing.T) {
+	opens := fakeReopen(t)
+	s := &DeviceSession{inEp: deadEndpoints{}, outEp: deadEndpoints{}, maxPacket: 64, bitrate: 7_500_000, latencyMs: 4}
+
+	buf := make([]byte, 100)
+	n, err := s.ReadRandomWithReconnect(context.Background(), buf)
+	if err != nil || n != len(buf) {
+		t.Fatalf("ReadRandomWithReconnect = %d, %v; want %d, nil", n, err, len(buf))
+	}
+	if *opens != 1 {
+		t.Errorf("%d reopens, want 1", *opens)
+	}
+	for i, b := range buf {
+		if b != byte(i) {
+			t.Fatalf("byte %d = %#x, want %#x from the reopened device", i, b, byte(i))
+		}
+	}
+	if s.bitrate != 7_500_000 || s.latencyMs != 4 {
+		t.Errorf("reconnect changed the settings to bitrate %d, latency %d", s.bitrate, s.latencyMs)
+	}
+
+	// A session the caller closed is not reopened.
+	s.Close()
+	if _, err := s.ReadRandomWithReconnect(context.Background(), buf); !errors.Is(err, errSessionClosed) {
+		t.Errorf("closed session: err = %v, want errSessionClosed", err)
+	}
+	if *opens != 1 {
+		t.Errorf("closed session was reopened")
+	}
+}
+
+func TestReconnectCancelled(t *testing.T) {
+	opens := fakeReopen(t)
+	s := &DeviceSession{inEp: deadEndpoints{}, outEp: deadEndpoints{}, maxPacket: 64, bitrate: 7_500_000, latencyMs: 4}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.ReadRandomWithReconnect(ctx, make([]byte, 10)); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if *opens != 0 {
+		t.Errorf("%d reopens after cancellation, want 0", *opens)
+	}
+}
@@ -0,0 +1,88 @@
+package bbusb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Candidate settings tried by AutoTune, and how long each is benchmarked.
+var (
+	TuneBitrates  = []uint{1_000_000, 2_500_000, 5_000_000, 10_000_000}
+	TuneLatencies = []uint8{1, 2, 4, 16}
+	TuneTrial     = 500 * time.Millisecond
+)
+
+// tuneReadSize is the buffer handed to ReadRandom during a trial.
+const tuneReadSize = 4096
+
+// randomSession is the part of DeviceSession AutoTune needs.
+type randomSession interface {
+	ReadRandom(buf []byte) (int, error)
+	Close()
+}
+
+// openTuneSession opens a session for one AutoTune trial. It is a variable so
+// the selection logic can run against a fake device.
+var openTuneSession = func(ctx context.Context, bitrate uint, latencyMs uint8) (randomSession, error) {
+	return OpenBitBabblerContext(ctx, bitrate, latencyMs)
+}
+
+// AutoTune benchmarks the BitBabbler at every combination of TuneBitrates and
+// TuneLatencies and returns the configuration with the highest measured
+// throughput. The device is reopened for each trial, and each trial reads for
+// TuneTrial. Combinations that fail to open or read are skipped; an error is
+// returned only if none succeeds or ctx is cancelled.
+func AutoTune(ctx context.Context) (bitrate uint, latencyMs uint8, bytesPerSec float64, err error) {
+	var lastErr error
+	found := false
+	for _, br := range TuneBitrates {
+		for _, lat := range TuneLatencies {
+			if err := ctx.Err(); err != nil {
+				return 0, 0, 0, err
+			}
+			rate, err := benchmarkSetting(ctx, br, lat)
+			if err != nil {
+				lastErr = fmt.Errorf("bitrate %d latency %dms: %w", br, lat, err)
+				continue
+			}
+			if !found || rate > bytesPerSec {
+				bitrate, latencyMs, bytesPerSec = br, lat, rate
+				found = true
+			}
+		}
+	}
+	if !found {
+		if lastErr == nil {
+			lastErr = errors.New("no candidate settings")
+		}
+		return 0, 0, 0, fmt.Errorf("auto-tune: %w", lastErr)
+	}
+	return bitrate, latencyMs, bytesPerSec, nil
+}
+
+// benchmarkSetting opens the device at one setting and measures throughput
+// over TuneTrial.
+func benchmarkSetting(ctx context.Context, bitrate uint, latencyMs uint8) (float64, error) {
+	s, err := openTuneSession(ctx, bitrate, latencyMs)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+
+	buf := make([]byte, tuneReadSize)
+	var total int
+	start := time.Now()
+	for time.Since(start) < TuneTrial {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		n, err := s.ReadRandom(buf)
+		total += n
+		if err != nil {
+			return 0, err
+		}
+	}
+	return float64(total) / time.Since(start).Seconds(), nil
+}
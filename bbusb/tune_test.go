@@ -0,0 +1,68 @@
+package bbusb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeTuneSession delivers chunk bytes per read, one read per millisecond.
+type fakeTuneSession struct {
+	chunk  int
+	closed *int
+}
+
+func (s fakeTuneSession) ReadRandom(buf []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return min(s.chunk, len(buf)), nil
+}
+
+func (s fakeTuneSession) Close() { *s.closed++ }
+
+func TestAutoTunePicksFastestSetting(t *testing.T) {
+	oldOpen, oldRates, oldLats, oldTrial := openTuneSession, TuneBitrates, TuneLatencies, TuneTrial
+	t.Cleanup(func() { openTuneSession, TuneBitrates, TuneLatencies, TuneTrial = oldOpen, oldRates, oldLats, oldTrial })
+	TuneBitrates = []uint{1_000_000, 5_000_000, 10_000_000}
+	TuneLatencies = []uint8{1, 4}
+	TuneTrial = 20 * time.Millisecond
+
+	opened, closed := 0, 0
+	openTuneSession = func(ctx context.Context, bitrate uint, latencyMs uint8) (randomSession, error) {
+		if bitrate == 10_000_000 {
+			return nil, errors.New("bitrate rejected")
+		}
+		opened++
+		chunk := 64
+		if bitrate == 5_000_000 && latencyMs == 4 {
+			chunk = 4096
+		}
+		return fakeTuneSession{chunk: chunk, closed: &closed}, nil
+	}
+
+	bitrate, latency, rate, err := AutoTune(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bitrate != 5_000_000 || latency != 4 {
+		t.Errorf("AutoTune picked %d/%dms, want 5000000/4ms", bitrate, latency)
+	}
+	if rate <= 0 {
+		t.Errorf("bytesPerSec = %v, want positive", rate)
+	}
+	if opened != 4 || closed != opened {
+		t.Errorf("opened %d sessions and closed %d, want 4 each (reopened per trial)", opened, closed)
+	}
+}
+
+func TestAutoTuneNoneSucceeds(t *testing.T) {
+	oldOpen, oldRates, oldLats := openTuneSession, TuneBitrates, TuneLatencies
+	t.Cleanup(func() { openTuneSession, TuneBitrates, TuneLatencies = oldOpen, oldRates, oldLats })
+	TuneBitrates, TuneLatencies = []uint{1_000_000}, []uint8{1}
+	openTuneSession = func(context.Context, uint, uint8) (randomSession, error) {
+		return nil, errors.New("no device")
+	}
+	if _, _, _, err := AutoTune(context.Background()); err == nil {
+		t.Error("AutoTune succeeded with no working setting")
+	}
+}
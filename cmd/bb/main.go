@@ -17,6 +17,7 @@ func main() {
 	bits := flag.Int("bits", 1024, "number of bits to read per batch")
 	bitrate := flag.Uint("bitrate", 2500000, "bitrate for BitBabbler (default 2.5M)")
 	latency := flag.Uint("latency", 1, "FTDI latency timer in ms")
+	autoTune := flag.Bool("autotune", false, "benchmark bitrate/latency combinations and use the fastest")
 	flag.Parse()
 
 	// Check if device is present
@@ -38,6 +39,16 @@ func main() {
 	fmt.Printf("Device path: %s\n", device.DevicePath)
 	fmt.Printf("Using serial mode (simplified - not full MPSSE)\n")
 
+	if *autoTune {
+		log.Printf("auto-tuning bitrate/latency...")
+		br, lat, rate, err := bbusb.AutoTune(context.Background())
+		if err != nil {
+			log.Fatalf("auto-tune failed: %v", err)
+		}
+		log.Printf("best: bitrate %d, latency %dms (%.0f bytes/s)", br, lat, rate)
+		*bitrate, *latency = br, uint(lat)
+	}
+
 	// Open device session
 	session, err := bbusb.OpenBitBabbler(*bitrate, uint8(*latency))
 	if err != nil {
@@ -46,6 +57,9 @@ func main() {
 	defer session.Close()
 
 	fmt.Printf("BitBabbler device initialized successfully!\n")
+	if actual := session.Bitrate(); *bitrate != 0 && actual != *bitrate {
+		fmt.Printf("Bitrate %d rounded up to %d, the closest the clock divisor allows\n", *bitrate, actual)
+	}
 
 	// Calculate byte count
 	byteCount := (*bits + 7) / 8
@@ -66,8 +80,11 @@ func main() {
 		}
 
 		buf := make([]byte, byteCount)
-		n, err := session.ReadRandom(buf)
+		n, err := session.ReadRandomWithReconnect(ctx, buf)
 		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
 			log.Printf("read error: %v", err)
 			continue
 		}
@@ -37,6 +37,15 @@ func main() {
 	fmt.Printf("  Friendly Name: %s\n", device.FriendlyName)
 	fmt.Printf("  Device Path: %s\n", device.DevicePath)
 	fmt.Printf("  Hardware IDs: %v\n", device.HardwareIDs)
+	if device.ProductID != 0 {
+		fmt.Printf("  Product ID: %04x\n", device.ProductID)
+	}
+	if device.Speed != "" {
+		fmt.Printf("  USB Speed: %s\n", device.Speed)
+	}
+	if device.SlowBus(bbusb.DefaultBitrate) {
+		fmt.Printf("⚠️  The device is on a USB link too slow for %d bit/s; plug it into a USB 2.0 (or newer) port.\n", bbusb.DefaultBitrate)
+	}
 
 	// Try to enumerate all devices
 	devices, err := bbusb.EnumerateDevices()
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// deviceFlags are the flags choosing which TrueRNG to read from. The main
+// command and every subcommand that reads from a device register them.
+type deviceFlags struct {
+	alias     *string
+	aliasFile *string
+	serial    *string
+	location  *string
+}
+
+// addDeviceFlags registers the device selection flags on fs.
+func addDeviceFlags(fs *flag.FlagSet) *deviceFlags {
+	return &deviceFlags{
+		alias:     fs.String("device", "", "device alias to read from, resolved to a serial number via -aliases"),
+		aliasFile: fs.String("aliases", defaultAliasPath(), "device alias file (JSON object: nickname -> serial)"),
+		serial:    fs.String("serial", "", "USB serial number of the device to read from (see -list)"),
+		location:  fs.String("location", "", "USB bus/port location of the device to read from, e.g. 1-2.3 (Linux; see -list)"),
+	}
+}
+
+// selected reports whether the flags name a specific device rather than
+// leaving it to the first one detected.
+func (f *deviceFlags) selected() bool {
+	return *f.alias != "" || *f.serial != "" || *f.location != ""
+}
+
+// find returns the device the flags select, or the first detected TrueRNG
+// when they select none.
+func (f *deviceFlags) find() (*truerng.DeviceInfo, error) {
+	n := 0
+	for _, v := range []string{*f.alias, *f.serial, *f.location} {
+		if v != "" {
+			n++
+		}
+	}
+	switch {
+	case n > 1:
+		return nil, errors.New("-device, -serial and -location each select a device; use one")
+	case *f.alias != "":
+		return truerng.FindDeviceByAlias(*f.aliasFile, *f.alias)
+	case *f.serial != "":
+		return truerng.FindDeviceBySerial(*f.serial)
+	case *f.location != "":
+		return truerng.FindDeviceByLocation(*f.location)
+	}
+	return truerng.FindDevice()
+}
+
+// findWaiting calls find until it succeeds or wait has passed, polling twice
+// a second, so a device plugged in just after startup is still picked up.
+// With wait zero it calls find once.
+func findWaiting(find func() (*truerng.DeviceInfo, error), wait time.Duration) (*truerng.DeviceInfo, error) {
+	device, err := find()
+	for deadline := time.Now().Add(wait); err != nil && time.Now().Before(deadline); {
+		time.Sleep(500 * time.Millisecond)
+		device, err = find()
+	}
+	return device, err
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+func TestFindWaiting(t *testing.T) {
+	calls := 0
+	find := func() (*truerng.DeviceInfo, error) {
+		calls++
+		if calls < 2 {
+			return nil, errors.New("no TrueRNG device found")
+		}
+		return &truerng.DeviceInfo{Port: "/dev/ttyACM0"}, nil
+	}
+
+	if _, err := findWaiting(find, 0); err == nil || calls != 1 {
+		t.Fatalf("without a wait: err = %v after %d lookups, want one failed lookup", err, calls)
+	}
+	calls = 0
+	device, err := findWaiting(find, 2*time.Second)
+	if err != nil || device.Port != "/dev/ttyACM0" || calls != 2 {
+		t.Errorf("with a wait: %v, %v after %d lookups", device, err, calls)
+	}
+}
+
+func TestDeviceFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	dev := addDeviceFlags(fs)
+	if err := fs.Parse([]string{"-serial", "TR0001"}); err != nil {
+		t.Fatal(err)
+	}
+	if !dev.selected() {
+		t.Error("-serial does not select a device")
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	dev = addDeviceFlags(fs)
+	if err := fs.Parse([]string{"-serial", "TR0001", "-device", "lab"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dev.find(); err == nil {
+		t.Error("-device with -serial: want an error")
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	dev = addDeviceFlags(fs)
+	if err := fs.Parse([]string{"-location", "1-2.3"}); err != nil {
+		t.Fatal(err)
+	}
+	if !dev.selected() {
+		t.Error("-location does not select a device")
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	dev = addDeviceFlags(fs)
+	if err := fs.Parse([]string{"-location", "1-2.3", "-serial", "TR0001"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dev.find(); err == nil {
+		t.Error("-location with -serial: want an error")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// runFeed implements `trngcli feed [-mode m] [-credit bits] [-dry-run]`: like
+// rngd, it pushes device output into the kernel entropy pool until
+// interrupted.
+func runFeed(args []string) {
+	fs := flag.NewFlagSet("feed", flag.ExitOnError)
+	modeStr := fs.String("mode", "normal", "capture mode")
+	credit := fs.Int("credit", 6, fmt.Sprintf("entropy credited per byte, 0-%d bits (crediting needs root)", truerng.MaxEntropyCredit))
+	dryRun := fs.Bool("dry-run", false, "read and account without writing to /dev/random")
+	verbose := fs.Bool("v", false, "log the running totals after every write")
+	dev := addDeviceFlags(fs)
+	_ = fs.Parse(args)
+
+	mode, err := truerng.ParseCaptureMode(*modeStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opts := truerng.FeedOptions{DryRun: *dryRun}
+	if dev.selected() {
+		if opts.Device, err = dev.find(); err != nil {
+			log.Fatalf("device detection error: %v", err)
+		}
+	}
+	if *verbose {
+		opts.OnFeed = func(st truerng.FeedStats) {
+			log.Printf("fed %d bytes, credited %d bits", st.Bytes, st.CreditedBits)
+		}
+	}
+	st, err := truerng.FeedKernelEntropyWithOptions(ctx, mode, *credit, opts)
+	fmt.Fprintf(os.Stderr, "fed %d bytes in %d writes, credited %d bits\n", st.Bytes, st.Writes, st.CreditedBits)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatal(err)
+	}
+}
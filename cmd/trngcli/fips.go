@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// fipsTally counts FIPS 140-2 results the way rngtest reports them.
+type fipsTally struct {
+	bits                          int64
+	successes, failures           int64
+	monobit, poker, runs, longRun int64
+	continuous                    int64
+}
+
+// add records one block's result; repeated reports whether the block failed
+// the continuous test.
+func (t *fipsTally) add(r truerng.FIPSResult, repeated bool) {
+	t.bits += truerng.FIPS1402Bytes * 8
+	if !r.MonobitPass {
+		t.monobit++
+	}
+	if !r.PokerPass {
+		t.poker++
+	}
+	if !r.RunsPass {
+		t.runs++
+	}
+	if !r.LongRunPass {
+		t.longRun++
+	}
+	if repeated {
+		t.continuous++
+	}
+	if r.Pass() && !repeated {
+		t.successes++
+	} else {
+		t.failures++
+	}
+}
+
+// print writes the counts in rngtest's layout.
+func (t *fipsTally) print(w io.Writer) {
+	fmt.Fprintf(w, "trngcli: bits received from input: %d\n", t.bits)
+	fmt.Fprintf(w, "trngcli: FIPS 140-2 successes: %d\n", t.successes)
+	fmt.Fprintf(w, "trngcli: FIPS 140-2 failures: %d\n", t.failures)
+	fmt.Fprintf(w, "trngcli: FIPS 140-2(2001-10-10) Monobit: %d\n", t.monobit)
+	fmt.Fprintf(w, "trngcli: FIPS 140-2(2001-10-10) Poker: %d\n", t.poker)
+	fmt.Fprintf(w, "trngcli: FIPS 140-2(2001-10-10) Runs: %d\n", t.runs)
+	fmt.Fprintf(w, "trngcli: FIPS 140-2(2001-10-10) Long run: %d\n", t.longRun)
+	fmt.Fprintf(w, "trngcli: FIPS 140-2(2001-10-10) Continuous run: %d\n", t.continuous)
+}
+
+// runFIPS implements `trngcli fips [-mode m] [-blocks n] [-every n]`: an
+// in-process rngtest. It runs the FIPS 140-2 tests over consecutive
+// 20,000-bit blocks from the device and prints rolling pass/fail counts.
+func runFIPS(args []string) {
+	fs := flag.NewFlagSet("fips", flag.ExitOnError)
+	modeStr := fs.String("mode", "normal", "capture mode")
+	blocks := fs.Int("blocks", 0, "stop after this many 20000-bit blocks (0: until Ctrl+C)")
+	every := fs.Int("every", 100, "print the running counts every N blocks (0: only at the end)")
+	dev := addDeviceFlags(fs)
+	_ = fs.Parse(args)
+
+	mode, err := truerng.ParseCaptureMode(*modeStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	device, err := dev.find()
+	if err != nil {
+		log.Fatalf("device detection error: %v", err)
+	}
+	s, err := truerng.OpenDevice(*device, mode)
+	if err != nil {
+		udevHint(err, device.Model)
+		log.Fatal(err)
+	}
+	defer s.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := fipsLoop(ctx, s.Read, *blocks, *every, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fipsLoop tests blocks 20,000-bit blocks from read (0: until ctx ends),
+// printing the counts to w every every blocks. The final counts are always
+// printed on the way out, whether the run completed, was interrupted or
+// failed to read.
+func fipsLoop(ctx context.Context, read func(bitCount int) ([]byte, error), blocks, every int, w io.Writer) error {
+	var tally fipsTally
+	var ct truerng.ContinuousTest
+	defer tally.print(w)
+	for n := 1; blocks == 0 || n <= blocks; n++ {
+		if ctx.Err() != nil {
+			return nil
+		}
+		block, err := read(truerng.FIPS1402Bytes * 8)
+		if err != nil {
+			return err
+		}
+		res := truerng.FIPS140Block(block)
+		if res.Err != nil {
+			return res.Err
+		}
+		repeated := false
+		for off := 0; off+truerng.FIPSBlockSize <= len(block); off += truerng.FIPSBlockSize {
+			if errors.Is(ct.Check(block[off:off+truerng.FIPSBlockSize]), truerng.ErrRepeatedBlock) {
+				repeated = true
+			}
+		}
+		tally.add(res.FIPSResult, repeated)
+		if every > 0 && n%every == 0 && n != blocks {
+			tally.print(w)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// fipsReader returns a read func handing out random blocks. Once calls
+// blocks have been read, the next read calls then (if set) and, if err is
+// set, fails with it instead of returning a block.
+func fipsReader(calls int, then func(), err error) func(int) ([]byte, error) {
+	src := rand.NewChaCha8([32]byte{7})
+	n := 0
+	return func(bitCount int) ([]byte, error) {
+		if n == calls {
+			if then != nil {
+				then()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		n++
+		b := make([]byte, bitCount/8)
+		_, _ = src.Read(b)
+		return b, nil
+	}
+}
+
+func TestFIPSLoopPrintsSummary(t *testing.T) {
+	const summary = "trngcli: bits received from input: "
+	bits := func(n int) string {
+		return fmt.Sprintf("%s%d\n", summary, n*truerng.FIPS1402Bytes*8)
+	}
+
+	// Completing on a print boundary prints the final counts once.
+	var out bytes.Buffer
+	if err := fipsLoop(context.Background(), fipsReader(-1, nil, nil), 4, 2, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), summary); got != 2 {
+		t.Errorf("4 blocks every 2: %d summaries, want 2", got)
+	}
+	if !strings.HasSuffix(out.String(), "Continuous run: 0\n") || !strings.Contains(out.String(), bits(4)) {
+		t.Errorf("final summary missing:\n%s", out.String())
+	}
+
+	// Ctrl+C during the read of a block that lands on a print boundary
+	// still prints the final counts.
+	out.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := fipsLoop(ctx, fipsReader(1, cancel, nil), 0, 2, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), summary); got != 2 {
+		t.Errorf("interrupted on a boundary: %d summaries, want 2\n%s", got, out.String())
+	}
+	if !strings.Contains(out.String(), bits(2)) {
+		t.Errorf("interrupted run: final summary does not count 2 blocks:\n%s", out.String())
+	}
+
+	// A read error returns it after printing the counts so far.
+	out.Reset()
+	errBoom := errors.New("boom")
+	if err := fipsLoop(context.Background(), fipsReader(1, nil, errBoom), 0, 0, &out); !errors.Is(err, errBoom) {
+		t.Fatalf("err = %v, want %v", err, errBoom)
+	}
+	if !strings.Contains(out.String(), bits(1)) {
+		t.Errorf("read error: summary does not count 1 block:\n%s", out.String())
+	}
+}
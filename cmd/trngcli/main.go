@@ -8,23 +8,90 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/Thiagojm/rng_cli_linux/truerng"
 )
 
 func main() {
-	bits := flag.Int("bits", 1024, "number of bits to read per batch")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "monitor":
+			runMonitor(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "pipe":
+			runPipe(os.Args[2:])
+			return
+		case "feed":
+			runFeed(os.Args[2:])
+			return
+		case "token":
+			runToken(os.Args[2:])
+			return
+		case "fips":
+			runFIPS(os.Args[2:])
+			return
+		}
+	}
+
+	bits := flag.Int("bits", 0, "number of bits to read per batch (0: the device model's recommended block size)")
 	interval := flag.Duration("interval", 0, "interval between reads (e.g. 2s). 0 for one-shot")
 	modeStr := flag.String("mode", "normal", "(deprecated - now uses default serial configuration)")
 	list := flag.Bool("list", false, "list all detected TrueRNG devices")
+	modes := flag.Bool("modes", false, "print the capture modes with their baud rates and supported models")
 	reconnect := flag.Bool("reconnect", false, "enable automatic reconnection on device disconnection")
+	shard := flag.String("shard", "", "with -interval, also write raw bytes to time-sharded files: hourly|daily")
+	shardPrefix := flag.String("shard-prefix", "data/capture", "path prefix for -shard files")
+	dev := addDeviceFlags(flag.CommandLine)
+	jitter := flag.Duration("jitter", 0, "randomize each interval by up to ± this amount (e.g. 500ms)")
+	probe := flag.Bool("probe", false, "if port enumeration fails, probe /dev/ttyACM* and /dev/ttyUSB* directly")
+	metricsCSV := flag.String("metrics-csv", "", "with -interval, append per-batch throughput/entropy metrics to this CSV file")
+	recordSize := flag.Int("record-size", 0, "frame printed output into records of this many bytes, one hex record per line")
+	recordPad := flag.Bool("record-pad", true, "with -record-size, zero-pad a short final record instead of dropping it")
+	format := flag.String("format", "hex", "stdout format: hex (one line per batch) | hexstream (concatenated hex, no separators) | json | csv")
+	entropy := flag.Bool("entropy", false, "tag each printed batch with its Shannon entropy (bits/byte)")
+	ringSize := flag.Int("ring", 0, "with -interval, keep the last N bytes read and dump them as hex to stderr on a read error")
+	rejectStuck := flag.Bool("reject-stuck", false, "with -interval, stop with an error when a batch is all 0x00 or all 0xFF (dead source or dangling UART)")
+	continuousTest := flag.Bool("continuous-test", false, "with -interval, stop with an error when a batch repeats the previous one (FIPS 140-2 continuous test)")
+	delayFirst := flag.Bool("delay-first", false, "with -interval, wait one interval before the first read instead of reading immediately")
+	waitDevice := flag.Duration("wait-device", 0, "keep looking for the device (selected or first detected) this long at startup instead of failing at once (e.g. 30s)")
+	debias := flag.Bool("debias", false, "with -interval, von Neumann debias each batch (for raw/unwhitened modes; batches come out shorter and variable-length)")
+	whiten := flag.String("whiten", "none", "with -interval, post-process each batch: none, vonneumann (same as -debias) or balancefold (XOR the halves; half length, weaker but cheaper)")
+	xorPrev := flag.Bool("xor-prev", false, "with -interval, emit each batch XORed with the previous raw batch (the first read only primes it)")
+	teePath := flag.String("tee", "", "also write the raw bytes to this file, alongside the formatted stdout output")
+	teeMaxBytes := flag.Int64("tee-max-bytes", 0, "rotate the -tee file to <file>.<n> when it would grow past this size (0: never)")
+	teeMaxFiles := flag.Int("tee-max-files", 0, "with -tee-max-bytes, keep only the newest N rotated files (0: keep all)")
+	teeSplit := flag.Bool("tee-split-on-reconnect", false, "with -reconnect, move the -tee file to <file>.<n> and start a new one after each reconnect, so every file is one unbroken run")
+	outPath := flag.String("out", "", "also write raw bytes to this file, plus a <file>.manifest.json sidecar")
+	stream := flag.Bool("stream", false, "write raw bytes to stdout continuously until Ctrl+C or the reader goes away (e.g. trngcli -stream > entropy.bin)")
+	dryRun := flag.Bool("dry-run", false, "print the resolved device, mode and read parameters, then exit without opening the port")
 	flag.Parse()
 
+	truerng.ProbeFallback = *probe
+
+	// Except for the default hex format, stdout carries nothing but the
+	// formatted (or -stream raw) data; status messages go to stderr.
+	var info io.Writer = os.Stdout
+	if *format != "hex" || *stream {
+		info = os.Stderr
+	}
+
+	if *modes {
+		printModes(os.Stdout)
+		return
+	}
+
 	// List devices if requested
 	if *list {
 		if err := truerng.ListDevices(); err != nil {
@@ -33,69 +100,286 @@ func main() {
 		return
 	}
 
-	// Parse capture mode
-	var mode truerng.CaptureMode
-	switch strings.ToLower(*modeStr) {
-	case "normal":
-		mode = truerng.ModeNormal
-	case "psdebug":
-		mode = truerng.ModePSDebug
-	case "rngdebug":
-		mode = truerng.ModeRNGDebug
-	case "rng1white":
-		mode = truerng.ModeRNG1White
-	case "rng2white":
-		mode = truerng.ModeRNG2White
-	case "raw_bin":
-		mode = truerng.ModeRawBin
-	case "raw_asc":
-		mode = truerng.ModeRawASC
-	case "unwhitened":
-		mode = truerng.ModeUnwhitened
-	case "normal_asc":
-		mode = truerng.ModeNormalASC
-	case "normal_asc_slow":
-		mode = truerng.ModeNormalASCSlow
-	default:
-		log.Fatalf("unknown mode: %s", *modeStr)
+	mode, err := truerng.ParseCaptureMode(*modeStr)
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	// Detect device and show info
-	device, err := truerng.FindDevice()
+	device, err := findWaiting(dev.find, *waitDevice)
 	if err != nil {
 		log.Fatalf("device detection error: %v", err)
 	}
 
-	fmt.Printf("Using TrueRNG device: %s on %s (Model: %s)\n",
+	fmt.Fprintf(info, "Using TrueRNG device: %s on %s (Model: %s)\n",
 		device.Name, device.Port, device.Model.String())
-	fmt.Printf("Using default serial configuration (no mode switching)\n")
+	fmt.Fprintf(info, "Using default serial configuration (no mode switching)\n")
+
+	if *bits == 0 {
+		*bits = device.Model.RecommendedBlockSize() * 8
+	}
+	// -dry-run stops here, before anything below opens the port.
+	if *dryRun {
+		plan, err := truerng.PlanRead(*device, mode, *bits, *interval, *reconnect)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printPlan(os.Stdout, plan)
+		return
+	}
+	if *stream {
+		s, err := truerng.OpenDevice(*device, mode)
+		if err != nil {
+			udevHint(err, device.Model)
+			log.Fatal(err)
+		}
+		defer s.Close()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		n, err := s.StreamTo(ctx, os.Stdout)
+		log.Printf("streamed %d bytes", n)
+		if err != nil {
+			log.Fatalf("stream error: %v", err)
+		}
+		return
+	}
+	printer, err := newBatchPrinter(os.Stdout, *format, *bits, *recordSize, *recordPad)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sinks := truerng.NewTeeSink()
+	defer func() {
+		if err := sinks.Close(); err != nil {
+			log.Printf("close output: %v", err)
+		}
+	}()
+	if *outPath != "" {
+		c, err := openCaptureFile(*outPath, *device, mode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sinks.Add(c)
+	}
+	var tee *truerng.FileSink
+	if *teePath != "" {
+		if *teeMaxBytes > 0 {
+			tee, err = truerng.NewRotatingFileSink(*teePath, truerng.FileRotation{MaxBytes: *teeMaxBytes, MaxFiles: *teeMaxFiles})
+		} else {
+			tee, err = truerng.NewFileSink(*teePath, true)
+		}
+		if err != nil {
+			log.Fatalf("open tee file: %v", err)
+		}
+		sinks.Add(tee)
+	}
 
 	if *interval == 0 {
-		data, err := truerng.ReadBitsWithMode(*bits, mode)
+		data, err := truerng.ReadBitsFromPort(device.Port, *bits, mode)
 		if err != nil {
+			udevHint(err, device.Model)
 			log.Fatalf("read error: %v", err)
 		}
-		fmt.Printf("read %d bits (%d bytes)\n", *bits, len(data))
-		fmt.Printf("%s\n", hex.EncodeToString(data))
+		fmt.Fprintf(info, "read %d bits (%d bytes)\n", *bits, len(data))
+		if err := printer.Print(truerng.NewBatch(data, *entropy), false); err != nil {
+			log.Fatalf("write stdout: %v", err)
+		}
+		if err := sinks.WriteBatch(data); err != nil {
+			log.Fatalf("write output: %v", err)
+		}
 		return
 	}
 
+	if *shard != "" {
+		period, err := parseShardPeriod(*shard)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s, err := truerng.NewTimeShardedSink(*shardPrefix, ".bin", period)
+		if err != nil {
+			log.Fatalf("shard sink: %v", err)
+		}
+		sinks.Add(s)
+	}
+
+	stats := newCaptureStats()
+	onBatch := func(b truerng.Batch) {
+		stats.Add(b.Data)
+		if err := printer.Print(b, true); err != nil {
+			log.Fatalf("write stdout: %v", err)
+		}
+		if err := sinks.WriteBatch(b.Data); err != nil {
+			log.Fatalf("write output: %v", err)
+		}
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
+	if sinks.Len() > 0 {
+		reopenOnHangup(ctx, sinks)
+	}
+	dumpStatsOnSignal(ctx, stats)
 
+	cfg := truerng.CollectConfig{Mode: mode, Reconnect: *reconnect, IntervalJitter: *jitter, ComputeEntropy: *entropy, RejectStuck: *rejectStuck, ContinuousTest: *continuousTest, DelayFirstRead: *delayFirst, XORWithPrevious: *xorPrev, WaitForDevice: *waitDevice, Debias: *debias}
+	switch *whiten {
+	case "none":
+	case "vonneumann":
+		cfg.Debias = true
+	case "balancefold":
+		cfg.BalanceFold = true
+	default:
+		log.Fatalf("invalid -whiten %q (want none, vonneumann or balancefold)", *whiten)
+	}
+	if dev.selected() {
+		cfg.Port = device.Port
+	}
+	cfg.OnReconnect = stats.Reconnected
+	if *teeSplit && tee != nil {
+		cfg.OnReconnect = func(port string) {
+			stats.Reconnected(port)
+			if err := tee.Split(); err != nil {
+				log.Printf("split tee file: %v", err)
+			}
+		}
+	}
+	if *ringSize > 0 {
+		cfg.Ring = truerng.NewRingTap(*ringSize)
+	}
+	var metrics *truerng.CSVMetrics
+	if *metricsCSV != "" {
+		f, err := os.OpenFile(*metricsCSV, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			log.Fatalf("open metrics file: %v", err)
+		}
+		defer f.Close()
+		metrics = truerng.CSVMetricsSink(f)
+		cfg.OnStats = metrics.Record
+	}
+	schedule := interval.String()
+	if *jitter > 0 {
+		schedule += " ± " + jitter.String()
+	}
 	if *reconnect {
-		log.Printf("reading %d bits every %s with auto-reconnect. press Ctrl+C to stop...", *bits, interval.String())
-		err = truerng.CollectBitsAtIntervalWithReconnect(ctx, *bits, *interval, mode, func(b []byte) {
-			fmt.Printf("%s  %d bits  %s\n", time.Now().Format(time.RFC3339), *bits, hex.EncodeToString(b))
-		})
+		log.Printf("reading %d bits every %s with auto-reconnect. press Ctrl+C to stop...", *bits, schedule)
 	} else {
-		log.Printf("reading %d bits every %s. press Ctrl+C to stop...", *bits, interval.String())
-		err = truerng.CollectBitsAtIntervalWithMode(ctx, *bits, *interval, mode, func(b []byte) {
-			fmt.Printf("%s  %d bits  %s\n", time.Now().Format(time.RFC3339), *bits, hex.EncodeToString(b))
-		})
+		log.Printf("reading %d bits every %s. press Ctrl+C to stop...", *bits, schedule)
 	}
+	err = truerng.CollectBatchesAtInterval(ctx, *bits, *interval, cfg, onBatch)
 
+	if metrics != nil && metrics.Err() != nil {
+		log.Printf("metrics: %v", metrics.Err())
+	}
 	if err != nil && !errors.Is(err, context.Canceled) {
+		if cfg.Ring != nil {
+			last := cfg.Ring.LastBytes()
+			fmt.Fprintf(os.Stderr, "last %d bytes before the error:\n%s\n", len(last), hex.EncodeToString(last))
+		}
+		udevHint(err, device.Model)
 		log.Fatalf("collect error: %v", err)
 	}
 }
+
+// printModes writes the mode table for -modes.
+func printModes(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tMODE\tBAUD\tOUTPUT\tMODELS\tDESCRIPTION")
+	for _, d := range truerng.ModeTable() {
+		output := "binary"
+		if d.IsASCII {
+			output = "ascii"
+		}
+		models := make([]string, len(d.SupportedModels))
+		for i, m := range d.SupportedModels {
+			models[i] = m.String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			d.ShortName, d.Mode, d.BaudRate, output, strings.Join(models, ","), d.Description)
+	}
+	tw.Flush()
+}
+
+// printPlan writes the -dry-run summary.
+func printPlan(w io.Writer, p truerng.ReadPlan) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "device:\t%s (serial %s, model %s)\n", p.Device.Name, p.Device.SerialNumber, p.Device.Model)
+	fmt.Fprintf(tw, "port:\t%s\n", p.Device.Port)
+	fmt.Fprintf(tw, "mode:\t%s (%d baud)\n", p.Mode, p.BaudRate)
+	fmt.Fprintf(tw, "read:\t%d bits (%d bytes)\n", p.BitCount, p.ByteCount)
+	if p.Interval == 0 {
+		fmt.Fprintf(tw, "schedule:\tone-shot\n")
+	} else {
+		fmt.Fprintf(tw, "schedule:\tevery %s (reconnect: %t)\n", p.Interval, p.Reconnect)
+	}
+	fmt.Fprintf(tw, "port timeout:\t%s\n", p.PortTimeout)
+	fmt.Fprintf(tw, "read deadline:\t%s\n", p.ReadDeadline)
+	if p.ExpectedDuration > 0 {
+		fmt.Fprintf(tw, "expected read time:\t%s\n", p.ExpectedDuration.Round(time.Millisecond))
+	}
+	for _, warn := range p.Warnings {
+		fmt.Fprintf(tw, "warning:\t%s\n", warn)
+	}
+	tw.Flush()
+}
+
+// udevHint explains how to fix err if it is a permission problem, printing
+// the udev rule for model and where to install it.
+func udevHint(err error, model truerng.DeviceModel) {
+	if !truerng.IsPermissionDenied(err) {
+		return
+	}
+	installed, path, cerr := truerng.CheckUdevRules()
+	if cerr != nil {
+		return
+	}
+	if installed {
+		fmt.Fprintf(os.Stderr, "permission denied opening the device; check the udev rule in %s and replug it\n", path)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "permission denied opening the device and no TrueRNG udev rule is installed.\n"+
+		"Save the following as %s, then run `sudo udevadm control --reload-rules && sudo udevadm trigger`:\n\n%s\n",
+		path, truerng.SuggestUdevRule(model))
+}
+
+// reopenOnHangup reopens the output files behind r whenever SIGHUP arrives,
+// so logrotate can rename them and signal the process, until ctx is done.
+func reopenOnHangup(ctx context.Context, r interface{ Reopen() error }) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if err := r.Reopen(); err != nil {
+					log.Printf("reopen output: %v", err)
+					continue
+				}
+				log.Printf("SIGHUP: reopened output files")
+			}
+		}
+	}()
+}
+
+// defaultAliasPath returns the per-user alias file location, or an empty
+// string if the config directory cannot be determined.
+func defaultAliasPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "rng_cli_linux", "aliases.json")
+}
+
+// parseShardPeriod maps the -shard flag to a shard period.
+func parseShardPeriod(s string) (truerng.ShardPeriod, error) {
+	switch strings.ToLower(s) {
+	case "hourly":
+		return truerng.ShardHourly, nil
+	case "daily":
+		return truerng.ShardDaily, nil
+	default:
+		return 0, fmt.Errorf("unknown -shard period: %s (allowed: hourly, daily)", s)
+	}
+}
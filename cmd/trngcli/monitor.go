@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// runMonitor implements `trngcli monitor`: it reads continuously and prints a
+// live quality line (rolling entropy, ones ratio, monobit p-value, verdict)
+// that is updated in place until Ctrl+C.
+func runMonitor(args []string) {
+	def := truerng.DefaultQualityThresholds()
+	fs := flag.NewFlagSet("monitor", flag.ExitOnError)
+	bits := fs.Int("bits", 32768, "number of bits to read per refresh")
+	refresh := fs.Duration("refresh", time.Second, "interval between reads and status updates")
+	window := fs.Int("window", 65536, "rolling window size in bytes used for the live figures")
+	modeStr := fs.String("mode", "normal", "capture mode")
+	warnEntropy := fs.Float64("warn-entropy", def.WarnEntropy, "WARN when rolling entropy (bits/byte) drops below this")
+	failEntropy := fs.Float64("fail-entropy", def.FailEntropy, "FAIL when rolling entropy (bits/byte) drops below this")
+	warnP := fs.Float64("warn-p", def.WarnPValue, "WARN when the monobit p-value drops below this")
+	failP := fs.Float64("fail-p", def.FailPValue, "FAIL when the monobit p-value drops below this")
+	warnCompress := fs.Float64("warn-compress", 0, "WARN when the gzip compression ratio of the window drops below this (0 disables)")
+	failCompress := fs.Float64("fail-compress", 0, "FAIL when the gzip compression ratio of the window drops below this (0 disables)")
+	shared := fs.Bool("shared", false, "open the port non-exclusively to tap a device another reader is using (Linux; each byte goes to only one reader)")
+	dev := addDeviceFlags(fs)
+	_ = fs.Parse(args)
+
+	if *bits <= 0 {
+		log.Fatal("-bits must be > 0")
+	}
+	if *window <= 0 {
+		log.Fatal("-window must be > 0")
+	}
+	mode, err := truerng.ParseCaptureMode(*modeStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	thresholds := truerng.QualityThresholds{
+		WarnEntropy:          *warnEntropy,
+		FailEntropy:          *failEntropy,
+		WarnPValue:           *warnP,
+		FailPValue:           *failP,
+		WarnCompressionRatio: *warnCompress,
+		FailCompressionRatio: *failCompress,
+	}
+
+	device, err := dev.find()
+	if err != nil {
+		log.Fatalf("device detection error: %v", err)
+	}
+	fmt.Printf("Monitoring TrueRNG device: %s on %s (Model: %s). press Ctrl+C to stop...\n",
+		device.Name, device.Port, device.Model.String())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	meter := truerng.NewStatusMeter()
+	var total truerng.EntropyEstimator
+	counts := map[truerng.Verdict]int{}
+	var rolling []byte
+
+	cfg := truerng.CollectConfig{Mode: mode, SharedRead: *shared}
+	if dev.selected() {
+		cfg.Port = device.Port
+	}
+	err = truerng.CollectBitsAtIntervalWithConfig(ctx, *bits, *refresh, cfg, func(b []byte) {
+		meter.Add(len(b))
+		total.Add(b)
+		rolling = append(rolling, b...)
+		if len(rolling) > *window {
+			rolling = append(rolling[:0], rolling[len(rolling)-*window:]...)
+		}
+
+		r := truerng.AssessQuality(rolling, thresholds)
+		counts[r.Verdict]++
+		compress := ""
+		if r.CompressionRatio > 0 {
+			compress = fmt.Sprintf("  gzip=%.4f", r.CompressionRatio)
+		}
+		fmt.Printf("\r%s  entropy=%.4f  ones=%.4f  monobit_p=%.4f%s  [%s]\033[K",
+			meter.Snapshot(), r.Entropy, r.OnesRatio, r.MonobitP, compress, r.Verdict)
+	})
+	fmt.Println()
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatalf("monitor error: %v", err)
+	}
+
+	fmt.Println("Summary:")
+	fmt.Printf("  %s\n", meter.Snapshot())
+	fmt.Printf("  overall entropy=%.4f bits/byte  min-entropy=%.4f bits/byte  ones=%.4f\n",
+		total.Entropy(), total.MinEntropy(), total.OnesRatio())
+	fmt.Printf("  verdicts: PASS=%d WARN=%d FAIL=%d\n",
+		counts[truerng.VerdictPass], counts[truerng.VerdictWarn], counts[truerng.VerdictFail])
+}
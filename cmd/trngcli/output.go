@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// captureFile writes raw batches to the -out file and, on Close, writes the
+// capture's manifest sidecar next to it. It implements truerng.Sink. The
+// manifest covers every batch written since start, including those that went
+// to files rotated away by Reopen.
+type captureFile struct {
+	file     *truerng.FileSink
+	manifest *truerng.ManifestBuilder
+}
+
+func openCaptureFile(path string, device truerng.DeviceInfo, mode truerng.CaptureMode) (*captureFile, error) {
+	f, err := truerng.NewFileSink(path, true)
+	if err != nil {
+		return nil, fmt.Errorf("open output file: %w", err)
+	}
+	return &captureFile{file: f, manifest: truerng.NewManifestBuilder(device, mode)}, nil
+}
+
+func (c *captureFile) WriteBatch(b []byte) error {
+	if err := c.file.WriteBatch(b); err != nil {
+		return err
+	}
+	c.manifest.Add(b)
+	return nil
+}
+
+// Reopen reopens the output file at its original path (see truerng.FileSink).
+func (c *captureFile) Reopen() error {
+	return c.file.Reopen()
+}
+
+func (c *captureFile) Close() error {
+	if err := c.file.Close(); err != nil {
+		return err
+	}
+	return truerng.WriteManifest(truerng.ManifestPath(c.file.Path()), c.manifest.Manifest())
+}
+
+// batchPrinter writes batches to stdout in the -format chosen on the command
+// line: hex (one line per batch), hexstream (concatenated hex), json (one
+// object per line) or csv.
+type batchPrinter struct {
+	w          io.Writer
+	format     string
+	bits       int
+	recordSize int
+	recordPad  bool
+
+	stream    io.Writer
+	csv       *csv.Writer
+	csvHeader bool
+}
+
+func newBatchPrinter(w io.Writer, format string, bits, recordSize int, recordPad bool) (*batchPrinter, error) {
+	switch format {
+	case "hex", "hexstream", "json", "csv":
+	default:
+		return nil, fmt.Errorf("unknown format: %s (want hex, hexstream, json or csv)", format)
+	}
+	if recordSize > 0 && format != "hex" {
+		return nil, fmt.Errorf("-record-size cannot be combined with -format %s", format)
+	}
+	return &batchPrinter{
+		w:          w,
+		format:     format,
+		bits:       bits,
+		recordSize: recordSize,
+		recordPad:  recordPad,
+		stream:     hex.NewEncoder(w),
+		csv:        csv.NewWriter(w),
+	}, nil
+}
+
+// Print writes one batch. stamped selects the timestamped hex line used for
+// interval reads over the bare hex of a one-shot read.
+func (p *batchPrinter) Print(b truerng.Batch, stamped bool) error {
+	// Post-processing such as -debias changes the batch length.
+	bits := p.bits
+	if len(b.Data) != (p.bits+7)/8 {
+		bits = len(b.Data) * 8
+	}
+	switch {
+	case p.format == "hexstream":
+		_, err := p.stream.Write(b.Data)
+		return err
+	case p.format == "json":
+		return json.NewEncoder(p.w).Encode(truerng.NewBatchJSON(b, bits))
+	case p.format == "csv":
+		if !p.csvHeader {
+			if err := p.csv.Write([]string{"timestamp", "bytes", "entropy_bits_per_byte", "hex"}); err != nil {
+				return err
+			}
+			p.csvHeader = true
+		}
+		entropy := ""
+		if b.HasEntropy {
+			entropy = strconv.FormatFloat(b.Entropy, 'f', 4, 64)
+		}
+		if err := p.csv.Write([]string{b.Time.Format(time.RFC3339), strconv.Itoa(len(b.Data)), entropy, hex.EncodeToString(b.Data)}); err != nil {
+			return err
+		}
+		p.csv.Flush()
+		return p.csv.Error()
+	case p.recordSize > 0:
+		for _, r := range truerng.SplitRecords(b.Data, p.recordSize, p.recordPad) {
+			if _, err := fmt.Fprintln(p.w, hex.EncodeToString(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	line := hex.EncodeToString(b.Data)
+	if stamped {
+		line = fmt.Sprintf("%s  %d bits  %s", b.Time.Format(time.RFC3339), bits, line)
+	}
+	if b.HasEntropy {
+		line += fmt.Sprintf("  entropy=%.4f", b.Entropy)
+	}
+	_, err := fmt.Fprintln(p.w, line)
+	return err
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+func TestHexStreamConcatenatesBatches(t *testing.T) {
+	var out bytes.Buffer
+	p, err := newBatchPrinter(&out, "hexstream", 32, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches := [][]byte{{0xde, 0xad, 0xbe, 0xef}, {0x00, 0x01, 0x02, 0x03}, {0xff, 0x10, 0x20, 0x30}}
+	var all []byte
+	for _, data := range batches {
+		if err := p.Print(truerng.Batch{Time: time.Now(), Data: data}, true); err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, data...)
+	}
+	if got, want := out.String(), hex.EncodeToString(all); got != want {
+		t.Errorf("hexstream output %q, want %q", got, want)
+	}
+}
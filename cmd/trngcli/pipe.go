@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// runPipe implements `trngcli pipe [-mode m] [-device alias] -- command [args...]`: it runs
+// command with device bytes on its stdin until the command exits, then exits
+// with the command's exit code.
+func runPipe(args []string) {
+	fs := flag.NewFlagSet("pipe", flag.ExitOnError)
+	modeStr := fs.String("mode", "normal", "capture mode")
+	dev := addDeviceFlags(fs)
+	_ = fs.Parse(args)
+
+	argv := fs.Args()
+	if len(argv) == 0 {
+		log.Fatal("usage: trngcli pipe [-mode m] [-device alias] -- command [args...]")
+	}
+	mode, err := truerng.ParseCaptureMode(*modeStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	device, err := dev.find()
+	if err != nil {
+		log.Fatalf("device detection error: %v", err)
+	}
+	s, err := truerng.OpenDevice(*device, mode)
+	if err != nil {
+		udevHint(err, device.Model)
+		log.Fatal(err)
+	}
+	defer s.Close()
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		log.Fatalf("start %s: %v", argv[0], err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	pipeErr := make(chan error, 1)
+	go func() {
+		_, err := s.PipeTo(ctx, stdin)
+		_ = stdin.Close()
+		pipeErr <- err
+	}()
+
+	waitErr := cmd.Wait()
+	cancel()
+	if err := <-pipeErr; err != nil {
+		log.Printf("pipe: %v", err)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(waitErr, &exitErr):
+		os.Exit(exitErr.ExitCode())
+	case waitErr != nil:
+		log.Fatalf("%s: %v", argv[0], waitErr)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+	"github.com/Thiagojm/rng_cli_linux/truerng/httpd"
+)
+
+// runServe implements `trngcli serve`: an HTTP endpoint returning random
+// bytes, e.g. `curl 'localhost:8080/?bytes=64&format=hex'`.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "listen address")
+	modeStr := fs.String("mode", "normal", "capture mode")
+	token := fs.String("token", "", "require `Authorization: Bearer <token>` on every request")
+	rate := fs.Int("rate", 0, "per-client rate limit in bytes/second (0 disables)")
+	dev := addDeviceFlags(fs)
+	_ = fs.Parse(args)
+
+	mode, err := truerng.ParseCaptureMode(*modeStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := []httpd.Option{httpd.WithMode(mode)}
+	if dev.selected() {
+		device, err := dev.find()
+		if err != nil {
+			log.Fatalf("device detection error: %v", err)
+		}
+		opts = append(opts, httpd.WithPort(device.Port))
+	}
+	if *token != "" {
+		opts = append(opts, httpd.WithAuth(*token))
+	}
+	if *rate > 0 {
+		opts = append(opts, httpd.WithClientRateLimit(*rate))
+	}
+
+	log.Printf("serving random bytes on http://%s/?bytes=N", *addr)
+	log.Fatal(http.ListenAndServe(*addr, httpd.Handler(opts...)))
+}
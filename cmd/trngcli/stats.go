@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// captureStats accumulates the figures of a SIGUSR1 stats dump. It is safe
+// for concurrent use.
+type captureStats struct {
+	meter *truerng.StatusMeter
+
+	mu         sync.Mutex
+	est        truerng.EntropyEstimator
+	reconnects int64
+}
+
+func newCaptureStats() *captureStats {
+	return &captureStats{meter: truerng.NewStatusMeter()}
+}
+
+// Add records a delivered batch.
+func (s *captureStats) Add(data []byte) {
+	s.meter.Add(len(data))
+	s.mu.Lock()
+	s.est.Add(data)
+	s.mu.Unlock()
+}
+
+// Reconnected counts a reconnection; it fits CollectConfig.OnReconnect.
+func (s *captureStats) Reconnected(string) {
+	s.mu.Lock()
+	s.reconnects++
+	s.mu.Unlock()
+}
+
+// String formats the current figures as one line.
+func (s *captureStats) String() string {
+	snap := s.meter.Snapshot()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return formatStats(snap, s.est.Entropy(), s.reconnects)
+}
+
+// formatStats renders a stats dump line.
+func formatStats(snap truerng.StatusSnapshot, entropy float64, reconnects int64) string {
+	return fmt.Sprintf("stats: %s  entropy=%.4f bits/byte  reconnects=%d", snap, entropy, reconnects)
+}
+
+// dumpStatsOnSignal prints s to stderr whenever SIGUSR1 arrives, until ctx is
+// done. It does nothing where SIGUSR1 does not exist.
+func dumpStatsOnSignal(ctx context.Context, s *captureStats) {
+	sig := make(chan os.Signal, 1)
+	if !notifyStatsSignal(sig) {
+		return
+	}
+	go func() {
+		defer signal.Stop(sig)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sig:
+				fmt.Fprintln(os.Stderr, s)
+			}
+		}
+	}()
+}
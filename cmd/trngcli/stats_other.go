@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// notifyStatsSignal reports that there is no SIGUSR1 on this platform.
+func notifyStatsSignal(c chan<- os.Signal) bool {
+	return false
+}
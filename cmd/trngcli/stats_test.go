@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+func TestFormatStats(t *testing.T) {
+	snap := truerng.StatusSnapshot{Elapsed: 90*time.Second + 400*time.Millisecond, Bytes: 123456, Batches: 12, BytesPerSec: 1371.7}
+	got := formatStats(snap, 7.99812, 3)
+	want := "stats: 1m30s  123456 bytes  12 batches  1371.7 B/s  entropy=7.9981 bits/byte  reconnects=3"
+	if got != want {
+		t.Errorf("formatStats =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestCaptureStats(t *testing.T) {
+	s := newCaptureStats()
+	// 256 distinct byte values once each: exactly 8 bits per byte.
+	var all []byte
+	for i := range 256 {
+		all = append(all, byte(i))
+	}
+	s.Add(all[:128])
+	s.Add(all[128:])
+	s.Reconnected("/dev/ttyACM0")
+
+	got := s.String()
+	for _, part := range []string{"256 bytes", "2 batches", "entropy=8.0000", "reconnects=1"} {
+		if !strings.Contains(got, part) {
+			t.Errorf("%q lacks %q", got, part)
+		}
+	}
+	s.Add(bytes.Repeat([]byte{0}, 256))
+	if !strings.Contains(s.String(), "512 bytes") {
+		t.Errorf("after a third batch: %q", s.String())
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// runToken implements `trngcli token [-bits n] [-encoding e] [-mode m]`: it
+// prints one random token, e.g. for an API key.
+func runToken(args []string) {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	bits := fs.Int("bits", 128, "token entropy in bits (rounded up to whole bytes)")
+	encStr := fs.String("encoding", "hex", "token encoding: hex, base32 or base64url")
+	modeStr := fs.String("mode", "normal", "capture mode")
+	dev := addDeviceFlags(fs)
+	_ = fs.Parse(args)
+
+	enc, err := truerng.ParseEncoding(*encStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mode, err := truerng.ParseCaptureMode(*modeStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var token string
+	model := truerng.DeviceModelUnknown
+	if dev.selected() {
+		var device *truerng.DeviceInfo
+		if device, err = dev.find(); err != nil {
+			log.Fatalf("device detection error: %v", err)
+		}
+		model = device.Model
+		token, err = truerng.GenerateTokenFromPort(device.Port, *bits, enc, mode)
+	} else {
+		token, err = truerng.GenerateToken(*bits, enc, mode)
+	}
+	if err != nil {
+		udevHint(err, model)
+		log.Fatal(err)
+	}
+	fmt.Println(token)
+}
@@ -6,6 +6,7 @@ require (
 	github.com/google/gousb v1.1.3
 	github.com/xuri/excelize/v2 v2.8.1
 	go.bug.st/serial v1.6.4
+	nhooyr.io/websocket v1.8.17
 )
 
 require (
@@ -0,0 +1,41 @@
+package truerng
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadAliases reads a device alias file mapping nicknames to USB serial
+// numbers. The file is a JSON object, for example:
+//
+//	{"lab-rng-1": "TR0012345", "lab-rng-2": "TR0012346"}
+func LoadAliases(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read alias file: %w", err)
+	}
+	aliases := map[string]string{}
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("parse alias file %s: %w", path, err)
+	}
+	return aliases, nil
+}
+
+// FindDeviceByAlias resolves alias through the alias file at path and returns
+// the connected device with the mapped serial number.
+func FindDeviceByAlias(path, alias string) (*DeviceInfo, error) {
+	aliases, err := LoadAliases(path)
+	if err != nil {
+		return nil, err
+	}
+	serial, ok := aliases[alias]
+	if !ok {
+		return nil, fmt.Errorf("unknown device alias %q (not in %s)", alias, path)
+	}
+	device, err := FindDeviceBySerial(serial)
+	if err != nil {
+		return nil, fmt.Errorf("alias %q: %w", alias, err)
+	}
+	return device, nil
+}
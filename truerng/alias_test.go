@@ -0,0 +1,49 @@
+package truerng
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeAliases(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindDeviceByAlias(t *testing.T) {
+	fakePorts(t,
+		trueRNGPort("/dev/ttyFAKE0", "TR0001"),
+		trueRNGPort("/dev/ttyFAKE1", "TR0002"),
+	)
+	path := writeAliases(t, `{"lab-rng-1": "TR0002", "spare": "TR0099"}`)
+
+	device, err := FindDeviceByAlias(path, "lab-rng-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device.Port != "/dev/ttyFAKE1" || device.SerialNumber != "TR0002" {
+		t.Errorf("lab-rng-1 resolved to %s (serial %s), want /dev/ttyFAKE1 (TR0002)", device.Port, device.SerialNumber)
+	}
+
+	if _, err := FindDeviceByAlias(path, "spare"); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("alias of an absent serial: err = %v, want ErrDeviceNotFound", err)
+	}
+	if _, err := FindDeviceByAlias(path, "nope"); err == nil {
+		t.Error("unknown alias: want an error")
+	}
+}
+
+func TestLoadAliasesErrors(t *testing.T) {
+	if _, err := LoadAliases(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file: want an error")
+	}
+	if _, err := LoadAliases(writeAliases(t, `["not", "an", "object"]`)); err == nil {
+		t.Error("malformed file: want an error")
+	}
+}
@@ -0,0 +1,131 @@
+package truerng
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+// Autocorrelation returns the lag-bit autocorrelation coefficient of data,
+// read MSB-first as a bit stream: each bit is mapped to ±1 and the products of
+// bits lag apart are averaged. Independent, unbiased bits give values near 0
+// (within about 1/sqrt(n) for n bits); a stream that repeats with period lag
+// gives +1 and one that flips every lag bits gives -1. Strong bias also pulls
+// the value towards +1. It returns 0 if lag is not positive or data has no
+// pair of bits lag apart.
+func Autocorrelation(data []byte, lag int) float64 {
+	n := len(data) * 8
+	if lag <= 0 || lag >= n {
+		return 0
+	}
+	agree := 0
+	for i := 0; i+lag < n; i++ {
+		if bitAt(data, i) == bitAt(data, i+lag) {
+			agree++
+		}
+	}
+	pairs := n - lag
+	return float64(2*agree-pairs) / float64(pairs)
+}
+
+// AutocorrMonitor computes the Autocorrelation coefficient of a stream over
+// consecutive windows, carrying bits across batch boundaries, and raises an
+// alarm for every window whose |coefficient| exceeds the threshold. It can be
+// fed directly (Write) or attached to a collect loop via
+// CollectConfig.Autocorr. It is safe for concurrent use.
+type AutocorrMonitor struct {
+	mu        sync.Mutex
+	lag       int
+	window    int
+	threshold float64
+	onAlarm   func(corr float64)
+
+	hist   []byte // the last lag bits, indexed by position modulo lag
+	seen   int64  // bits seen since creation or Reset
+	agree  int    // agreeing pairs in the current window
+	pairs  int    // pairs in the current window
+	last   float64
+	alarms int64
+}
+
+// NewAutocorrMonitor returns a monitor for the given bit lag that evaluates
+// every window bit pairs and calls onAlarm (if set) with the coefficient of
+// each window where |corr| > threshold. onAlarm runs on the goroutine that
+// completed the window, after the monitor's lock is released. Windows of a
+// few thousand bits or more keep false alarms rare at thresholds around 0.1.
+func NewAutocorrMonitor(lag, window int, threshold float64, onAlarm func(corr float64)) (*AutocorrMonitor, error) {
+	if lag <= 0 {
+		return nil, errors.New("lag must be positive")
+	}
+	if window <= 0 {
+		return nil, errors.New("window must be positive")
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, errors.New("threshold must be between 0 and 1")
+	}
+	return &AutocorrMonitor{
+		lag:       lag,
+		window:    window,
+		threshold: threshold,
+		onAlarm:   onAlarm,
+		hist:      make([]byte, lag),
+	}, nil
+}
+
+// Write feeds p into the monitor. It never fails and implements io.Writer.
+func (m *AutocorrMonitor) Write(p []byte) (int, error) {
+	var raised []float64
+	m.mu.Lock()
+	lag := int64(m.lag)
+	for i := 0; i < len(p)*8; i++ {
+		bit := bitAt(p, i)
+		slot := m.seen % lag
+		if m.seen >= lag {
+			m.pairs++
+			if m.hist[slot] == bit {
+				m.agree++
+			}
+		}
+		m.hist[slot] = bit
+		m.seen++
+		if m.pairs == m.window {
+			m.last = float64(2*m.agree-m.pairs) / float64(m.pairs)
+			m.agree, m.pairs = 0, 0
+			if math.Abs(m.last) > m.threshold {
+				m.alarms++
+				raised = append(raised, m.last)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if m.onAlarm != nil {
+		for _, corr := range raised {
+			m.onAlarm(corr)
+		}
+	}
+	return len(p), nil
+}
+
+// Last returns the coefficient of the most recently completed window, or 0
+// before the first window completes.
+func (m *AutocorrMonitor) Last() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+// Alarms returns how many windows have exceeded the threshold.
+func (m *AutocorrMonitor) Alarms() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.alarms
+}
+
+// Reset discards the bit history, the partial window and the alarm count.
+func (m *AutocorrMonitor) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.hist)
+	m.seen, m.agree, m.pairs, m.last, m.alarms = 0, 0, 0, 0, 0
+}
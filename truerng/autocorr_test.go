@@ -0,0 +1,72 @@
+package truerng
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestAutocorrelation(t *testing.T) {
+	random := randomBytes(1, 1<<14)
+	for lag := 1; lag <= 16; lag++ {
+		// 3 standard deviations for ~128k pairs.
+		if c := Autocorrelation(random, lag); math.Abs(c) > 0.01 {
+			t.Errorf("random lag %d: corr = %.4f, want near 0", lag, c)
+		}
+	}
+
+	// A pattern repeating every 8 bits is perfectly correlated at lag 8,
+	// and 0x55 flips on every bit, so lag 1 is perfectly anti-correlated.
+	pattern := bytes.Repeat([]byte{0xA7}, 1024)
+	if c := Autocorrelation(pattern, 8); c != 1 {
+		t.Errorf("repeating byte lag 8: corr = %v, want 1", c)
+	}
+	if c := Autocorrelation(bytes.Repeat([]byte{0x55}, 1024), 1); c != -1 {
+		t.Errorf("alternating bits lag 1: corr = %v, want -1", c)
+	}
+
+	if c := Autocorrelation(random[:1], 8); c != 0 {
+		t.Errorf("lag beyond data: corr = %v, want 0", c)
+	}
+	if c := Autocorrelation(random, 0); c != 0 {
+		t.Errorf("lag 0: corr = %v, want 0", c)
+	}
+}
+
+func TestAutocorrMonitor(t *testing.T) {
+	var raised []float64
+	mon, err := NewAutocorrMonitor(8, 4096, 0.1, func(c float64) { raised = append(raised, c) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fed in odd-sized batches so the bit history has to carry across them.
+	random := randomBytes(2, 1<<13)
+	for len(random) > 0 {
+		n := min(len(random), 37)
+		_, _ = mon.Write(random[:n])
+		random = random[n:]
+	}
+	if mon.Alarms() != 0 || len(raised) != 0 {
+		t.Fatalf("random input raised %d alarms (%v)", mon.Alarms(), raised)
+	}
+	if math.Abs(mon.Last()) > 0.1 {
+		t.Errorf("random Last = %.4f", mon.Last())
+	}
+
+	_, _ = mon.Write(bytes.Repeat([]byte{0x3C, 0x3C, 0x3C, 0x3C}, 1024))
+	if mon.Alarms() == 0 || len(raised) != int(mon.Alarms()) || mon.Last() != 1 {
+		t.Errorf("repeated pattern: alarms = %d, callbacks = %d, Last = %v", mon.Alarms(), len(raised), mon.Last())
+	}
+
+	mon.Reset()
+	if mon.Alarms() != 0 || mon.Last() != 0 {
+		t.Errorf("after Reset: alarms = %d, Last = %v", mon.Alarms(), mon.Last())
+	}
+
+	for _, bad := range [][3]float64{{0, 10, 0.1}, {8, 0, 0.1}, {8, 10, 1.5}} {
+		if _, err := NewAutocorrMonitor(int(bad[0]), int(bad[1]), bad[2], nil); err == nil {
+			t.Errorf("NewAutocorrMonitor%v accepted", bad)
+		}
+	}
+}
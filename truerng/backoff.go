@@ -0,0 +1,53 @@
+package truerng
+
+import "time"
+
+// ReconnectConfig sets the backoff between reconnection attempts of the
+// Reconnect collect loop. Zero fields take the defaults: 500ms initial delay,
+// doubling up to 30s.
+type ReconnectConfig struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Factor       float64
+}
+
+// Backoff produces capped exponentially growing delays: Initial, then each
+// delay Factor times the previous one, never more than Max. The zero value
+// uses the ReconnectConfig defaults. It is not safe for concurrent use.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	Factor  float64
+
+	next time.Duration
+}
+
+// NewBackoff returns a Backoff configured from cfg.
+func NewBackoff(cfg ReconnectConfig) *Backoff {
+	return &Backoff{Initial: cfg.InitialDelay, Max: cfg.MaxDelay, Factor: cfg.Factor}
+}
+
+// Next returns the delay to wait now and advances the sequence.
+func (b *Backoff) Next() time.Duration {
+	initial, maxDelay, factor := b.Initial, b.Max, b.Factor
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+	if factor < 1 {
+		factor = 2
+	}
+	if b.next <= 0 {
+		b.next = initial
+	}
+	d := min(b.next, maxDelay)
+	b.next = time.Duration(float64(d) * factor)
+	return d
+}
+
+// Reset starts the sequence over at Initial.
+func (b *Backoff) Reset() {
+	b.next = 0
+}
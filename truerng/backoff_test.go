@@ -0,0 +1,42 @@
+package truerng
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBackoffSequence(t *testing.T) {
+	b := NewBackoff(ReconnectConfig{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Factor: 3})
+	var got []time.Duration
+	for range 5 {
+		got = append(got, b.Next())
+	}
+	want := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second, time.Second}
+	if !slices.Equal(got, want) {
+		t.Errorf("sequence = %v, want %v", got, want)
+	}
+
+	b.Reset()
+	if d := b.Next(); d != 100*time.Millisecond {
+		t.Errorf("after Reset: Next = %v, want 100ms", d)
+	}
+	if d := b.Next(); d != 300*time.Millisecond {
+		t.Errorf("second after Reset: Next = %v, want 300ms", d)
+	}
+}
+
+func TestBackoffDefaults(t *testing.T) {
+	var b Backoff
+	var got []time.Duration
+	for range 8 {
+		got = append(got, b.Next())
+	}
+	want := []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second,
+		8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("default sequence = %v, want %v", got, want)
+	}
+}
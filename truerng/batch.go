@@ -0,0 +1,155 @@
+package truerng
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// batchReadTimeout is how long a collect-loop batch may take to arrive.
+const batchReadTimeout = 5 * time.Second
+
+// Batch is one delivery from a collect loop together with its capture-time
+// metadata.
+type Batch struct {
+	Time time.Time
+	// Data is only valid during the callback unless CollectConfig.CopyBatch
+	// is set.
+	Data []byte
+	// Entropy is the Shannon entropy of Data in bits per byte. It is only
+	// computed when requested (CollectConfig.ComputeEntropy); HasEntropy tells
+	// a computed 0 apart from "not computed".
+	Entropy    float64
+	HasEntropy bool
+}
+
+// NewBatch wraps data captured now, computing its entropy if computeEntropy
+// is set.
+func NewBatch(data []byte, computeEntropy bool) Batch {
+	b := Batch{Time: time.Now(), Data: data}
+	if computeEntropy {
+		b.Entropy = ShannonEntropy(data)
+		b.HasEntropy = true
+	}
+	return b
+}
+
+// CollectBatchesAtInterval is CollectBitsAtIntervalWithConfig delivering each
+// batch as a Batch, tagged with its capture time and, when
+// cfg.ComputeEntropy is set, its entropy.
+func CollectBatchesAtInterval(ctx context.Context, bitCount int, interval time.Duration, cfg CollectConfig, onBatch func(Batch)) error {
+	if onBatch == nil {
+		return errors.New("onBatch callback must not be nil")
+	}
+	return CollectBitsAtIntervalWithConfig(ctx, bitCount, interval, cfg, func(b []byte) {
+		onBatch(NewBatch(b, cfg.ComputeEntropy))
+	})
+}
+
+// CollectDualAtInterval is CollectBitsAtIntervalWithConfig delivering every
+// batch twice: raw as read from the device and processed as it comes out of
+// the post-processing cfg selects (XORWithPrevious, BalanceFold, Debias), so
+// the two can be compared without reading the device again. Without any
+// post-processing both are the same slice. processed is only delivered when
+// the pipeline yields something (see the individual options); with
+// XORWithPrevious it also depends on the raw batch before. Unless
+// cfg.CopyBatch is set, raw is only valid during the callback.
+func CollectDualAtInterval(ctx context.Context, bitCount int, interval time.Duration, cfg CollectConfig, onDual func(raw, processed []byte)) error {
+	if onDual == nil {
+		return errors.New("onDual callback must not be nil")
+	}
+	var raw []byte
+	cfg.rawTap = func(b []byte) { raw = b }
+	return CollectBitsAtIntervalWithConfig(ctx, bitCount, interval, cfg, func(processed []byte) {
+		onDual(raw, processed)
+	})
+}
+
+// ReadBatch performs one complete batch read from the first TrueRNG, the same
+// read each collect loop makes per tick: open the port, flush stale input,
+// read byteCount bytes within 5 seconds, apply opts.RejectStuck and
+// opts.RequireQualityCheck and zero the bits of the last byte beyond
+// bitCount. byteCount must be (bitCount+7)/8.
+// opts.HardTimeout and opts.SharedRead apply as described at ReadOptions;
+// opts.MinBytes is ignored, as a batch is never delivered short.
+func ReadBatch(ctx context.Context, byteCount, bitCount int, mode CaptureMode, opts ReadOptions) ([]byte, error) {
+	if bitCount <= 0 {
+		return nil, errors.New("bitCount must be positive")
+	}
+	if byteCount != (bitCount+7)/8 {
+		return nil, fmt.Errorf("byteCount %d does not match bitCount %d", byteCount, bitCount)
+	}
+	portName, err := FindPort()
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+	buf := make([]byte, byteCount)
+	if err := readBatchFromPort(ctx, portName, buf, bitCount, opts); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readBatchFromPort opens portName (shared if opts.SharedRead), flushes it and fills buf with one batch
+// via readBatch. The port is closed again before returning.
+func readBatchFromPort(ctx context.Context, portName string, buf []byte, bitCount int, opts ReadOptions) error {
+	port, err := openPort(portName, &serial.Mode{Parity: serial.NoParity, StopBits: serial.OneStopBit}, opts.SharedRead)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", portName, err)
+	}
+	defer func() { _ = port.Close() }()
+	_ = port.SetDTR(true)
+	_ = port.SetReadTimeout(2000 * time.Millisecond)
+	_ = port.ResetInputBuffer()
+	return readBatch(ctx, port, buf, bitCount, opts)
+}
+
+// readBatch fills buf from an open port within batchReadTimeout, then applies
+// opts.RejectStuck and opts.RequireQualityCheck (clearing buf when the
+// check fails) and masks the trailing bits beyond bitCount. Read failures
+// are wrapped as "read error" (with ErrPortClosed when the port went away)
+// so callers can tell them from timeouts (ErrReadTimeout), cancellation and
+// ErrNoiseSourceDead.
+func readBatch(ctx context.Context, port serial.Port, buf []byte, bitCount int, opts ReadOptions) error {
+	total := 0
+	deadline := time.Now().Add(batchReadTimeout)
+	for total < len(buf) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w after %s: read %d/%d bytes", ErrReadTimeout, batchReadTimeout, total, len(buf))
+		}
+		n, err := readWithWatchdog(port, buf[total:], opts.HardTimeout)
+		if err != nil {
+			return err
+		}
+		total += n
+		if n == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	if opts.RejectStuck {
+		if err := CheckStuck(buf); err != nil {
+			return err
+		}
+	}
+	if opts.RequireQualityCheck {
+		if err := VerifyQuality(buf); err != nil {
+			clear(buf)
+			return err
+		}
+	}
+	maskTrailingBits(buf, bitCount)
+	return nil
+}
+
+// maskTrailingBits zeroes the bits of data's last byte beyond bitCount.
+func maskTrailingBits(data []byte, bitCount int) {
+	if extraBits := (8 - (bitCount % 8)) % 8; extraBits != 0 && len(data) > 0 {
+		data[len(data)-1] &= byte(0xFF << extraBits)
+	}
+}
@@ -0,0 +1,175 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+func TestNewBatchEntropy(t *testing.T) {
+	every := make([]byte, 512)
+	for i := range every {
+		every[i] = byte(i)
+	}
+	if b := NewBatch(every, true); !b.HasEntropy || math.Abs(b.Entropy-8) > 1e-9 {
+		t.Errorf("all byte values twice: entropy %v (computed %v), want 8", b.Entropy, b.HasEntropy)
+	}
+	if b := NewBatch(make([]byte, 64), true); !b.HasEntropy || b.Entropy != 0 {
+		t.Errorf("zeros: entropy %v (computed %v), want a computed 0", b.Entropy, b.HasEntropy)
+	}
+	if b := NewBatch(every, false); b.HasEntropy || b.Entropy != 0 {
+		t.Error("entropy computed although not requested")
+	}
+
+	line, err := json.Marshal(NewBatchJSON(NewBatch(every, true), 4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(line, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := fields["entropy"].(float64); !ok || math.Abs(e-8) > 1e-9 {
+		t.Errorf("JSON entropy = %v in %s, want 8", fields["entropy"], line)
+	}
+}
+
+func TestCollectBatchesTagsEntropy(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(12)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var batches []Batch
+	err := CollectBatchesAtInterval(ctx, 4096*8, 10*time.Millisecond, CollectConfig{ComputeEntropy: true}, func(b Batch) {
+		batches = append(batches, b)
+		if len(batches) == 2 {
+			cancel()
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		t.Fatal(err)
+	}
+	if len(batches) < 2 {
+		t.Fatalf("got %d batches, want 2", len(batches))
+	}
+	for i, b := range batches {
+		if !b.HasEntropy || b.Entropy < 7.9 || b.Entropy > 8 {
+			t.Errorf("batch %d entropy %v (computed %v), want about 7.95", i, b.Entropy, b.HasEntropy)
+		}
+	}
+}
+
+// benchmarkCollect runs a collect loop against a fake device for b.N batches
+// of 4 KiB.
+func benchmarkCollect(b *testing.B, copyBatch bool) {
+	const port = "/dev/ttyFAKE0"
+	oldList, oldOpen := listPorts, serialOpen
+	b.Cleanup(func() { listPorts, serialOpen = oldList, oldOpen })
+	listPorts = func() ([]*enumerator.PortDetails, error) {
+		return []*enumerator.PortDetails{trueRNGPort(port, "A1")}, nil
+	}
+	dev := randomPort(17)
+	serialOpen = func(string, *serial.Mode) (serial.Port, error) { return dev, nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	cfg := CollectConfig{Reconnect: true, CopyBatch: copyBatch}
+	b.ReportAllocs()
+	b.ResetTimer()
+	err := CollectBitsAtIntervalWithConfig(ctx, 4096*8, time.Nanosecond, cfg, func([]byte) {
+		if n++; n == b.N {
+			cancel()
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkCollectReusedBuffer(b *testing.B) { benchmarkCollect(b, false) }
+func BenchmarkCollectCopyBatch(b *testing.B)    { benchmarkCollect(b, true) }
+
+func TestReadBatchMasksTrailingBits(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: {src: bytes.NewReader(bytes.Repeat([]byte{0xFF}, 64))}})
+
+	got, err := ReadBatch(context.Background(), 2, 13, ModeNormal, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0xFF, 0xF8}; !bytes.Equal(got, want) {
+		t.Errorf("13-bit batch = %x, want %x", got, want)
+	}
+	if _, err := ReadBatch(context.Background(), 3, 13, ModeNormal, ReadOptions{}); err == nil {
+		t.Error("mismatched byteCount accepted")
+	}
+}
+
+func TestReadBatchCancelled(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	// The device never delivers anything.
+	fakeSerial(t, map[string]*fakePort{port: {}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := ReadBatch(ctx, 8, 64, ModeNormal, ReadOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed >= batchReadTimeout {
+		t.Errorf("cancellation noticed after %v", elapsed)
+	}
+}
+
+func TestCollectDualAtInterval(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(31)})
+
+	for _, fold := range []bool{false, true} {
+		var raw, processed [][]byte
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := CollectDualAtInterval(ctx, 512, time.Millisecond, CollectConfig{BalanceFold: fold}, func(r, p []byte) {
+			raw = append(raw, bytes.Clone(r))
+			processed = append(processed, bytes.Clone(p))
+			if len(raw) == 3 {
+				cancel()
+			}
+		})
+		cancel()
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("fold %v: err = %v, want context.Canceled", fold, err)
+		}
+		if len(raw) != 3 {
+			t.Fatalf("fold %v: %d batches delivered, want 3", fold, len(raw))
+		}
+		for i := range raw {
+			if len(raw[i]) != 64 {
+				t.Errorf("fold %v: raw batch %d has %d bytes, want 64", fold, i, len(raw[i]))
+			}
+			want := raw[i]
+			if fold {
+				want = BalanceFold(bytes.Clone(raw[i]))
+			}
+			if !bytes.Equal(processed[i], want) {
+				t.Errorf("fold %v: processed batch %d is not the pipeline applied to raw", fold, i)
+			}
+		}
+	}
+
+	if err := CollectDualAtInterval(context.Background(), 64, time.Second, CollectConfig{}, nil); err == nil {
+		t.Error("nil callback accepted")
+	}
+}
@@ -0,0 +1,334 @@
+package truerng
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// CollectConfig tunes the interval collection loops. The zero value behaves
+// like CollectBitsAtInterval.
+type CollectConfig struct {
+	// Mode is the capture mode to read in (ModeNormal when empty).
+	Mode CaptureMode
+	// Port pins the loop to this serial port, e.g. the Port of a device
+	// picked with FindDeviceBySerial, instead of the first detected TrueRNG.
+	// The Reconnect loop then waits for the device to come back on the same
+	// port.
+	Port string
+	// Reconnect keeps a single port open and reconnects when the device goes
+	// away, as CollectBitsAtIntervalWithReconnect does. When false the port is
+	// opened for every read.
+	Reconnect bool
+	// IntervalJitter randomizes the schedule: each delay between reads is
+	// interval plus a uniformly random offset in [-IntervalJitter,
+	// +IntervalJitter], drawn from math/rand, so the access pattern is not
+	// predictable. Delays never go below zero.
+	IntervalJitter time.Duration
+	// OnStats, when set, is called after every batch with running capture
+	// statistics (see CSVMetricsSink for a ready-made consumer).
+	OnStats func(CollectStats)
+	// ComputeEntropy tags every Batch delivered by CollectBatchesAtInterval
+	// with its Shannon entropy. It is off by default to save the extra pass
+	// over the data.
+	ComputeEntropy bool
+	// Ring, when set, receives every batch before onBatch so the most recent
+	// bytes are available after a read error (see RingTap).
+	Ring *RingTap
+	// Autocorr, when set, is fed every batch before onBatch so correlated
+	// output raises its alarm (see AutocorrMonitor).
+	Autocorr *AutocorrMonitor
+	// CopyBatch hands onBatch a freshly allocated copy of every batch. By
+	// default the loop reuses one buffer, and the slice is only valid until
+	// onBatch returns.
+	CopyBatch bool
+	// RejectStuck ends the loop with an error wrapping ErrNoiseSourceDead when
+	// a batch is all 0x00 or all 0xFF (see CheckStuck).
+	RejectStuck bool
+	// ContinuousTest ends the loop with ErrRepeatedBlock as soon as a batch
+	// is identical to the one before it (see ContinuousTest), which catches
+	// a stuck device on its second read.
+	ContinuousTest bool
+	// FirstReadImmediate chooses whether the first read happens as soon as
+	// the loop starts (true, the behavior when it is nil) or only after one
+	// (jittered) interval (false), so that the reads of loops started
+	// together line up on the interval. It is a pointer so that leaving it
+	// unset keeps the immediate first read; when set it overrides
+	// DelayFirstRead.
+	FirstReadImmediate *bool
+	// DelayFirstRead is the zero-value-friendly spelling of
+	// FirstReadImmediate set to false. It is ignored when
+	// FirstReadImmediate is set.
+	DelayFirstRead bool
+	// SharedRead opens the port without exclusive access so another reader
+	// can use the device at the same time; see ReadOptions.SharedRead for
+	// how the output is split between them.
+	SharedRead bool
+	// XORWithPrevious delivers each batch XORed with the raw batch read before
+	// it, for extra mixing; the first raw batch is only buffered and produces
+	// no delivery. Every raw batch feeds two outputs, so neighbouring outputs
+	// are not independent: treat the stream as carrying at most half the
+	// independent samples it appears to. Ring, Autocorr and the stuck check
+	// still see the raw batches; OnStats sees the XORed ones.
+	XORWithPrevious bool
+	// Debias passes every batch through VonNeumannDebias before onBatch, for
+	// the unwhitened modes (ModeRawBin, ModeUnwhitened). The debiased batch is
+	// variable-length and usually much shorter than bitCount bits (about a
+	// quarter of it), so onBatch must not assume a size; batches that debias
+	// to nothing are not delivered. With XORWithPrevious the raw batches are
+	// XORed first.
+	Debias bool
+	// BalanceFold passes every batch through BalanceFold before onBatch (and
+	// before Debias), halving its length. It is a cheaper, weaker bias
+	// reduction than Debias with a constant output size.
+	BalanceFold bool
+	// ReconnectBackoff paces the Reconnect loop's attempts to get the device
+	// back: each failed attempt waits longer, up to the maximum, and a
+	// successful reconnection starts the sequence over.
+	ReconnectBackoff ReconnectConfig
+	// OnReconnect, when set, is called with the port name each time the
+	// Reconnect loop has re-established the connection.
+	OnReconnect func(portName string)
+	// WaitForDevice lets the loop start before the device is plugged in: the
+	// first device lookup is retried every 500ms for up to this long (or until
+	// ctx ends) instead of failing at once. Later lookups are unaffected; use
+	// Reconnect to ride out unplugs mid-run. Zero fails immediately.
+	WaitForDevice time.Duration
+
+	// rawTap, set by CollectDualAtInterval, sees every raw batch before the
+	// post-processing (XORWithPrevious, BalanceFold, Debias) runs.
+	rawTap func([]byte)
+}
+
+// delayFirstRead reports whether the loop waits one interval before its
+// first read, per FirstReadImmediate and DelayFirstRead.
+func (cfg CollectConfig) delayFirstRead() bool {
+	if cfg.FirstReadImmediate != nil {
+		return !*cfg.FirstReadImmediate
+	}
+	return cfg.DelayFirstRead
+}
+
+// CollectStats describes the progress of a collect loop after a batch.
+type CollectStats struct {
+	Time        time.Time
+	Bytes       int     // size of this batch
+	TotalBytes  int64   // bytes delivered since the loop started
+	BytesPerSec float64 // average throughput since the loop started
+	Entropy     float64 // Shannon entropy of this batch, bits per byte
+	OnesRatio   float64 // fraction of set bits in this batch
+}
+
+// CollectBitsAtIntervalWithConfig reads bitCount bits on the schedule given by
+// interval and cfg, invoking onBatch with each batch until ctx is cancelled or
+// a read fails.
+func CollectBitsAtIntervalWithConfig(ctx context.Context, bitCount int, interval time.Duration, cfg CollectConfig, onBatch func([]byte)) error {
+	if bitCount <= 0 {
+		return errors.New("bitCount must be positive")
+	}
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	if onBatch == nil {
+		return errors.New("onBatch callback must not be nil")
+	}
+	if cfg.IntervalJitter < 0 {
+		return errors.New("interval jitter must not be negative")
+	}
+	if cfg.WaitForDevice < 0 {
+		return errors.New("WaitForDevice must not be negative")
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeNormal
+	}
+
+	if cfg.OnStats != nil {
+		onBatch = withStats(onBatch, cfg.OnStats)
+	}
+	if cfg.Debias {
+		next := onBatch
+		onBatch = func(b []byte) {
+			if out := VonNeumannDebias(b); len(out) > 0 {
+				next(out)
+			}
+		}
+	}
+	if cfg.BalanceFold {
+		next := onBatch
+		onBatch = func(b []byte) {
+			if out := BalanceFold(b); len(out) > 0 {
+				next(out)
+			}
+		}
+	}
+	if cfg.XORWithPrevious {
+		onBatch = withXORPrevious(onBatch, cfg.CopyBatch)
+	}
+	if tap := cfg.rawTap; tap != nil {
+		next := onBatch
+		onBatch = func(b []byte) {
+			tap(b)
+			next(b)
+		}
+	}
+	if ring := cfg.Ring; ring != nil {
+		next := onBatch
+		onBatch = func(b []byte) {
+			_, _ = ring.Write(b)
+			next(b)
+		}
+	}
+	if mon := cfg.Autocorr; mon != nil {
+		next := onBatch
+		onBatch = func(b []byte) {
+			_, _ = mon.Write(b)
+			next(b)
+		}
+	}
+	if cfg.CopyBatch {
+		next := onBatch
+		onBatch = func(b []byte) {
+			next(append([]byte(nil), b...))
+		}
+	}
+
+	if cfg.Reconnect {
+		return collectWithReconnect(ctx, bitCount, interval, cfg, onBatch)
+	}
+	return collectPerRead(ctx, bitCount, interval, cfg, onBatch)
+}
+
+// devicePollInterval is how often waitForPort looks for the device.
+const devicePollInterval = 500 * time.Millisecond
+
+// findPort returns cfg.Port when the loop is pinned to a port, and FindPort's
+// result otherwise.
+func (cfg CollectConfig) findPort() (string, error) {
+	if cfg.Port != "" {
+		return cfg.Port, nil
+	}
+	return FindPort()
+}
+
+// waitForPort returns cfg.findPort's result, retrying for up to wait while no
+// device is found.
+func waitForPort(ctx context.Context, cfg CollectConfig, wait time.Duration) (string, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		port, err := cfg.findPort()
+		if err == nil || wait <= 0 || time.Now().After(deadline) {
+			return port, err
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(devicePollInterval):
+		}
+	}
+}
+
+// withStats wraps onBatch so that onStats is called after each batch.
+func withStats(onBatch func([]byte), onStats func(CollectStats)) func([]byte) {
+	start := time.Now()
+	var total int64
+	return func(b []byte) {
+		onBatch(b)
+		now := time.Now()
+		total += int64(len(b))
+		var est EntropyEstimator
+		est.Add(b)
+		st := CollectStats{
+			Time:       now,
+			Bytes:      len(b),
+			TotalBytes: total,
+			Entropy:    est.Entropy(),
+			OnesRatio:  est.OnesRatio(),
+		}
+		if secs := now.Sub(start).Seconds(); secs > 0 {
+			st.BytesPerSec = float64(total) / secs
+		}
+		onStats(st)
+	}
+}
+
+// withXORPrevious wraps onBatch so it receives each batch XORed with the one
+// before, skipping the first. The output buffer is reused unless fresh is set.
+func withXORPrevious(onBatch func([]byte), fresh bool) func([]byte) {
+	var prev, out []byte
+	return func(b []byte) {
+		if prev == nil {
+			prev = append([]byte(nil), b...)
+			return
+		}
+		if fresh || len(out) != len(b) {
+			out = make([]byte, len(b))
+		}
+		for i := range b {
+			out[i] = b[i] ^ prev[i]
+		}
+		prev = append(prev[:0], b...)
+		onBatch(out)
+	}
+}
+
+// intervalSchedule produces the wake-ups between reads of a collect loop. The
+// first read happens immediately; each later one is due one (jittered)
+// interval after the previous due time, so slow reads do not drift the
+// schedule. If the loop falls behind, the next read fires at once.
+type intervalSchedule struct {
+	interval time.Duration
+	jitter   time.Duration
+	due      time.Time
+	timer    *time.Timer
+	now      func() time.Time
+}
+
+func newIntervalSchedule(interval, jitter time.Duration) *intervalSchedule {
+	return &intervalSchedule{interval: interval, jitter: jitter, due: time.Now(), now: time.Now}
+}
+
+// next advances the schedule and returns a channel that fires when the next
+// read is due.
+func (s *intervalSchedule) next() <-chan time.Time {
+	s.due = s.due.Add(s.delay())
+	wait := s.due.Sub(s.now())
+	if wait < 0 {
+		s.due = s.now()
+		wait = 0
+	}
+	if s.timer == nil {
+		s.timer = time.NewTimer(wait)
+	} else {
+		s.timer.Reset(wait)
+	}
+	return s.timer.C
+}
+
+// delay returns the interval adjusted by a random offset within the jitter.
+func (s *intervalSchedule) delay() time.Duration {
+	d := s.interval
+	if s.jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(2*s.jitter)+1)) - s.jitter
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// wait blocks until the next read is due or ctx ends.
+func (s *intervalSchedule) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.next():
+		return nil
+	}
+}
+
+func (s *intervalSchedule) stop() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
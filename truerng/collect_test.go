@@ -0,0 +1,156 @@
+package truerng
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+)
+
+func TestIntervalScheduleJitterBand(t *testing.T) {
+	const interval, jitter = 10 * time.Second, 2 * time.Second
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := newIntervalSchedule(interval, jitter)
+	s.now = func() time.Time { return clock }
+	s.due = clock
+	defer s.stop()
+
+	for i := range 200 {
+		prev := s.due
+		s.next()
+		gap := s.due.Sub(prev)
+		if gap < interval-jitter || gap > interval+jitter {
+			t.Fatalf("interval %d = %v, want within %v ± %v", i, gap, interval, jitter)
+		}
+		clock = s.due
+	}
+}
+
+func TestIntervalScheduleFallsBehind(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := newIntervalSchedule(time.Second, 0)
+	s.now = func() time.Time { return clock }
+	s.due = clock
+	defer s.stop()
+
+	clock = clock.Add(time.Minute)
+	select {
+	case <-s.next():
+	case <-time.After(time.Second):
+		t.Fatal("late read did not fire at once")
+	}
+	if !s.due.Equal(clock) {
+		t.Errorf("due = %v, want reset to %v", s.due, clock)
+	}
+}
+
+func TestDelayFirstRead(t *testing.T) {
+	const port, interval = "/dev/ttyFAKE0", 800 * time.Millisecond
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(24)})
+
+	// The Reconnect loop spends about 200ms settling the port before its
+	// first read, well inside half an interval.
+	yes, no := true, false
+	for _, tc := range []struct {
+		cfg     CollectConfig
+		delayed bool
+	}{
+		{CollectConfig{}, false},
+		{CollectConfig{DelayFirstRead: true}, true},
+		{CollectConfig{Reconnect: true}, false},
+		{CollectConfig{Reconnect: true, DelayFirstRead: true}, true},
+		{CollectConfig{FirstReadImmediate: &no}, true},
+		{CollectConfig{FirstReadImmediate: &yes, DelayFirstRead: true}, false},
+		{CollectConfig{Reconnect: true, FirstReadImmediate: &no}, true},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		start := time.Now()
+		var first time.Duration
+		err := CollectBitsAtIntervalWithConfig(ctx, 64, interval, tc.cfg, func([]byte) {
+			first = time.Since(start)
+			cancel()
+		})
+		cancel()
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("reconnect %v, delayed %v: err = %v, want context.Canceled", tc.cfg.Reconnect, tc.delayed, err)
+		}
+		if tc.delayed && first < interval {
+			t.Errorf("reconnect %v: delayed first batch after %v, want at least %v", tc.cfg.Reconnect, first, interval)
+		}
+		if !tc.delayed && first >= interval/2 {
+			t.Errorf("reconnect %v: first batch after %v, want it immediately", tc.cfg.Reconnect, first)
+		}
+	}
+}
+
+func TestXORWithPrevious(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(25)})
+
+	var raw, out [][]byte
+	cfg := CollectConfig{XORWithPrevious: true}
+	cfg.rawTap = func(b []byte) { raw = append(raw, append([]byte(nil), b...)) }
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := CollectBitsAtIntervalWithConfig(ctx, 256, time.Millisecond, cfg, func(b []byte) {
+		out = append(out, append([]byte(nil), b...))
+		if len(out) == 3 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	// The first raw batch has nothing to mix with and is not delivered.
+	if len(raw) != 4 || len(out) != 3 {
+		t.Fatalf("%d raw batches, %d delivered; want 4 and 3", len(raw), len(out))
+	}
+	for k, b := range out {
+		for i := range b {
+			if b[i] != raw[k+1][i]^raw[k][i] {
+				t.Fatalf("batch %d byte %d = %#x, want raw[%d]^raw[%d] = %#x", k, i, b[i], k+1, k, raw[k+1][i]^raw[k][i])
+			}
+		}
+	}
+}
+
+func TestWaitForDevice(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakeSerial(t, map[string]*fakePort{port: randomPort(27)})
+
+	// The device is plugged in 300ms after the loop starts.
+	var plugged time.Time
+	old := listPorts
+	listPorts = func() ([]*enumerator.PortDetails, error) {
+		if time.Now().Before(plugged) {
+			return nil, nil
+		}
+		return []*enumerator.PortDetails{trueRNGPort(port, "A1")}, nil
+	}
+	t.Cleanup(func() { listPorts = old })
+
+	for _, reconnect := range []bool{false, true} {
+		plugged = time.Now().Add(300 * time.Millisecond)
+		err := CollectBitsAtIntervalWithConfig(context.Background(), 64, time.Second, CollectConfig{Reconnect: reconnect}, func([]byte) {})
+		if err == nil {
+			t.Fatalf("reconnect %v: no error without WaitForDevice", reconnect)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		got := 0
+		cfg := CollectConfig{Reconnect: reconnect, WaitForDevice: 2 * time.Second}
+		err = CollectBitsAtIntervalWithConfig(ctx, 64, time.Second, cfg, func([]byte) {
+			got++
+			cancel()
+		})
+		cancel()
+		if !errors.Is(err, context.Canceled) || got != 1 {
+			t.Errorf("reconnect %v: err = %v after %d batches, want a batch once the device appears", reconnect, err, got)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package truerng
+
+import (
+	"errors"
+	"time"
+)
+
+// ReadResult describes one read: the data together with where it came from,
+// how long it took and, optionally, its entropy.
+type ReadResult struct {
+	// Data holds the bits packed MSB-first, trailing bits beyond NBits zeroed.
+	Data []byte
+	// NBits is the number of bits actually read. It equals the requested
+	// count unless ReadOptions.MinBytes allowed a short read.
+	NBits    int
+	Device   DeviceInfo
+	Duration time.Duration
+	// Entropy is the Shannon entropy of Data in bits per byte. It is only
+	// computed on request (see ReadDetailedWithOptions); HasEntropy tells a
+	// computed 0 apart from "not computed".
+	Entropy    float64
+	HasEntropy bool
+}
+
+// ReadDetailed reads bitCount bits like ReadBitsWithMode and returns them
+// with the device, the time the read took and the number of bits read. The
+// entropy is not computed; use ReadDetailedWithOptions for that.
+func ReadDetailed(bitCount int, mode CaptureMode) (ReadResult, error) {
+	return ReadDetailedWithOptions(bitCount, mode, ReadOptions{}, false)
+}
+
+// ReadDetailedWithOptions is ReadDetailed with opts applied as by
+// ReadBytesWithOptions, also filling in ReadResult.Entropy when
+// computeEntropy is set. Duration covers opening the port and reading, not
+// device lookup or the entropy computation.
+func ReadDetailedWithOptions(bitCount int, mode CaptureMode, opts ReadOptions, computeEntropy bool) (ReadResult, error) {
+	if bitCount <= 0 {
+		return ReadResult{}, errors.New("bitCount must be positive")
+	}
+	byteCount := (bitCount + 7) / 8
+	if err := validateReadOptions(byteCount, opts); err != nil {
+		return ReadResult{}, err
+	}
+	device, err := FindDevice()
+	if err != nil {
+		return ReadResult{}, err
+	}
+
+	start := time.Now()
+	data, err := readBytesFromPortOpts(device.Port, mode, byteCount, opts)
+	if err != nil {
+		return ReadResult{}, err
+	}
+	res := ReadResult{Data: data, NBits: min(bitCount, len(data)*8), Device: *device, Duration: time.Since(start)}
+	if err := checkReadData(data, opts); err != nil {
+		return ReadResult{}, err
+	}
+	maskTrailingBits(data, res.NBits)
+	if computeEntropy {
+		res.Entropy = ShannonEntropy(data)
+		res.HasEntropy = true
+	}
+	return res, nil
+}
@@ -0,0 +1,48 @@
+package truerng
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadDetailed(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	dev := &fakePort{}
+	fakeSerial(t, map[string]*fakePort{port: dev})
+	stream := randomBytes(34, 4096)
+
+	dev.src = bytes.NewReader(stream)
+	res, err := ReadDetailed(8*1000+3, ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.NBits != 8003 || len(res.Data) != 1001 || res.Data[1000]&0x1F != 0 {
+		t.Errorf("NBits = %d, %d bytes ending %#x; want 8003 bits in 1001 bytes, tail masked", res.NBits, len(res.Data), res.Data[1000])
+	}
+	if !bytes.Equal(res.Data[:1000], stream[:1000]) {
+		t.Error("Data does not match the device output")
+	}
+	if res.Device.Port != port || res.Device.SerialNumber != "A1" || res.Device.Model != DeviceModelTrueRNG {
+		t.Errorf("Device = %+v", res.Device)
+	}
+	if res.Duration <= 0 {
+		t.Errorf("Duration = %v, want positive", res.Duration)
+	}
+	if res.HasEntropy || res.Entropy != 0 {
+		t.Errorf("entropy computed without being asked: %v", res.Entropy)
+	}
+
+	dev.src = bytes.NewReader(stream)
+	res, err = ReadDetailedWithOptions(8*4096, ModeNormal, ReadOptions{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.HasEntropy || res.Entropy < 7.9 || res.Entropy > 8 {
+		t.Errorf("Entropy = %v (HasEntropy %v), want about 7.95", res.Entropy, res.HasEntropy)
+	}
+
+	if _, err := ReadDetailed(0, ModeNormal); err == nil {
+		t.Error("zero bitCount accepted")
+	}
+}
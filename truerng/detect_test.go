@@ -0,0 +1,95 @@
+package truerng
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+)
+
+func TestGetTrueRNGModelConfidence(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		port  *enumerator.PortDetails
+		model DeviceModel
+		conf  Confidence
+	}{
+		{"nil", nil, DeviceModelUnknown, ConfidenceNone},
+		{"TrueRNG VID/PID", trueRNGPort("/dev/ttyACM0", ""), DeviceModelTrueRNG, ConfidenceHigh},
+		{"pro VID/PID lower case", &enumerator.PortDetails{IsUSB: true, VID: "16d0", PID: "0aa0"}, DeviceModelTrueRNGpro, ConfidenceHigh},
+		{"product string", &enumerator.PortDetails{IsUSB: true, VID: "1234", PID: "5678", Product: "TrueRNG clone"}, DeviceModelTrueRNGpro, ConfidenceMedium},
+		{"serial string", &enumerator.PortDetails{IsUSB: true, SerialNumber: "truerng-42"}, DeviceModelTrueRNGpro, ConfidenceMedium},
+		{"name only", &enumerator.PortDetails{Name: "/dev/TrueRNG0"}, DeviceModelTrueRNGpro, ConfidenceLow},
+		{"product string off USB", &enumerator.PortDetails{Name: "/dev/ttyS0", Product: "TrueRNG"}, DeviceModelUnknown, ConfidenceNone},
+		{"unrelated", &enumerator.PortDetails{IsUSB: true, VID: "0403", PID: "6001", Product: "FT232R"}, DeviceModelUnknown, ConfidenceNone},
+	} {
+		model, conf := getTrueRNGModel(tc.port)
+		if model != tc.model || conf != tc.conf {
+			t.Errorf("%s: got %v/%v, want %v/%v", tc.name, model, conf, tc.model, tc.conf)
+		}
+	}
+}
+
+func TestEnumerateDevicesDetailed(t *testing.T) {
+	pro := proPort("/dev/ttyFAKE1", "PRO1")
+	pro.Product = "TrueRNGpro"
+	fakePorts(t,
+		&enumerator.PortDetails{Name: "/dev/ttyS0"},
+		trueRNGPort("/dev/ttyFAKE0", "A1"),
+		nil,
+		pro,
+	)
+
+	devices, err := EnumerateDevicesDetailed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("found %d devices, want the 2 TrueRNGs", len(devices))
+	}
+	d := devices[1]
+	if d.PortDetails != pro {
+		t.Errorf("PortDetails = %+v, want the enumerator entry of %s", d.PortDetails, pro.Name)
+	}
+	if d.Port != pro.Name || d.Model != DeviceModelTrueRNGpro || d.SerialNumber != "PRO1" || d.Name != "TrueRNGpro" {
+		t.Errorf("DeviceInfo = %+v", d.DeviceInfo)
+	}
+
+	plain, err := EnumerateDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range plain {
+		if plain[i] != devices[i].DeviceInfo {
+			t.Errorf("EnumerateDevices[%d] = %+v, want %+v", i, plain[i], devices[i].DeviceInfo)
+		}
+	}
+}
+
+func TestEnumerationTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	old, oldTimeout := listPorts, EnumerationTimeout
+	listPorts = func() ([]*enumerator.PortDetails, error) {
+		<-release
+		return []*enumerator.PortDetails{trueRNGPort("/dev/ttyFAKE0", "A1")}, nil
+	}
+	EnumerationTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { listPorts, EnumerationTimeout = old, oldTimeout })
+
+	start := time.Now()
+	_, err := EnumerateDevices()
+	if !errors.Is(err, ErrEnumerationTimeout) {
+		t.Fatalf("err = %v, want ErrEnumerationTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("EnumerateDevices returned after %s, want about %s", elapsed, EnumerationTimeout)
+	}
+
+	// A quick enumerator is unaffected.
+	fakePorts(t, trueRNGPort("/dev/ttyFAKE0", "A1"))
+	if devices, err := EnumerateDevices(); err != nil || len(devices) != 1 {
+		t.Errorf("EnumerateDevices() = %v, %v; want one device", devices, err)
+	}
+}
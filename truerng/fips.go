@@ -0,0 +1,200 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// FIPSBlockSize is the block size used by FIPSSoak for the continuous test:
+// 16 bytes (128 bits), well above the 64-bit minimum of FIPS 140-2.
+const FIPSBlockSize = 16
+
+// ErrRepeatedBlock is returned by ContinuousTest.Check when a block equals
+// the one before it.
+var ErrRepeatedBlock = errors.New("repeated block")
+
+// ContinuousTest is the FIPS 140-2 continuous random number generator test:
+// every block must differ from the one before it. A repeat is a failure.
+type ContinuousTest struct {
+	prev []byte
+}
+
+// Check remembers block and returns ErrRepeatedBlock if it is identical to
+// the previous block. The first block always passes.
+func (t *ContinuousTest) Check(block []byte) error {
+	repeated := t.prev != nil && bytes.Equal(block, t.prev)
+	t.prev = append(t.prev[:0], block...)
+	if repeated {
+		return ErrRepeatedBlock
+	}
+	return nil
+}
+
+// Reset forgets the previous block.
+func (t *ContinuousTest) Reset() {
+	t.prev = nil
+}
+
+// FIPSSoakResult summarizes a FIPSSoak run.
+type FIPSSoakResult struct {
+	Bytes    int64
+	Blocks   int64
+	Failures int64
+}
+
+// FailureRate returns the fraction of blocks that failed.
+func (r FIPSSoakResult) FailureRate() float64 {
+	if r.Blocks == 0 {
+		return 0
+	}
+	return float64(r.Failures) / float64(r.Blocks)
+}
+
+// FIPSSoak streams from the first TrueRNG and applies the continuous test to
+// every FIPSBlockSize block until ctx ends, calling onFailure (if set) with
+// the byte offset of each repeated block. Cancellation is the normal way to
+// end a soak, so it returns the totals with a nil error then; a read error
+// is returned together with the totals up to that point.
+func FIPSSoak(ctx context.Context, mode CaptureMode, onFailure func(offset int64)) (FIPSSoakResult, error) {
+	var res FIPSSoakResult
+	s, err := Open(mode)
+	if err != nil {
+		return res, err
+	}
+	defer s.Close()
+
+	var ct ContinuousTest
+	buf := make([]byte, s.BlockSize()-s.BlockSize()%FIPSBlockSize)
+	if len(buf) == 0 {
+		buf = make([]byte, FIPSBlockSize)
+	}
+	for ctx.Err() == nil {
+		if err := s.fill(buf); err != nil {
+			if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+				break
+			}
+			return res, err
+		}
+		for off := 0; off < len(buf); off += FIPSBlockSize {
+			if ct.Check(buf[off:off+FIPSBlockSize]) != nil {
+				res.Failures++
+				if onFailure != nil {
+					onFailure(res.Bytes + int64(off))
+				}
+			}
+			res.Blocks++
+		}
+		res.Bytes += int64(len(buf))
+	}
+	return res, nil
+}
+
+// FIPS1402Bytes is the sample size of the FIPS 140-2 statistical tests:
+// 20,000 bits.
+const FIPS1402Bytes = 2500
+
+// fipsRunBounds are the accepted counts of runs of length 1 to 5 and 6 or
+// more, the same for runs of zeros and of ones.
+var fipsRunBounds = [6][2]int{
+	{2315, 2685}, {1114, 1386}, {527, 723}, {240, 384}, {103, 209}, {103, 209},
+}
+
+// fipsLongRun is the run length that fails the long run test.
+const fipsLongRun = 26
+
+// FIPSResult holds the measured values and verdicts of the FIPS 140-2
+// power-up statistical tests.
+type FIPSResult struct {
+	// Ones is the monobit count; it must lie in (9725, 10275).
+	Ones        int
+	MonobitPass bool
+	// Poker is the poker statistic over 5000 4-bit segments; it must lie in
+	// (2.16, 46.17).
+	Poker     float64
+	PokerPass bool
+	// Runs counts runs by bit value and length: Runs[b][n-1] is the number of
+	// runs of bit b with length n, the last entry covering 6 or more. Each
+	// must lie within the FIPS intervals.
+	Runs     [2][6]int
+	RunsPass bool
+	// LongestRun is the longest run of either bit; 26 or more fails.
+	LongestRun  int
+	LongRunPass bool
+}
+
+// Pass reports whether all four tests passed.
+func (r FIPSResult) Pass() bool {
+	return r.MonobitPass && r.PokerPass && r.RunsPass && r.LongRunPass
+}
+
+// FIPSBlockResult is the outcome of FIPS140Block: the FIPS 140-2 verdicts,
+// or Err when the block was not FIPS1402Bytes long and no test ran.
+type FIPSBlockResult struct {
+	FIPSResult
+	Err error
+}
+
+// Pass reports whether the block had the right size and passed all four
+// tests.
+func (r FIPSBlockResult) Pass() bool {
+	return r.Err == nil && r.FIPSResult.Pass()
+}
+
+// FIPS140Block is FIPS1402 for one 20,000-bit block, the shape rngtest
+// works in, with a wrong-sized block reported in the result rather than as
+// a second return value.
+func FIPS140Block(block []byte) FIPSBlockResult {
+	r, err := FIPS1402(block)
+	return FIPSBlockResult{FIPSResult: r, Err: err}
+}
+
+// FIPS1402 runs the FIPS 140-2 monobit, poker, runs and long run tests on
+// exactly FIPS1402Bytes bytes, read MSB-first. It is the classic go/no-go
+// check for a fresh capture; a failing sample is not an error, only a
+// sample of the wrong size is.
+func FIPS1402(data []byte) (FIPSResult, error) {
+	var r FIPSResult
+	if len(data) != FIPS1402Bytes {
+		return r, fmt.Errorf("FIPS 140-2 tests need %d bytes, got %d", FIPS1402Bytes, len(data))
+	}
+
+	var nibbles [16]int
+	for _, b := range data {
+		r.Ones += bits.OnesCount8(b)
+		nibbles[b>>4]++
+		nibbles[b&0x0F]++
+	}
+	r.MonobitPass = r.Ones > 9725 && r.Ones < 10275
+
+	var sum float64
+	for _, f := range nibbles {
+		sum += float64(f) * float64(f)
+	}
+	r.Poker = 16.0/5000.0*sum - 5000
+	r.PokerPass = r.Poker > 2.16 && r.Poker < 46.17
+
+	total := len(data) * 8
+	for i := 0; i < total; {
+		bit := bitAt(data, i)
+		n := 1
+		for i+n < total && bitAt(data, i+n) == bit {
+			n++
+		}
+		r.Runs[bit][min(n, 6)-1]++
+		r.LongestRun = max(r.LongestRun, n)
+		i += n
+	}
+	r.RunsPass = true
+	for b := range r.Runs {
+		for n, count := range r.Runs[b] {
+			if count < fipsRunBounds[n][0] || count > fipsRunBounds[n][1] {
+				r.RunsPass = false
+			}
+		}
+	}
+	r.LongRunPass = r.LongestRun < fipsLongRun
+	return r, nil
+}
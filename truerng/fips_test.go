@@ -0,0 +1,135 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestFIPSSoakCountsKnownRepeats(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+
+	// Two session buffers of random blocks, with three blocks repeating the
+	// one before them; block 256 repeats across the buffer boundary.
+	stream := randomBytes(23, 2*DeviceModelTrueRNG.RecommendedBlockSize())
+	repeats := []int{10, 256, 300}
+	var wantOffsets []int64
+	for _, b := range repeats {
+		copy(stream[b*FIPSBlockSize:(b+1)*FIPSBlockSize], stream[(b-1)*FIPSBlockSize:b*FIPSBlockSize])
+		wantOffsets = append(wantOffsets, int64(b*FIPSBlockSize))
+	}
+
+	// Replay the stream once, then end the soak as an operator would.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rest := stream
+	fakeSerial(t, map[string]*fakePort{port: {src: readerFunc(func(p []byte) (int, error) {
+		if len(rest) == 0 {
+			cancel()
+			return 0, os.ErrClosed
+		}
+		n := copy(p, rest)
+		rest = rest[n:]
+		return n, nil
+	})}})
+
+	var offsets []int64
+	res, err := FIPSSoak(ctx, ModeNormal, func(off int64) { offsets = append(offsets, off) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FIPSSoakResult{Bytes: int64(len(stream)), Blocks: int64(len(stream) / FIPSBlockSize), Failures: int64(len(repeats))}
+	if res != want {
+		t.Errorf("result = %+v, want %+v", res, want)
+	}
+	if !slices.Equal(offsets, wantOffsets) {
+		t.Errorf("failure offsets = %v, want %v", offsets, wantOffsets)
+	}
+	if rate := res.FailureRate(); rate != 3.0/512 {
+		t.Errorf("FailureRate = %v, want %v", rate, 3.0/512)
+	}
+}
+
+func TestContinuousTest(t *testing.T) {
+	var ct ContinuousTest
+	a, b := []byte{1, 2, 3, 4}, []byte{1, 2, 3, 5}
+	for i, tc := range []struct {
+		block  []byte
+		repeat bool
+	}{{a, false}, {b, false}, {b, true}, {a, false}} {
+		if err := ct.Check(tc.block); (err != nil) != tc.repeat {
+			t.Errorf("block %d: Check = %v, want repeat %v", i, err, tc.repeat)
+		}
+	}
+	ct.Reset()
+	if err := ct.Check(a); err != nil {
+		t.Errorf("first block after Reset: %v", err)
+	}
+}
+
+func TestFIPS1402(t *testing.T) {
+	r, err := FIPS1402(randomBytes(29, FIPS1402Bytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Pass() {
+		t.Errorf("random sample failed: %+v", r)
+	}
+
+	// Alternating bits are perfectly balanced but have only runs of one.
+	r, err = FIPS1402(bytes.Repeat([]byte{0x55}, FIPS1402Bytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ones != 10000 || !r.MonobitPass || r.PokerPass || r.RunsPass || r.LongestRun != 1 || !r.LongRunPass || r.Pass() {
+		t.Errorf("alternating bits: %+v", r)
+	}
+
+	r, err = FIPS1402(make([]byte, FIPS1402Bytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ones != 0 || r.MonobitPass || r.LongestRun != 20000 || r.LongRunPass {
+		t.Errorf("all zeros: %+v", r)
+	}
+
+	if _, err := FIPS1402(make([]byte, FIPS1402Bytes-1)); err == nil {
+		t.Error("short sample accepted")
+	}
+}
+
+func TestFIPS140Block(t *testing.T) {
+	pass := randomBytes(41, FIPS1402Bytes)
+	if r := FIPS140Block(pass); !r.Pass() || r.Err != nil {
+		t.Errorf("random block failed: %+v", r)
+	}
+
+	// A run of 32 zero bits in otherwise random data fails the long run
+	// test alone.
+	longRun := bytes.Clone(pass)
+	copy(longRun[1000:], []byte{0x80, 0, 0, 0, 0, 0x01})
+	if r := FIPS140Block(longRun); r.Pass() || r.LongRunPass || r.LongestRun < 32 || !r.MonobitPass {
+		t.Errorf("block with a 32-bit run: %+v", r)
+	}
+
+	// Setting the top bit of every other byte biases the block towards ones.
+	biased := bytes.Clone(pass)
+	for i := 0; i < len(biased); i += 2 {
+		biased[i] |= 0x80
+	}
+	if r := FIPS140Block(biased); r.Pass() || r.MonobitPass {
+		t.Errorf("biased block: %+v", r)
+	}
+
+	// Alternating bits are balanced but fail poker and runs.
+	if r := FIPS140Block(bytes.Repeat([]byte{0x55}, FIPS1402Bytes)); r.Pass() || !r.MonobitPass || r.PokerPass || r.RunsPass {
+		t.Errorf("alternating bits: %+v", r)
+	}
+
+	if r := FIPS140Block(pass[:100]); r.Err == nil || r.Pass() {
+		t.Errorf("short block: %+v", r)
+	}
+}
@@ -0,0 +1,111 @@
+package truerng
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// ErrUnsupported reports a feature the connected device or its firmware does
+// not offer.
+var ErrUnsupported = errors.New("not supported by this device")
+
+// firmwareProbeSize bounds how much output ReadFirmwareInfo scans for a
+// banner; firmware that has one sends it ahead of the first debug frames.
+const firmwareProbeSize = 512
+
+// FirmwareInfo is the parsed firmware banner of a TrueRNG.
+type FirmwareInfo struct {
+	// Model is the model string the firmware reports, e.g. "TrueRNGpro V2".
+	Model string
+	// Version is the firmware version, e.g. "1.3".
+	Version string
+	// Banner is the banner line as received.
+	Banner string
+}
+
+var (
+	bannerModelRe   = regexp.MustCompile(`(?i)\bTrueRNG\w*(?:\s+V\d+\b)?`)
+	bannerVersionRe = regexp.MustCompile(`(?i)\b(?:firmware|fw|version|ver)\s*[:=]?\s*v?(\d+(?:\.\d+)+)`)
+	bannerNumberRe  = regexp.MustCompile(`\d+(?:\.\d+)+`)
+)
+
+// ReadFirmwareInfo switches the TrueRNG on port to ModePSDebug, scans the
+// start of its output for the firmware banner and switches it back to
+// ModeNormal. Models without debug modes, and firmware that goes straight to
+// voltage frames, yield an error wrapping ErrUnsupported.
+func ReadFirmwareInfo(port string) (FirmwareInfo, error) {
+	device, err := deviceOnPort(port)
+	if err != nil {
+		return FirmwareInfo{}, err
+	}
+	if !ModePSDebug.SupportedBy(device.Model) {
+		return FirmwareInfo{}, fmt.Errorf("%s firmware banner: %w", device.Model, ErrUnsupported)
+	}
+	if err := switchMode(port, ModePSDebug); err != nil {
+		return FirmwareInfo{}, fmt.Errorf("switch to %s: %w", ModePSDebug, err)
+	}
+	defer func() { _ = switchMode(port, ModeNormal) }()
+
+	out, err := readBannerOutput(port)
+	if err != nil {
+		return FirmwareInfo{}, err
+	}
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if info, err := ParseFirmwareBanner(string(line)); err == nil {
+			return info, nil
+		}
+	}
+	return FirmwareInfo{}, fmt.Errorf("no firmware banner in %s output: %w", ModePSDebug, ErrUnsupported)
+}
+
+// readBannerOutput opens port without flushing it, so output sent as soon as
+// DTR rises is kept, and reads up to firmwareProbeSize bytes within 3 seconds.
+func readBannerOutput(port string) ([]byte, error) {
+	p, err := serialOpen(port, &serial.Mode{Parity: serial.NoParity, StopBits: serial.OneStopBit})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", port, err)
+	}
+	defer func() { _ = p.Close() }()
+	_ = p.SetDTR(true)
+	_ = p.SetReadTimeout(500 * time.Millisecond)
+
+	buf := make([]byte, firmwareProbeSize)
+	total := 0
+	deadline := time.Now().Add(3 * time.Second)
+	for total < len(buf) && time.Now().Before(deadline) {
+		n, err := p.Read(buf[total:])
+		if err != nil {
+			return nil, readError(err)
+		}
+		total += n
+	}
+	return buf[:total], nil
+}
+
+// ParseFirmwareBanner parses a firmware banner line such as
+// "TrueRNGpro V2 Firmware 1.3" or "TrueRNG v3 - version: 2.01". The line must
+// name a TrueRNG model and carry a dotted version number; anything else,
+// including ordinary debug frames, returns an error wrapping ErrUnsupported.
+func ParseFirmwareBanner(line string) (FirmwareInfo, error) {
+	line = strings.TrimSpace(line)
+	model := bannerModelRe.FindString(line)
+	if model == "" {
+		return FirmwareInfo{}, fmt.Errorf("%q is not a firmware banner: %w", line, ErrUnsupported)
+	}
+	var version string
+	if m := bannerVersionRe.FindStringSubmatch(line); m != nil {
+		version = m[1]
+	} else if all := bannerNumberRe.FindAllString(line, -1); len(all) > 0 {
+		version = all[len(all)-1]
+	}
+	if version == "" {
+		return FirmwareInfo{}, fmt.Errorf("firmware banner %q has no version: %w", line, ErrUnsupported)
+	}
+	return FirmwareInfo{Model: model, Version: version, Banner: line}, nil
+}
@@ -0,0 +1,68 @@
+package truerng
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseFirmwareBanner(t *testing.T) {
+	for _, tc := range []struct{ line, model, version string }{
+		{"TrueRNGpro V2 Firmware 1.3\r", "TrueRNGpro V2", "1.3"},
+		{"TrueRNG v3 - version: 2.01", "TrueRNG v3", "2.01"},
+		{"  TrueRNGpro fw=v1.2.7 build 2019.04  ", "TrueRNGpro", "1.2.7"},
+		{"TrueRNGpro 1.0", "TrueRNGpro", "1.0"},
+	} {
+		info, err := ParseFirmwareBanner(tc.line)
+		if err != nil {
+			t.Errorf("ParseFirmwareBanner(%q): %v", tc.line, err)
+			continue
+		}
+		if info.Model != tc.model || info.Version != tc.version {
+			t.Errorf("ParseFirmwareBanner(%q) = %q %q, want %q %q", tc.line, info.Model, info.Version, tc.model, tc.version)
+		}
+	}
+
+	for _, line := range []string{"", "1.42 0.87 1.93", "TrueRNGpro ready"} {
+		if _, err := ParseFirmwareBanner(line); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("ParseFirmwareBanner(%q) = %v, want ErrUnsupported", line, err)
+		}
+	}
+}
+
+func TestReadFirmwareInfo(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, proPort(port, "PRO1"))
+	current := ModeNormal
+	fakeKnock(t, &current, nil, nil)
+
+	// The banner comes first, then PS_DEBUG frames fill the probe.
+	out := []byte("1.42 0.87\nTrueRNGpro Firmware 1.3\n")
+	out = append(out, bytes.Repeat([]byte("1.42 0.87\n"), firmwareProbeSize/10)...)
+	fakeSerial(t, map[string]*fakePort{port: {chunks: [][]byte{out}}})
+
+	info, err := ReadFirmwareInfo(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Model != "TrueRNGpro" || info.Version != "1.3" {
+		t.Errorf("info = %+v", info)
+	}
+	if current != ModeNormal {
+		t.Errorf("left the device in %s", current)
+	}
+
+	// Frames only: no banner.
+	fakeSerial(t, map[string]*fakePort{port: {chunks: [][]byte{bytes.Repeat([]byte("1.42 0.87\n"), firmwareProbeSize/10+1)}}})
+	if _, err := ReadFirmwareInfo(port); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("no banner: err = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestReadFirmwareInfoUnsupportedModel(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, ""))
+	if _, err := ReadFirmwareInfo(port); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("TrueRNG: err = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,353 @@
+package truerng
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// BinaryFrameSize is the size of the frame ReadFrame returns for binary modes,
+// which have no structure of their own.
+const BinaryFrameSize = 64
+
+// maxFrameLine bounds a text frame; anything longer means the device is not
+// emitting the expected line-based output.
+const maxFrameLine = 256
+
+// Frame is one unit of device output. Text modes emit one frame per line;
+// binary modes are cut into BinaryFrameSize chunks.
+type Frame struct {
+	Mode CaptureMode
+	// Raw is the frame as received, without the line terminator.
+	Raw []byte
+	// Values holds the numbers carried by debug frames: the supply voltage in
+	// mV for PSDEBUG, the two ADC readings (RNG1, RNG2) for RNGDEBUG and the
+	// sample values for RAW_ASC. It is nil for the other modes.
+	Values []int64
+}
+
+// ReadFrame reads one complete frame from the first TrueRNG, which must
+// already be in mode. For text modes any partial line in flight is skipped,
+// so the returned frame always starts at a line boundary.
+func ReadFrame(mode CaptureMode) (Frame, error) {
+	portName, err := FindPort()
+	if err != nil {
+		return Frame{}, err
+	}
+	if !mode.IsASCII() {
+		raw, err := readBytesFromPort(portName, mode, BinaryFrameSize)
+		if err != nil {
+			return Frame{}, err
+		}
+		return Frame{Mode: mode, Raw: raw}, nil
+	}
+	line, err := readFrameLine(portName)
+	if err != nil {
+		return Frame{}, err
+	}
+	return ParseFrame(mode, line)
+}
+
+// ParseFrame parses a single text frame (one line, with or without its line
+// terminator) produced in mode. Binary modes are accepted as-is.
+func ParseFrame(mode CaptureMode, line []byte) (Frame, error) {
+	raw := bytes.TrimRight(line, "\r\n")
+	f := Frame{Mode: mode, Raw: append([]byte(nil), raw...)}
+
+	var want int // number of values expected; -1 for any positive number
+	switch mode {
+	case ModePSDebug:
+		want = 1
+	case ModeRNGDebug:
+		want = 2
+	case ModeRawASC:
+		want = -1
+	default:
+		return f, nil
+	}
+
+	fields := strings.FieldsFunc(string(raw), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t'
+	})
+	if len(fields) == 0 {
+		return Frame{}, fmt.Errorf("%s frame %q: no values", mode, raw)
+	}
+	if want > 0 && len(fields) != want {
+		return Frame{}, fmt.Errorf("%s frame %q: want %d fields, got %d", mode, raw, want, len(fields))
+	}
+	for _, field := range fields {
+		v, err := parseFrameValue(field)
+		if err != nil {
+			return Frame{}, fmt.Errorf("%s frame %q: %w", mode, raw, err)
+		}
+		f.Values = append(f.Values, v)
+	}
+	return f, nil
+}
+
+// parseFrameValue parses a decimal or 0x-prefixed hexadecimal field. Leading
+// zeros in decimal fields are not treated as octal.
+func parseFrameValue(s string) (int64, error) {
+	if hexDigits, ok := strings.CutPrefix(strings.ToLower(s), "0x"); ok {
+		return strconv.ParseInt(hexDigits, 16, 64)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// readFrameLine opens portName, discards the partial line in flight and
+// returns the next complete line including its terminator.
+func readFrameLine(portName string) ([]byte, error) {
+	var out []byte
+	err := readFrameLines(portName, func(line []byte) (bool, error) {
+		out = append([]byte(nil), line...)
+		return true, nil
+	})
+	return out, err
+}
+
+// readFrameLines opens portName, discards the partial line in flight and
+// hands each following complete line (including its terminator) to onLine
+// until it reports done. The line slice is reused between calls. Every line
+// must arrive within 10 seconds.
+func readFrameLines(portName string, onLine func(line []byte) (done bool, err error)) error {
+	port, err := serialOpen(portName, &serial.Mode{Parity: serial.NoParity, StopBits: serial.OneStopBit})
+	if err != nil {
+		return fmt.Errorf("open %s: %w", portName, err)
+	}
+	defer func() { _ = port.Close() }()
+	_ = port.SetDTR(true)
+	_ = port.SetReadTimeout(1000 * time.Millisecond)
+	_ = port.ResetInputBuffer()
+
+	var line []byte
+	synced := false
+	buf := make([]byte, maxFrameLine)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if time.Now().After(deadline) {
+			return errors.New("read timeout after 10s waiting for a frame")
+		}
+		n, err := port.Read(buf)
+		if err != nil {
+			return readError(err)
+		}
+		if n == 0 {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		for _, c := range buf[:n] {
+			if !synced {
+				synced = c == '\n'
+				continue
+			}
+			line = append(line, c)
+			if c == '\n' {
+				done, err := onLine(line)
+				if done || err != nil {
+					return err
+				}
+				line = line[:0]
+				deadline = time.Now().Add(10 * time.Second)
+				continue
+			}
+			if len(line) > maxFrameLine {
+				return fmt.Errorf("no line terminator within %d bytes; is the device in a text mode?", maxFrameLine)
+			}
+		}
+	}
+}
+
+// ReadRNGDebugSamples switches the first TrueRNG to ModeRNGDebug and reads
+// until count ADC samples have been parsed. Each RNGDEBUG frame carries one
+// sample from each generator, so samples alternate RNG1, RNG2. A leading
+// partial frame is discarded. The device is switched back to ModeNormal
+// afterwards. A malformed frame fails the read; see
+// ReadRNGDebugSamplesWithOptions to skip such frames instead.
+func ReadRNGDebugSamples(count int) ([]uint16, error) {
+	samples, _, err := ReadRNGDebugSamplesWithOptions(count, RNGDebugOptions{})
+	return samples, err
+}
+
+// ReadRNGDebug is ReadRNGDebugSamples returning samples sample pairs split by
+// channel: rng1[i] and rng2[i] come from the same RNGDEBUG frame. This is the
+// form to plot each raw noise source on its own, e.g. to spot a degraded
+// channel.
+func ReadRNGDebug(samples int) (rng1, rng2 []int, err error) {
+	if samples <= 0 {
+		return nil, nil, errors.New("samples must be positive")
+	}
+	raw, err := ReadRNGDebugSamples(2 * samples)
+	if err != nil {
+		return nil, nil, err
+	}
+	rng1 = make([]int, samples)
+	rng2 = make([]int, samples)
+	for i := range samples {
+		rng1[i] = int(raw[2*i])
+		rng2[i] = int(raw[2*i+1])
+	}
+	return rng1, rng2, nil
+}
+
+// RNGDebugOptions tunes how RNGDEBUG output is parsed.
+type RNGDebugOptions struct {
+	// SkipMalformed drops frames that do not parse (e.g. after a glitch on the
+	// line) and carries on, instead of failing. Skipped frames are counted.
+	SkipMalformed bool
+}
+
+// ReadRNGDebugSamplesWithOptions is ReadRNGDebugSamples with opts applied. It
+// also returns the number of malformed frames skipped.
+func ReadRNGDebugSamplesWithOptions(count int, opts RNGDebugOptions) (samples []uint16, skipped int, err error) {
+	if count <= 0 {
+		return nil, 0, errors.New("count must be positive")
+	}
+	portName, err := FindPort()
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := switchMode(portName, ModeRNGDebug); err != nil {
+		return nil, 0, fmt.Errorf("switch to %s: %w", ModeRNGDebug, err)
+	}
+	defer func() { _ = switchMode(portName, ModeNormal) }()
+
+	samples = make([]uint16, 0, count)
+	err = readFrameLines(portName, func(line []byte) (bool, error) {
+		var ok bool
+		var perr error
+		samples, ok, perr = appendRNGDebugLine(samples, line, count, opts.SkipMalformed)
+		if !ok {
+			skipped++
+		}
+		return len(samples) == count, perr
+	})
+	if err != nil {
+		return nil, skipped, err
+	}
+	return samples, skipped, nil
+}
+
+// ParseRNGDebugSamples extracts up to count samples from a captured RNGDEBUG
+// stream. Like ReadRNGDebugSamples it skips everything up to the first line
+// break, since a capture may start mid-frame, and ignores a trailing
+// unterminated frame. A malformed frame fails the parse.
+func ParseRNGDebugSamples(stream []byte, count int) ([]uint16, error) {
+	samples, _, err := parseRNGDebugStream(stream, count, false)
+	return samples, err
+}
+
+// ParseRNGDebugLenient is ParseRNGDebugSamples with
+// RNGDebugOptions.SkipMalformed: frames that do not parse are dropped and
+// counted in skipped, so a long capture with occasional corruption still
+// yields its good samples.
+func ParseRNGDebugLenient(stream []byte, count int) (samples []uint16, skipped int, err error) {
+	return parseRNGDebugStream(stream, count, true)
+}
+
+// parseRNGDebugStream implements ParseRNGDebugSamples and
+// ParseRNGDebugLenient.
+func parseRNGDebugStream(stream []byte, count int, skipMalformed bool) (samples []uint16, skipped int, err error) {
+	if count <= 0 {
+		return nil, 0, errors.New("count must be positive")
+	}
+	i := bytes.IndexByte(stream, '\n')
+	if i < 0 {
+		return nil, 0, nil
+	}
+	stream = stream[i+1:]
+	for len(samples) < count {
+		j := bytes.IndexByte(stream, '\n')
+		if j < 0 {
+			break
+		}
+		var ok bool
+		if samples, ok, err = appendRNGDebugLine(samples, stream[:j+1], count, skipMalformed); err != nil {
+			return nil, skipped, err
+		}
+		if !ok {
+			skipped++
+		}
+		stream = stream[j+1:]
+	}
+	return samples, skipped, nil
+}
+
+// appendRNGDebugLine is appendRNGDebugSamples that, with skipMalformed,
+// leaves samples untouched and reports ok=false for a frame that does not
+// parse instead of returning the error.
+func appendRNGDebugLine(samples []uint16, line []byte, limit int, skipMalformed bool) (out []uint16, ok bool, err error) {
+	n := len(samples)
+	out, err = appendRNGDebugSamples(samples, line, limit)
+	if err != nil && skipMalformed {
+		return out[:n], false, nil
+	}
+	return out, true, err
+}
+
+// appendRNGDebugSamples parses one RNGDEBUG line and appends its samples,
+// stopping at limit. Blank lines are skipped.
+func appendRNGDebugSamples(samples []uint16, line []byte, limit int) ([]uint16, error) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return samples, nil
+	}
+	f, err := ParseFrame(ModeRNGDebug, line)
+	if err != nil {
+		return samples, err
+	}
+	for _, v := range f.Values {
+		if len(samples) == limit {
+			break
+		}
+		if v < 0 || v > 0xFFFF {
+			return samples, fmt.Errorf("RNGDEBUG sample %d out of range", v)
+		}
+		samples = append(samples, uint16(v))
+	}
+	return samples, nil
+}
+
+// psVoltageLines is how many readings ReadPSVoltage collects, and
+// psVoltageMaxLines how many lines it reads at most to get them.
+const (
+	psVoltageLines    = 10
+	psVoltageMaxLines = 4 * psVoltageLines
+)
+
+// ReadPSVoltage switches the first TrueRNG to ModePSDebug and returns 10
+// supply voltage readings in millivolts, one per line. Partial and
+// non-numeric lines (such as the firmware banner) are skipped. If junk keeps
+// coming, it gives up after 40 lines and returns the readings so far, failing
+// only when there are none. The device is switched back to ModeNormal
+// afterwards. PSDEBUG is only available on TrueRNGpro models.
+func ReadPSVoltage() ([]int, error) {
+	portName, err := FindPort()
+	if err != nil {
+		return nil, err
+	}
+	if err := switchMode(portName, ModePSDebug); err != nil {
+		return nil, fmt.Errorf("switch to %s: %w", ModePSDebug, err)
+	}
+	defer func() { _ = switchMode(portName, ModeNormal) }()
+
+	millivolts := make([]int, 0, psVoltageLines)
+	lines := 0
+	err = readFrameLines(portName, func(line []byte) (bool, error) {
+		lines++
+		if f, err := ParseFrame(ModePSDebug, line); err == nil {
+			millivolts = append(millivolts, int(f.Values[0]))
+		}
+		return len(millivolts) == psVoltageLines || lines == psVoltageMaxLines, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(millivolts) == 0 {
+		return nil, fmt.Errorf("no voltage readings in %d %s lines", lines, ModePSDebug)
+	}
+	return millivolts, nil
+}
@@ -0,0 +1,152 @@
+package truerng
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFrame(t *testing.T) {
+	tests := []struct {
+		mode CaptureMode
+		line string
+		want []int64
+	}{
+		{ModeRNGDebug, "0x0123 0x0ABC\r\n", []int64{0x123, 0xABC}},
+		{ModeRNGDebug, "0x0000,0x0FFF\n", []int64{0, 0xFFF}},
+		{ModePSDebug, "5012\r\n", []int64{5012}},
+		{ModePSDebug, "0980\n", []int64{980}}, // not octal
+		{ModeRawASC, "12 34 56 78\n", []int64{12, 34, 56, 78}},
+	}
+	for _, tt := range tests {
+		f, err := ParseFrame(tt.mode, []byte(tt.line))
+		if err != nil {
+			t.Errorf("ParseFrame(%s, %q): %v", tt.mode, tt.line, err)
+			continue
+		}
+		if !slices.Equal(f.Values, tt.want) {
+			t.Errorf("ParseFrame(%s, %q) values = %v, want %v", tt.mode, tt.line, f.Values, tt.want)
+		}
+		if bytes.ContainsAny(f.Raw, "\r\n") {
+			t.Errorf("ParseFrame(%s, %q) raw %q keeps the terminator", tt.mode, tt.line, f.Raw)
+		}
+	}
+
+	for _, bad := range []struct {
+		mode CaptureMode
+		line string
+	}{
+		{ModeRNGDebug, "0x0123\n"},
+		{ModePSDebug, "5012 5013\n"},
+		{ModePSDebug, "volts\n"},
+		{ModeRawASC, "\n"},
+	} {
+		if _, err := ParseFrame(bad.mode, []byte(bad.line)); err == nil {
+			t.Errorf("ParseFrame(%s, %q): want an error", bad.mode, bad.line)
+		}
+	}
+}
+
+func TestReadFrameSkipsPartialLine(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, proPort(port, "PRO1"))
+	for _, tt := range []struct {
+		mode   CaptureMode
+		stream string
+		raw    string
+		values []int64
+	}{
+		{ModeRNGDebug, "BC\r\n0x0123 0x0456\r\n0x0789 0x0ABC\r\n", "0x0123 0x0456", []int64{0x123, 0x456}},
+		{ModePSDebug, "12\r\n4987\r\n4990\r\n", "4987", []int64{4987}},
+	} {
+		fakeSerial(t, map[string]*fakePort{port: {src: bytes.NewReader([]byte(tt.stream))}})
+		f, err := ReadFrame(tt.mode)
+		if err != nil {
+			t.Fatalf("ReadFrame(%s): %v", tt.mode, err)
+		}
+		if string(f.Raw) != tt.raw || !slices.Equal(f.Values, tt.values) || f.Mode != tt.mode {
+			t.Errorf("ReadFrame(%s) = %q %v, want %q %v", tt.mode, f.Raw, f.Values, tt.raw, tt.values)
+		}
+	}
+}
+
+func TestReadFrameBinary(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	want := randomBytes(9, 3*BinaryFrameSize)
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: {src: bytes.NewReader(want)}})
+	f, err := ReadFrame(ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(f.Raw, want[:BinaryFrameSize]) || f.Values != nil {
+		t.Errorf("binary frame = %x %v, want the first %d bytes", f.Raw, f.Values, BinaryFrameSize)
+	}
+}
+
+func TestReadRNGDebugSamples(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, proPort(port, "PRO1"))
+	stream := "0A 0x0BCD\r\n0x0001 0x0002\r\n0x0003 0x0004\r\n0x0005 0x0006\r\n0x0007 0x0008\r\n"
+	fakeSerial(t, map[string]*fakePort{port: {src: bytes.NewReader([]byte(stream))}})
+	current := ModeNormal
+	fakeKnock(t, &current, nil, nil)
+
+	got, err := ReadRNGDebugSamples(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint16{1, 2, 3, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("ReadRNGDebugSamples(5) = %v, want %v", got, want)
+	}
+	if current != ModeNormal {
+		t.Errorf("device left in %s, want normal", current)
+	}
+}
+
+func TestParseRNGDebugSamples(t *testing.T) {
+	stream := []byte("23 0x0456\n0x0100 0x0200\nglitch\n0x0300 0x0400\n0x05")
+	if _, err := ParseRNGDebugSamples(stream, 10); err == nil {
+		t.Error("strict parse accepted a malformed frame")
+	}
+	got, skipped, err := ParseRNGDebugLenient(stream, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint16{0x100, 0x200, 0x300, 0x400}; !slices.Equal(got, want) || skipped != 1 {
+		t.Errorf("lenient parse = %v (skipped %d), want %v (skipped 1)", got, skipped, want)
+	}
+	got, err = ParseRNGDebugSamples(stream[:30], 3)
+	if err != nil || !slices.Equal(got, []uint16{0x100, 0x200}) {
+		t.Errorf("strict parse before the glitch = %v, %v; want [256 512]", got, err)
+	}
+}
+
+func TestReadRNGDebugSamplesSkipMalformed(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, proPort(port, "PRO1"))
+	stream := "0x0BCD\r\n0x0001 0x0002\r\n0x00#3 0x0004\r\n0x0003 0x0004\r\n\x00\x7f\r\n" +
+		"0x0005 0x0006\r\n0x0007 0xZZZZ\r\n0x0007 0x0008\r\n"
+	dev := &fakePort{}
+	fakeSerial(t, map[string]*fakePort{port: dev})
+	current := ModeNormal
+	fakeKnock(t, &current, nil, nil)
+
+	dev.src = strings.NewReader(stream)
+	if _, _, err := ReadRNGDebugSamplesWithOptions(8, RNGDebugOptions{}); err == nil {
+		t.Error("strict read accepted a malformed frame")
+	}
+
+	dev.src = strings.NewReader(stream)
+	got, skipped, err := ReadRNGDebugSamplesWithOptions(8, RNGDebugOptions{SkipMalformed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint16{1, 2, 3, 4, 5, 6, 7, 8}; !slices.Equal(got, want) || skipped != 3 {
+		t.Errorf("got %v (skipped %d), want %v (skipped 3)", got, skipped, want)
+	}
+	if current != ModeNormal {
+		t.Errorf("device left in %s, want normal", current)
+	}
+}
@@ -0,0 +1,80 @@
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// freshDrainPoll is the read timeout used while draining stale bytes. A read
+// that returns nothing within it means the host-side buffer is empty.
+const freshDrainPoll = 5 * time.Millisecond
+
+// freshReadDeadline bounds the collection of the fresh bytes. It is a
+// variable so tests need not wait it out.
+var freshReadDeadline = defaultChunkDeadline
+
+// ReadNowFresh returns n bytes that were produced after the call, for
+// interactive uses such as a "roll now" button. CDC-ACM devices buffer output
+// ahead of the reader even after ResetInputBuffer, so everything that can be
+// read immediately is treated as stale and discarded. Draining stops as soon
+// as the buffer runs dry or after maxStaleness, whichever comes first; the n
+// bytes returned are collected after that point. The whole call is bounded by
+// maxStaleness plus the usual 10s read deadline, after which it fails with
+// ErrReadTimeout.
+func ReadNowFresh(n int, mode CaptureMode, maxStaleness time.Duration) ([]byte, error) {
+	if n <= 0 {
+		return nil, errors.New("n must be positive")
+	}
+	if maxStaleness <= 0 {
+		return nil, errors.New("maxStaleness must be positive")
+	}
+	portName, err := FindPort()
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := serialOpen(portName, &serial.Mode{Parity: serial.NoParity, StopBits: serial.OneStopBit})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", portName, err)
+	}
+	defer func() { _ = port.Close() }()
+	_ = port.SetDTR(true)
+	_ = port.ResetInputBuffer()
+
+	// Drain: discard whatever is already queued.
+	_ = port.SetReadTimeout(freshDrainPoll)
+	scratch := make([]byte, 4096)
+	drainUntil := time.Now().Add(maxStaleness)
+	for time.Now().Before(drainUntil) {
+		m, err := port.Read(scratch)
+		if err != nil {
+			return nil, readError(err)
+		}
+		if m == 0 {
+			break
+		}
+	}
+
+	// Collect n newly arriving bytes.
+	_ = port.SetReadTimeout(1000 * time.Millisecond)
+	buf := make([]byte, n)
+	total := 0
+	deadline := time.Now().Add(freshReadDeadline)
+	for total < n {
+		if time.Now().After(deadline) {
+			return nil, &shortReadError{got: total, want: n, after: freshReadDeadline}
+		}
+		m, err := port.Read(buf[total:])
+		if err != nil {
+			return nil, readError(err)
+		}
+		total += m
+		if m == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	return buf, nil
+}
@@ -0,0 +1,46 @@
+package truerng
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadNowFreshDiscardsBufferedBytes(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	stale := bytes.Repeat([]byte{0xAA}, 6000)
+	fresh := randomBytes(3, 64)
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{
+		// The stale bytes arrive in two reads, then the buffer runs dry.
+		port: {chunks: [][]byte{stale, {}, fresh}},
+	})
+
+	got, err := ReadNowFresh(len(fresh), ModeNormal, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, fresh) {
+		t.Errorf("ReadNowFresh returned %x, want the fresh bytes %x", got, fresh)
+	}
+}
+
+func TestReadNowFreshTimeout(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	// Nothing stale, then only 10 of the 64 bytes before the device stalls.
+	fakeSerial(t, map[string]*fakePort{port: {chunks: [][]byte{{}, randomBytes(4, 10)}}})
+	old := freshReadDeadline
+	freshReadDeadline = 50 * time.Millisecond
+	t.Cleanup(func() { freshReadDeadline = old })
+
+	_, err := ReadNowFresh(64, ModeNormal, time.Second)
+	if !errors.Is(err, ErrReadTimeout) {
+		t.Fatalf("err = %v, want ErrReadTimeout", err)
+	}
+	if want := "read 10/64 bytes"; !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %q, want it to mention %q", err, want)
+	}
+}
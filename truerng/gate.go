@@ -0,0 +1,75 @@
+package truerng
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// ReadUntilQualityFail reads from the first TrueRNG in window-sized chunks,
+// passing each to check, and stops at the first window check rejects. It
+// returns every byte of the windows that passed and the index of the failing
+// window (so failedAt*window == len(goodBytes)). All good bytes are kept in
+// memory, so bound long runs with ctx. If ctx ends or a read fails first,
+// the good bytes so far are returned with failedAt -1 and the error.
+func ReadUntilQualityFail(ctx context.Context, mode CaptureMode, window int, check func([]byte) bool) (goodBytes []byte, failedAt int, err error) {
+	if window <= 0 {
+		return nil, -1, errors.New("window must be positive")
+	}
+	if check == nil {
+		return nil, -1, errors.New("check must not be nil")
+	}
+	portName, err := FindPort()
+	if err != nil {
+		return nil, -1, err
+	}
+
+	port, err := serialOpen(portName, &serial.Mode{Parity: serial.NoParity, StopBits: serial.OneStopBit})
+	if err != nil {
+		return nil, -1, fmt.Errorf("open %s: %w", portName, err)
+	}
+	defer func() { _ = port.Close() }()
+	_ = port.SetDTR(true)
+	_ = port.SetReadTimeout(1000 * time.Millisecond)
+	_ = port.ResetInputBuffer()
+
+	buf := make([]byte, window)
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return goodBytes, -1, err
+		}
+		if err := readFull(ctx, port, buf); err != nil {
+			return goodBytes, -1, err
+		}
+		if !check(buf) {
+			return goodBytes, index, nil
+		}
+		goodBytes = append(goodBytes, buf...)
+	}
+}
+
+// readFull fills buf from port, allowing 10 seconds for it.
+func readFull(ctx context.Context, port serial.Port, buf []byte) error {
+	total := 0
+	deadline := time.Now().Add(10 * time.Second)
+	for total < len(buf) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return &shortReadError{got: total, want: len(buf)}
+		}
+		n, err := port.Read(buf[total:])
+		if err != nil {
+			return readError(err)
+		}
+		total += n
+		if n == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReadUntilQualityFailDegrades(t *testing.T) {
+	const port, window, healthy = "/dev/ttyFAKE0", 1024, 3
+	good := randomBytes(18, healthy*window)
+	// After K healthy windows the fake device gets stuck at zero.
+	stream := append(append([]byte(nil), good...), make([]byte, 4*window)...)
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: {src: bytes.NewReader(stream)}})
+
+	got, failedAt, err := ReadUntilQualityFail(context.Background(), ModeNormal, window, func(w []byte) bool {
+		return ShannonEntropy(w) > 7
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failedAt != healthy {
+		t.Errorf("failedAt = %d, want %d", failedAt, healthy)
+	}
+	if !bytes.Equal(got, good) {
+		t.Errorf("got %d good bytes, want the %d before the failure", len(got), len(good))
+	}
+}
+
+func TestReadUntilQualityFailCancelled(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(19)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	windows := 0
+	got, failedAt, err := ReadUntilQualityFail(ctx, ModeNormal, 256, func([]byte) bool {
+		if windows++; windows == 2 {
+			cancel()
+		}
+		return true
+	})
+	if !errors.Is(err, context.Canceled) || failedAt != -1 || len(got) != 2*256 {
+		t.Errorf("got %d bytes, failedAt %d, err %v; want 512, -1, context.Canceled", len(got), failedAt, err)
+	}
+}
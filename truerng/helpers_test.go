@@ -0,0 +1,149 @@
+package truerng
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+// randomBytes returns n reproducible pseudo-random bytes standing in for
+// healthy device output.
+func randomBytes(seed uint64, n int) []byte {
+	r := rand.New(rand.NewPCG(seed, seed^0x9E3779B97F4A7C15))
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(r.Uint32())
+	}
+	return out
+}
+
+// fakePorts makes the enumerator report ports for the rest of the test.
+func fakePorts(t *testing.T, ports ...*enumerator.PortDetails) {
+	t.Helper()
+	old := listPorts
+	listPorts = func() ([]*enumerator.PortDetails, error) { return ports, nil }
+	t.Cleanup(func() { listPorts = old })
+}
+
+// trueRNGPort returns the enumerator entry of a TrueRNG V3 on name.
+func trueRNGPort(name, serialNumber string) *enumerator.PortDetails {
+	return &enumerator.PortDetails{
+		Name:         name,
+		IsUSB:        true,
+		VID:          "04D8",
+		PID:          "F5FE",
+		SerialNumber: serialNumber,
+		Product:      "TrueRNG",
+	}
+}
+
+// fakePort is a serial.Port standing in for a device. Reads return the
+// scripted chunks in turn (an empty chunk is a read that timed out), then
+// whatever src yields; with no src left every read times out.
+type fakePort struct {
+	mu      sync.Mutex
+	chunks  [][]byte
+	src     io.Reader
+	written bytes.Buffer
+	modes   []serial.Mode
+	opens   int
+	dtr     bool
+	closed  bool
+}
+
+func (p *fakePort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, os.ErrClosed
+	}
+	if len(p.chunks) > 0 {
+		n := copy(b, p.chunks[0])
+		if p.chunks[0] = p.chunks[0][n:]; len(p.chunks[0]) == 0 {
+			p.chunks = p.chunks[1:]
+		}
+		return n, nil
+	}
+	if p.src == nil {
+		return 0, nil
+	}
+	n, err := p.src.Read(b)
+	if err == io.EOF {
+		p.src = nil
+		err = nil
+	}
+	return n, err
+}
+
+func (p *fakePort) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.written.Write(b)
+}
+
+func (p *fakePort) SetMode(mode *serial.Mode) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.modes = append(p.modes, *mode)
+	return nil
+}
+
+func (p *fakePort) SetDTR(dtr bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dtr = dtr
+	return nil
+}
+
+func (p *fakePort) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *fakePort) Drain() error                       { return nil }
+func (p *fakePort) ResetInputBuffer() error            { return nil }
+func (p *fakePort) ResetOutputBuffer() error           { return nil }
+func (p *fakePort) SetRTS(bool) error                  { return nil }
+func (p *fakePort) SetReadTimeout(time.Duration) error { return nil }
+func (p *fakePort) Break(time.Duration) error          { return nil }
+func (p *fakePort) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	return &serial.ModemStatusBits{}, nil
+}
+
+// randomPort returns a fakePort streaming endless pseudo-random data.
+func randomPort(seed uint64) *fakePort {
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	return &fakePort{src: rand.NewChaCha8(key)}
+}
+
+// fakeSerial makes serialOpen open the given ports by name for the rest of
+// the test; any other name fails as a missing device would.
+func fakeSerial(t *testing.T, ports map[string]*fakePort) {
+	t.Helper()
+	old := serialOpen
+	serialOpen = func(name string, mode *serial.Mode) (serial.Port, error) {
+		p, ok := ports[name]
+		if !ok {
+			return nil, fmt.Errorf("open %s: %w", name, os.ErrNotExist)
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.opens++
+		p.closed = false
+		p.modes = append(p.modes, *mode)
+		return p, nil
+	}
+	t.Cleanup(func() { serialOpen = old })
+}
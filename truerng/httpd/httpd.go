@@ -0,0 +1,224 @@
+// Package httpd serves TrueRNG random bytes over HTTP. The handler answers
+// GET requests with `?bytes=N` random bytes (raw, or hex with `?format=hex`)
+// and can optionally require a bearer token and rate-limit each client.
+package httpd
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+const (
+	defaultBytes = 32
+	maxBytes     = 65536
+)
+
+type config struct {
+	mode      truerng.CaptureMode
+	port      string
+	token     string
+	rateLimit int
+}
+
+// Option configures Handler.
+type Option func(*config)
+
+// WithMode sets the capture mode used for reads (ModeNormal by default).
+func WithMode(mode truerng.CaptureMode) Option {
+	return func(c *config) { c.mode = mode }
+}
+
+// WithPort serves bytes from the TrueRNG on port, e.g. one picked with
+// truerng.FindDeviceBySerial, instead of the first detected one.
+func WithPort(port string) Option {
+	return func(c *config) { c.port = port }
+}
+
+// WithAuth requires every request to carry `Authorization: Bearer <token>`.
+// Requests without a matching token get 401 Unauthorized.
+func WithAuth(token string) Option {
+	return func(c *config) { c.token = token }
+}
+
+// WithClientRateLimit limits each client IP to bytesPerSecPerIP bytes per
+// second, with a burst of one second's worth. Requests over quota get 429 Too
+// Many Requests; a request for more than bytesPerSecPerIP bytes could never
+// fit the burst, so it gets 400 Bad Request instead. Values <= 0 disable the
+// limit.
+func WithClientRateLimit(bytesPerSecPerIP int) Option {
+	return func(c *config) { c.rateLimit = bytesPerSecPerIP }
+}
+
+// Handler returns an http.Handler serving random bytes from the first
+// detected TrueRNG (see WithPort).
+func Handler(opts ...Option) http.Handler {
+	cfg := config{mode: truerng.ModeNormal}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var h http.Handler = entropyHandler{mode: cfg.mode, port: cfg.port}
+	if cfg.rateLimit > 0 {
+		h = rateLimit(h, newLimiter(cfg.rateLimit))
+	}
+	if cfg.token != "" {
+		h = requireToken(h, cfg.token)
+	}
+	return h
+}
+
+type entropyHandler struct {
+	mode truerng.CaptureMode
+	port string
+}
+
+func (h entropyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	n, err := requestedBytes(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var data []byte
+	if h.port != "" {
+		data, err = truerng.ReadBitsFromPort(h.port, n*8, h.mode)
+	} else {
+		data, err = truerng.ReadBytesWithMode(n, h.mode)
+	}
+	if err != nil {
+		http.Error(w, "read error: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Query().Get("format") == "hex" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, hex.EncodeToString(data))
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(data)
+}
+
+// requestedBytes parses the `bytes` query parameter.
+func requestedBytes(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("bytes")
+	if v == "" {
+		return defaultBytes, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 || n > maxBytes {
+		return 0, fmt.Errorf("bytes must be between 1 and %d", maxBytes)
+	}
+	return n, nil
+}
+
+// requireToken rejects requests without the expected bearer token.
+func requireToken(next http.Handler, token string) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimit charges each request's byte count against its client's bucket.
+func rateLimit(next http.Handler, l *limiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := requestedBytes(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if float64(n) > l.rate {
+			http.Error(w, fmt.Sprintf("bytes must not exceed the rate limit of %d per second", int(l.rate)), http.StatusBadRequest)
+			return
+		}
+		if !l.allow(clientIP(r), n, time.Now()) {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the host part of the request's remote address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limiterSweepInterval is how often the limiter drops idle buckets.
+const limiterSweepInterval = time.Minute
+
+// limiter is a per-client token bucket measured in bytes. A bucket that has
+// sat idle long enough to refill completely is no different from a new one,
+// so such buckets are swept out periodically to keep the map from growing
+// with every client ever seen.
+type limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(bytesPerSec int) *limiter {
+	return &limiter{rate: float64(bytesPerSec), buckets: map[string]*bucket{}}
+}
+
+// allow reports whether client may take n bytes at now, consuming them if so.
+func (l *limiter) allow(client string, n int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= limiterSweepInterval {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.rate, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.rate {
+		b.tokens = l.rate
+	}
+	b.last = now
+	if float64(n) > b.tokens {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// sweep drops the buckets that are full again at now.
+func (l *limiter) sweep(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.rate {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,102 @@
+package httpd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// okHandler stands in for the device-backed handler.
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func get(t *testing.T, h http.Handler, target, remote, auth string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = remote
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRequireToken(t *testing.T) {
+	h := requireToken(okHandler, "s3cret")
+	for _, tc := range []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"s3cret", http.StatusUnauthorized},
+		{"Bearer s3cret", http.StatusOK},
+	} {
+		if got := get(t, h, "/?bytes=8", "192.0.2.1:1000", tc.auth); got != tc.want {
+			t.Errorf("Authorization %q: status %d, want %d", tc.auth, got, tc.want)
+		}
+	}
+}
+
+func TestHandlerAuthBeforeRead(t *testing.T) {
+	// The token check runs before any device access, so no TrueRNG is needed.
+	srv := httptest.NewServer(Handler(WithAuth("s3cret")))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/?bytes=8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status %d, want 401", resp.StatusCode)
+	}
+	if resp.Header.Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("WWW-Authenticate = %q, want Bearer", resp.Header.Get("WWW-Authenticate"))
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := rateLimit(okHandler, newLimiter(100))
+	const alice, bob = "192.0.2.1:1000", "192.0.2.2:1000"
+
+	if got := get(t, h, "/?bytes=60", alice, ""); got != http.StatusOK {
+		t.Fatalf("first request: status %d, want 200", got)
+	}
+	if got := get(t, h, "/?bytes=60", alice, ""); got != http.StatusTooManyRequests {
+		t.Errorf("over quota: status %d, want 429", got)
+	}
+	if got := get(t, h, "/?bytes=60", bob, ""); got != http.StatusOK {
+		t.Errorf("other client: status %d, want 200", got)
+	}
+	if got := get(t, h, "/?bytes=101", bob, ""); got != http.StatusBadRequest {
+		t.Errorf("larger than the burst: status %d, want 400", got)
+	}
+	if got := get(t, h, "/?bytes=junk", bob, ""); got != http.StatusBadRequest {
+		t.Errorf("bad bytes: status %d, want 400", got)
+	}
+}
+
+func TestLimiterRefillsAndSweeps(t *testing.T) {
+	l := newLimiter(100)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if !l.allow("a", 100, start) {
+		t.Fatal("full burst refused")
+	}
+	if l.allow("a", 50, start.Add(400*time.Millisecond)) {
+		t.Error("allowed 50 bytes after refilling 40")
+	}
+	if !l.allow("a", 50, start.Add(500*time.Millisecond)) {
+		t.Error("refused 50 bytes after refilling 50")
+	}
+	l.allow("b", 1, start.Add(time.Second))
+
+	// A minute later both buckets are full again and get swept; only the
+	// requesting client's is recreated.
+	l.allow("c", 1, start.Add(2*limiterSweepInterval))
+	if len(l.buckets) != 1 || l.buckets["c"] == nil {
+		t.Errorf("buckets after sweep = %v, want only c", l.buckets)
+	}
+}
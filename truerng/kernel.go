@@ -0,0 +1,128 @@
+package truerng
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// MaxEntropyCredit is the largest per-byte credit FeedKernelEntropy accepts.
+// Crediting the full 8 bits per byte would claim a perfect source; one bit of
+// margin is kept even for a healthy device.
+const MaxEntropyCredit = 7
+
+// kernelFeedChunk is how many bytes are handed to the kernel per ioctl.
+const kernelFeedChunk = 512
+
+// kernelPoolPath is the device the feed writes to, and addKernelEntropy the
+// ioctl that credits it. They are variables so the feed can run against a
+// fake pool.
+var (
+	kernelPoolPath   = "/dev/random"
+	addKernelEntropy = ioctlAddEntropy
+)
+
+// FeedStats accounts for the data fed to the kernel pool so far.
+type FeedStats struct {
+	Writes       int
+	Bytes        int64
+	CreditedBits int64
+}
+
+// FeedOptions tunes FeedKernelEntropyWithOptions.
+type FeedOptions struct {
+	// DryRun reads from the device and keeps the accounting but does not
+	// touch /dev/random, so it needs no privileges.
+	DryRun bool
+	// OnFeed, when set, is called after every chunk with the running totals.
+	OnFeed func(FeedStats)
+	// Device, when set, is read from instead of the first detected TrueRNG.
+	Device *DeviceInfo
+}
+
+// FeedKernelEntropy reads from the first TrueRNG and adds the data to the
+// Linux kernel entropy pool until ctx is cancelled, crediting
+// creditBitsPerByte bits of entropy per byte (0 mixes the data in without
+// credit). Crediting needs CAP_SYS_ADMIN. Keep the credit conservative: it is
+// a claim about the device, and an over-estimate weakens the kernel's
+// guarantees.
+func FeedKernelEntropy(ctx context.Context, mode CaptureMode, creditBitsPerByte int) error {
+	_, err := FeedKernelEntropyWithOptions(ctx, mode, creditBitsPerByte, FeedOptions{})
+	return err
+}
+
+// FeedKernelEntropyWithOptions is FeedKernelEntropy with opts applied. It
+// returns the final accounting alongside the error that ended the loop
+// (ctx.Err() on cancellation).
+func FeedKernelEntropyWithOptions(ctx context.Context, mode CaptureMode, creditBitsPerByte int, opts FeedOptions) (FeedStats, error) {
+	if creditBitsPerByte < 0 || creditBitsPerByte > MaxEntropyCredit {
+		return FeedStats{}, fmt.Errorf("entropy credit must be between 0 and %d bits per byte, got %d", MaxEntropyCredit, creditBitsPerByte)
+	}
+	return feedKernel(ctx, mode, creditBitsPerByte, opts)
+}
+
+// FeedKernelPool makes the process an rngd for the first TrueRNG: it reads
+// from the device in mode and adds the data to /dev/random with the
+// RNDADDENTROPY ioctl, crediting bitsPerByte bits of entropy per byte, until
+// ctx ends (returning ctx.Err()). bitsPerByte ranges over 0 to 8, the full
+// 8 claiming a perfect source; FeedKernelEntropy stops at MaxEntropyCredit
+// and offers the dry run and accounting of FeedOptions.
+func FeedKernelPool(ctx context.Context, mode CaptureMode, bitsPerByte int) error {
+	if bitsPerByte < 0 || bitsPerByte > 8 {
+		return fmt.Errorf("bitsPerByte must be between 0 and 8, got %d", bitsPerByte)
+	}
+	_, err := feedKernel(ctx, mode, bitsPerByte, FeedOptions{})
+	return err
+}
+
+// feedKernel is the loop behind FeedKernelEntropyWithOptions and
+// FeedKernelPool, with creditBitsPerByte already checked.
+func feedKernel(ctx context.Context, mode CaptureMode, creditBitsPerByte int, opts FeedOptions) (FeedStats, error) {
+	var st FeedStats
+	var pool *os.File
+	if !opts.DryRun {
+		f, err := os.OpenFile(kernelPoolPath, os.O_WRONLY, 0)
+		if err != nil {
+			return st, fmt.Errorf("open %s: %w", kernelPoolPath, err)
+		}
+		defer f.Close()
+		pool = f
+	}
+
+	var s *Session
+	var err error
+	if opts.Device != nil {
+		s, err = OpenDevice(*opts.Device, mode)
+	} else {
+		s, err = Open(mode)
+	}
+	if err != nil {
+		return st, err
+	}
+	defer s.Close()
+
+	buf := make([]byte, kernelFeedChunk)
+	for {
+		if err := ctx.Err(); err != nil {
+			return st, err
+		}
+		if err := s.fill(buf); err != nil {
+			return st, err
+		}
+		if err := CheckStuck(buf); err != nil {
+			return st, err
+		}
+		credit := len(buf) * creditBitsPerByte
+		if pool != nil {
+			if err := addKernelEntropy(pool, buf, credit); err != nil {
+				return st, err
+			}
+		}
+		st.Writes++
+		st.Bytes += int64(len(buf))
+		st.CreditedBits += int64(credit)
+		if opts.OnFeed != nil {
+			opts.OnFeed(st)
+		}
+	}
+}
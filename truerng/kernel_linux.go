@@ -0,0 +1,29 @@
+//go:build linux
+
+package truerng
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// rndAddEntropy is RNDADDENTROPY, _IOW('R', 0x03, int[2]).
+const rndAddEntropy = 0x40085203
+
+// ioctlAddEntropy mixes data into the kernel pool via RNDADDENTROPY on
+// /dev/random, crediting creditBits bits.
+func ioctlAddEntropy(pool *os.File, data []byte, creditBits int) error {
+	// struct rand_pool_info { int entropy_count; int buf_size; __u32 buf[]; }
+	req := make([]byte, 8+len(data)+3)
+	binary.NativeEndian.PutUint32(req[0:], uint32(creditBits))
+	binary.NativeEndian.PutUint32(req[4:], uint32(len(data)))
+	copy(req[8:], data)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, pool.Fd(), rndAddEntropy, uintptr(unsafe.Pointer(&req[0])))
+	if errno != 0 {
+		return fmt.Errorf("RNDADDENTROPY: %w", errno)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package truerng
+
+import (
+	"errors"
+	"os"
+)
+
+// ioctlAddEntropy is only implemented on Linux; use FeedOptions.DryRun
+// elsewhere.
+func ioctlAddEntropy(pool *os.File, data []byte, creditBits int) error {
+	return errors.New("feeding the kernel entropy pool is only supported on Linux")
+}
@@ -0,0 +1,87 @@
+package truerng
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFeedKernelEntropyDryRun(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(22)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var seen []FeedStats
+	st, err := FeedKernelEntropyWithOptions(ctx, ModeNormal, 4, FeedOptions{DryRun: true, OnFeed: func(s FeedStats) {
+		seen = append(seen, s)
+		if len(seen) == 3 {
+			cancel()
+		}
+	}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	want := FeedStats{Writes: 3, Bytes: 3 * kernelFeedChunk, CreditedBits: 3 * kernelFeedChunk * 4}
+	if st != want {
+		t.Errorf("stats = %+v, want %+v", st, want)
+	}
+	if len(seen) != 3 || seen[0].Bytes != kernelFeedChunk {
+		t.Errorf("OnFeed saw %+v", seen)
+	}
+}
+
+func TestFeedKernelEntropyCreditRange(t *testing.T) {
+	for _, credit := range []int{-1, MaxEntropyCredit + 1} {
+		if _, err := FeedKernelEntropyWithOptions(context.Background(), ModeNormal, credit, FeedOptions{DryRun: true}); err == nil {
+			t.Errorf("credit %d accepted", credit)
+		}
+	}
+}
+
+func TestFeedKernelPool(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(23)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type ioctl struct{ size, credit int }
+	var calls []ioctl
+	oldPath, oldAdd := kernelPoolPath, addKernelEntropy
+	kernelPoolPath = filepath.Join(t.TempDir(), "random")
+	if err := os.WriteFile(kernelPoolPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	addKernelEntropy = func(pool *os.File, data []byte, creditBits int) error {
+		if pool.Name() != kernelPoolPath {
+			t.Errorf("ioctl on %s, want %s", pool.Name(), kernelPoolPath)
+		}
+		calls = append(calls, ioctl{len(data), creditBits})
+		if len(calls) == 2 {
+			cancel()
+		}
+		return nil
+	}
+	t.Cleanup(func() { kernelPoolPath, addKernelEntropy = oldPath, oldAdd })
+
+	if err := FeedKernelPool(ctx, ModeNormal, 8); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	want := []ioctl{{kernelFeedChunk, 8 * kernelFeedChunk}, {kernelFeedChunk, 8 * kernelFeedChunk}}
+	if !slices.Equal(calls, want) {
+		t.Errorf("ioctls %v, want %v", calls, want)
+	}
+
+	for _, bits := range []int{-1, 9} {
+		if err := FeedKernelPool(context.Background(), ModeNormal, bits); err == nil {
+			t.Errorf("bitsPerByte %d accepted", bits)
+		}
+	}
+	if len(calls) != 2 {
+		t.Error("an out-of-range bitsPerByte reached the ioctl")
+	}
+}
@@ -0,0 +1,33 @@
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FindDeviceByLocation returns the detected TrueRNG plugged into the USB
+// port at busPort, in the form USBLocation reports (e.g. "1-2.3"). Selecting
+// by physical slot is deterministic even among units whose serial numbers
+// are missing or duplicated. It needs USBLocation, so it fails with
+// ErrUnsupported outside Linux.
+func FindDeviceByLocation(busPort string) (*DeviceInfo, error) {
+	busPort = strings.TrimSpace(busPort)
+	if busPort == "" {
+		return nil, errors.New("location must not be empty")
+	}
+	devices, err := EnumerateDevices()
+	if err != nil {
+		return nil, err
+	}
+	for i := range devices {
+		loc, err := devices[i].USBLocation()
+		if errors.Is(err, ErrUnsupported) {
+			return nil, err
+		}
+		if err == nil && loc == busPort {
+			return &devices[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w at location %q", ErrDeviceNotFound, busPort)
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package truerng
+
+import "path/filepath"
+
+// USBLocation returns the device's position in the USB topology as the
+// kernel names it: bus, then the hub port chain, e.g. "1-2.3" for port 3 of
+// the hub on port 2 of bus 1. It stays the same for whatever unit is plugged
+// into that slot.
+func (d *DeviceInfo) USBLocation() (string, error) {
+	dir, err := usbDeviceDir(d.Port)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(dir), nil
+}
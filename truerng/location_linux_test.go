@@ -0,0 +1,22 @@
+package truerng
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFindDeviceByLocation(t *testing.T) {
+	fakePorts(t, trueRNGPort("/dev/ttyFAKE0", "A1"))
+	fakeSysfs(t, "ttyFAKE0", "1-2.3", "12")
+
+	d, err := FindDeviceByLocation(" 1-2.3 ")
+	if err != nil || d.Port != "/dev/ttyFAKE0" {
+		t.Fatalf("FindDeviceByLocation(1-2.3) = %v, %v; want /dev/ttyFAKE0", d, err)
+	}
+	if _, err := FindDeviceByLocation("1-2.4"); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("absent location: err = %v, want ErrDeviceNotFound", err)
+	}
+	if _, err := FindDeviceByLocation(""); err == nil {
+		t.Error("empty location accepted")
+	}
+}
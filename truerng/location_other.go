@@ -0,0 +1,9 @@
+//go:build !linux
+
+package truerng
+
+// USBLocation is only implemented on Linux, where sysfs names each USB
+// device after its position in the topology.
+func (d *DeviceInfo) USBLocation() (string, error) {
+	return "", ErrUnsupported
+}
@@ -0,0 +1,86 @@
+package truerng
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"time"
+)
+
+// Manifest describes an archived capture. It is written as a JSON sidecar
+// next to the data file so the capture documents itself.
+type Manifest struct {
+	Model        string      `json:"model"`
+	SerialNumber string      `json:"serial_number,omitempty"`
+	Port         string      `json:"port,omitempty"`
+	Mode         CaptureMode `json:"mode"`
+	Start        time.Time   `json:"start"`
+	End          time.Time   `json:"end"`
+	TotalBytes   int64       `json:"total_bytes"`
+	Entropy      float64     `json:"entropy_bits_per_byte"`
+	OnesRatio    float64     `json:"ones_ratio"`
+	SHA256       string      `json:"sha256"`
+}
+
+// ManifestPath returns the sidecar path for a data file, e.g.
+// "capture.bin" -> "capture.bin.manifest.json".
+func ManifestPath(dataPath string) string {
+	return dataPath + ".manifest.json"
+}
+
+// WriteManifest writes m to path as indented JSON.
+func WriteManifest(path string, m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// ManifestBuilder accumulates the digest and entropy summary of a capture as
+// its batches are written, so the manifest needs no second pass over the data.
+type ManifestBuilder struct {
+	m   Manifest
+	h   hash.Hash
+	est EntropyEstimator
+}
+
+// NewManifestBuilder starts a manifest for a capture from device in mode. The
+// start time is taken now.
+func NewManifestBuilder(device DeviceInfo, mode CaptureMode) *ManifestBuilder {
+	return &ManifestBuilder{
+		m: Manifest{
+			Model:        device.Model.String(),
+			SerialNumber: device.SerialNumber,
+			Port:         device.Port,
+			Mode:         mode,
+			Start:        time.Now(),
+		},
+		h: sha256.New(),
+	}
+}
+
+// Add records a batch that was written to the capture.
+func (b *ManifestBuilder) Add(batch []byte) {
+	b.h.Write(batch)
+	b.est.Add(batch)
+	b.m.TotalBytes += int64(len(batch))
+}
+
+// Manifest returns the manifest for everything added so far, with the end
+// time set to now.
+func (b *ManifestBuilder) Manifest() Manifest {
+	m := b.m
+	m.End = time.Now()
+	m.Entropy = b.est.Entropy()
+	m.OnesRatio = b.est.OnesRatio()
+	m.SHA256 = hex.EncodeToString(b.h.Sum(nil))
+	return m
+}
@@ -0,0 +1,66 @@
+package truerng
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteManifestFields(t *testing.T) {
+	device := DeviceInfo{Port: "/dev/ttyFAKE0", Model: DeviceModelTrueRNGpro, SerialNumber: "TR0001"}
+	b := NewManifestBuilder(device, ModeRawBin)
+	data := randomBytes(1, 4096)
+	b.Add(data[:1000])
+	b.Add(data[1000:])
+	m := b.Manifest()
+
+	path := ManifestPath(filepath.Join(t.TempDir(), "capture.bin"))
+	if filepath.Base(path) != "capture.bin.manifest.json" {
+		t.Fatalf("ManifestPath = %s", path)
+	}
+	if err := WriteManifest(path, m); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(data)
+	want := map[string]any{
+		"model":         "TrueRNGpro",
+		"serial_number": "TR0001",
+		"port":          "/dev/ttyFAKE0",
+		"mode":          string(ModeRawBin),
+		"total_bytes":   float64(len(data)),
+		"sha256":        hex.EncodeToString(sum[:]),
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %v, want %v", k, fields[k], v)
+		}
+	}
+	if h, ok := fields["entropy_bits_per_byte"].(float64); !ok || h < 7.9 || h > 8 {
+		t.Errorf("entropy_bits_per_byte = %v, want about 8", fields["entropy_bits_per_byte"])
+	}
+	if r, ok := fields["ones_ratio"].(float64); !ok || r < 0.49 || r > 0.51 {
+		t.Errorf("ones_ratio = %v, want about 0.5", fields["ones_ratio"])
+	}
+	for _, k := range []string{"start", "end"} {
+		s, _ := fields[k].(string)
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			t.Errorf("%s = %q: %v", k, s, err)
+		}
+	}
+	if m.End.Before(m.Start) {
+		t.Errorf("end %v before start %v", m.End, m.Start)
+	}
+}
@@ -0,0 +1,55 @@
+package truerng
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSVMetrics writes collect statistics as CSV rows of
+//
+//	timestamp,bytes,bytes_per_sec,entropy_bits_per_byte,ones_ratio
+//
+// with the header emitted before the first row. Use its Record method as
+// CollectConfig.OnStats.
+type CSVMetrics struct {
+	w           *csv.Writer
+	wroteHeader bool
+	err         error
+}
+
+// CSVMetricsSink returns a CSVMetrics writing to w.
+func CSVMetricsSink(w io.Writer) *CSVMetrics {
+	return &CSVMetrics{w: csv.NewWriter(w)}
+}
+
+// Record appends one row for s and flushes it. After the first write error
+// further rows are dropped; the error is available from Err.
+func (m *CSVMetrics) Record(s CollectStats) {
+	if m.err != nil {
+		return
+	}
+	if !m.wroteHeader {
+		m.err = m.w.Write([]string{"timestamp", "bytes", "bytes_per_sec", "entropy_bits_per_byte", "ones_ratio"})
+		m.wroteHeader = true
+	}
+	if m.err == nil {
+		m.err = m.w.Write([]string{
+			s.Time.Format(time.RFC3339),
+			strconv.Itoa(s.Bytes),
+			strconv.FormatFloat(s.BytesPerSec, 'f', 1, 64),
+			strconv.FormatFloat(s.Entropy, 'f', 4, 64),
+			strconv.FormatFloat(s.OnesRatio, 'f', 4, 64),
+		})
+	}
+	m.w.Flush()
+	if m.err == nil {
+		m.err = m.w.Error()
+	}
+}
+
+// Err returns the first error encountered while writing rows.
+func (m *CSVMetrics) Err() error {
+	return m.err
+}
@@ -0,0 +1,44 @@
+package truerng
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCSVMetricsRows(t *testing.T) {
+	var buf bytes.Buffer
+	m := CSVMetricsSink(&buf)
+	m.Record(CollectStats{
+		Time:        time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Bytes:       1024,
+		TotalBytes:  4096,
+		BytesPerSec: 512.25,
+		Entropy:     7.81234,
+		OnesRatio:   0.50017,
+	})
+	m.Record(CollectStats{Time: time.Date(2024, 5, 1, 12, 30, 1, 0, time.UTC), Bytes: 8})
+	if err := m.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := "timestamp,bytes,bytes_per_sec,entropy_bits_per_byte,ones_ratio\n" +
+		"2024-05-01T12:30:00Z,1024,512.2,7.8123,0.5002\n" +
+		"2024-05-01T12:30:01Z,8,0.0,0.0000,0.0000\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestCSVMetricsWriteError(t *testing.T) {
+	m := CSVMetricsSink(failingWriter{})
+	m.Record(CollectStats{Time: time.Now()})
+	m.Record(CollectStats{Time: time.Now()})
+	if m.Err() == nil {
+		t.Error("write error not reported")
+	}
+}
@@ -0,0 +1,448 @@
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// modeVerifySize is how many bytes are read to check that a mode switch took.
+const modeVerifySize = 64
+
+// switchMode and verifyMode are the hooks ReachableModes uses to talk to the
+// device. They are variables so the probing logic can be exercised without
+// hardware.
+var (
+	switchMode = changeMode
+	verifyMode = verifyModeOnPort
+)
+
+var (
+	reachableMu     sync.Mutex
+	reachableCache  = map[string][]CaptureMode{}
+	switchableCache = map[string]bool{}
+)
+
+// switchSettle is how long CanSwitchMode waits after a knock for a USB
+// re-enumeration to show up. It is a variable so tests need not wait.
+var switchSettle = 1500 * time.Millisecond
+
+// After a knock, ReadBytesWithModeSwitch polls every reenumeratePoll for up
+// to reenumerateTimeout for the device to be back.
+const (
+	reenumerateTimeout = 5 * time.Second
+	reenumeratePoll    = 250 * time.Millisecond
+)
+
+// ModeDescriptor is the metadata of one capture mode.
+type ModeDescriptor struct {
+	Mode CaptureMode
+	// ShortName is the lower-case name used on the command line.
+	ShortName string
+	// BaudRate is the rate whose knock sequence selects the mode.
+	BaudRate int
+	// IsASCII is set for modes that emit text rather than binary data.
+	IsASCII         bool
+	SupportedModels []DeviceModel
+	Description     string
+}
+
+var (
+	allModels = []DeviceModel{DeviceModelTrueRNG, DeviceModelTrueRNGpro, DeviceModelTrueRNGproV2}
+	proModels = []DeviceModel{DeviceModelTrueRNGpro, DeviceModelTrueRNGproV2}
+	v2Models  = []DeviceModel{DeviceModelTrueRNGproV2}
+)
+
+// modeTable is the single source of mode metadata, ordered by baud rate.
+var modeTable = []ModeDescriptor{
+	{ModeNormal, "normal", 300, false, allModels, "Streams combined + Mersenne Twister"},
+	{ModePSDebug, "psdebug", 1200, true, proModels, "PS Voltage in mV in ASCII"},
+	{ModeRNGDebug, "rngdebug", 2400, true, proModels, "RNG Debug 0x0RRR 0x0RRR in ASCII"},
+	{ModeRNG1White, "rng1white", 4800, false, proModels, "RNG1 + Mersenne Twister"},
+	{ModeRNG2White, "rng2white", 9600, false, proModels, "RNG2 + Mersenne Twister"},
+	{ModeRawBin, "raw_bin", 19200, false, proModels, "Raw ADC Samples in Binary Mode"},
+	{ModeRawASC, "raw_asc", 38400, true, proModels, "Raw ADC Samples in ASCII Mode"},
+	{ModeUnwhitened, "unwhitened", 57600, false, v2Models, "Unwhitened RNG1-RNG2"},
+	{ModeNormalASC, "normal_asc", 115200, true, v2Models, "Normal in ASCII Mode"},
+	{ModeNormalASCSlow, "normal_asc_slow", 230400, true, v2Models, "Normal in ASCII Mode - Slow for small devices"},
+}
+
+// ModeTable returns the metadata of every capture mode, ordered by baud
+// rate. The result is a copy and may be modified.
+func ModeTable() []ModeDescriptor {
+	out := make([]ModeDescriptor, len(modeTable))
+	for i, d := range modeTable {
+		d.SupportedModels = append([]DeviceModel(nil), d.SupportedModels...)
+		out[i] = d
+	}
+	return out
+}
+
+// lookupMode returns the descriptor of m.
+func lookupMode(m CaptureMode) (ModeDescriptor, bool) {
+	for _, d := range modeTable {
+		if d.Mode == m {
+			return d, true
+		}
+	}
+	return ModeDescriptor{}, false
+}
+
+// SupportedModes returns the capture modes the given model documents, in
+// ModeTable order. The original TrueRNG has a single output mode; the
+// V2-only modes are listed for TrueRNGproV2 alone.
+func SupportedModes(model DeviceModel) []CaptureMode {
+	var modes []CaptureMode
+	for _, d := range modeTable {
+		if slices.Contains(d.SupportedModels, model) {
+			modes = append(modes, d.Mode)
+		}
+	}
+	return modes
+}
+
+// ReachableModes reports which of the modes the device on port claims to
+// support can actually be entered from this host. Each mode is tried with the
+// knock sequence and then verified by reading a short sample; modes whose
+// switch or verification fails are left out. The device is knocked back to
+// ModeNormal afterwards.
+//
+// Probing takes a few seconds per mode, so the result is cached per device
+// (by serial number, or port when the serial is unknown). Use
+// ForgetReachableModes to force a fresh probe.
+func ReachableModes(port string) ([]CaptureMode, error) {
+	device, err := deviceOnPort(port)
+	if err != nil {
+		return nil, err
+	}
+	key := reachableKey(*device)
+
+	reachableMu.Lock()
+	defer reachableMu.Unlock()
+	if modes, ok := reachableCache[key]; ok {
+		return append([]CaptureMode(nil), modes...), nil
+	}
+
+	modes := probeReachableModes(port, SupportedModes(device.Model))
+	reachableCache[key] = modes
+	return append([]CaptureMode(nil), modes...), nil
+}
+
+// ForgetReachableModes drops the cached ReachableModes and CanSwitchMode
+// results for the device on port, or for every device when port is empty.
+func ForgetReachableModes(port string) {
+	reachableMu.Lock()
+	defer reachableMu.Unlock()
+	if port == "" {
+		reachableCache = map[string][]CaptureMode{}
+		switchableCache = map[string]bool{}
+		return
+	}
+	keys := []string{port}
+	if device, err := deviceOnPort(port); err == nil {
+		keys = append(keys, reachableKey(*device))
+	}
+	for _, key := range keys {
+		delete(reachableCache, key)
+		delete(switchableCache, key)
+	}
+}
+
+// CanSwitchMode reports whether the knock sequence is safe on this host for
+// the device on port. With some hosts and drivers the open/close knock makes
+// the device re-enumerate or disappear, in which case mode switching should
+// not be offered at all. The test is reversible: it knocks into ModeNormal
+// (the mode the device is normally in), waits for things to settle and checks
+// that the device is still on port and still streams binary data.
+//
+// A device that vanishes yields false with a nil error; an error means the
+// test could not be run, e.g. the port could not be opened. Conclusive
+// results are cached per device like ReachableModes.
+func CanSwitchMode(port string) (bool, error) {
+	device, err := deviceOnPort(port)
+	if err != nil {
+		return false, err
+	}
+	key := reachableKey(*device)
+
+	reachableMu.Lock()
+	defer reachableMu.Unlock()
+	if ok, cached := switchableCache[key]; cached {
+		return ok, nil
+	}
+
+	if err := switchMode(port, ModeNormal); err != nil {
+		return false, err
+	}
+	time.Sleep(switchSettle)
+	ok := true
+	if _, err := deviceOnPort(port); err != nil {
+		ok = false
+	} else if err := verifyMode(port, ModeNormal); err != nil {
+		ok = false
+	}
+	switchableCache[key] = ok
+	return ok, nil
+}
+
+// probeReachableModes knocks into each candidate mode in turn and keeps those
+// that verify.
+func probeReachableModes(port string, candidates []CaptureMode) []CaptureMode {
+	var reachable []CaptureMode
+	for _, mode := range candidates {
+		if err := switchMode(port, mode); err != nil {
+			continue
+		}
+		if err := verifyMode(port, mode); err != nil {
+			continue
+		}
+		reachable = append(reachable, mode)
+	}
+	if len(candidates) > 0 {
+		_ = switchMode(port, ModeNormal)
+	}
+	return reachable
+}
+
+// verifyModeOnPort reads a short sample and checks that its shape matches the
+// mode: ASCII modes must produce printable text, binary modes must produce
+// bytes outside the printable range. This tells ASCII and binary output apart
+// but cannot distinguish, say, RNG1WHITE from NORMAL.
+func verifyModeOnPort(port string, mode CaptureMode) error {
+	sample, err := readBytesFromPort(port, mode, modeVerifySize)
+	if err != nil {
+		return err
+	}
+	text := isASCIIText(sample)
+	if mode.IsASCII() != text {
+		return fmt.Errorf("%s: output does not look like this mode", mode)
+	}
+	return nil
+}
+
+// ParseCaptureMode maps a mode's short name ("normal", "rng1white",
+// "raw_bin", ...; see ModeDescriptor.ShortName) to the mode, ignoring case.
+func ParseCaptureMode(s string) (CaptureMode, error) {
+	names := make([]string, len(modeTable))
+	for i, d := range modeTable {
+		if strings.EqualFold(s, d.ShortName) {
+			return d.Mode, nil
+		}
+		names[i] = d.ShortName
+	}
+	return "", fmt.Errorf("unknown capture mode %q (allowed: %s)", s, strings.Join(names, ", "))
+}
+
+// ShortName returns the mode's short name as accepted by ParseCaptureMode, or
+// "" for an unknown mode.
+func (m CaptureMode) ShortName() string {
+	d, _ := lookupMode(m)
+	return d.ShortName
+}
+
+// SupportedBy reports whether model documents mode m, i.e. whether m is in
+// SupportedModes(model).
+func (m CaptureMode) SupportedBy(model DeviceModel) bool {
+	d, ok := lookupMode(m)
+	return ok && slices.Contains(d.SupportedModels, model)
+}
+
+// IsASCII reports whether the mode emits ASCII text rather than binary data.
+func (m CaptureMode) IsASCII() bool {
+	d, _ := lookupMode(m)
+	return d.IsASCII
+}
+
+// isASCIIText reports whether data consists only of printable ASCII and
+// line/field separators.
+func isASCIIText(data []byte) bool {
+	for _, b := range data {
+		if (b < 0x20 || b > 0x7e) && b != '\n' && b != '\r' && b != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// deviceOnPort returns the detected TrueRNG on port.
+func deviceOnPort(port string) (*DeviceInfo, error) {
+	devices, err := EnumerateDevices()
+	if err != nil {
+		return nil, err
+	}
+	for i := range devices {
+		if devices[i].Port == port {
+			return &devices[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no TrueRNG device on %s", port)
+}
+
+func reachableKey(d DeviceInfo) string {
+	if d.SerialNumber != "" {
+		return "serial:" + d.SerialNumber
+	}
+	return d.Port
+}
+
+// CompareWhitening reads byteCount bytes in ModeUnwhitened and then in
+// ModeNormal from the first TrueRNG, knocking into each mode in turn, and
+// returns the Shannon entropy (bits per byte) of both captures. A working
+// whitener should yield whitenedEntropy above rawEntropy. Each knock is
+// followed by the wait for re-enumeration ReadBytesWithModeSwitch does. The
+// device is left in ModeNormal. Only the TrueRNGproV2 offers the unwhitened mode.
+func CompareWhitening(byteCount int) (rawEntropy, whitenedEntropy float64, err error) {
+	if byteCount <= 0 {
+		return 0, 0, errors.New("byteCount must be positive")
+	}
+	device, err := FindDevice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if device.Model != DeviceModelTrueRNGproV2 {
+		return 0, 0, fmt.Errorf("%s does not support %s; a TrueRNGproV2 is required", device.Model, ModeUnwhitened)
+	}
+
+	entropyIn := func(mode CaptureMode) (float64, error) {
+		port, err := switchAndAwait(*device, mode)
+		if err != nil {
+			return 0, err
+		}
+		device.Port = port
+		data, err := readBytesFromPort(port, mode, byteCount)
+		if err != nil {
+			return 0, fmt.Errorf("read %s: %w", mode, err)
+		}
+		var est EntropyEstimator
+		est.Add(data)
+		return est.Entropy(), nil
+	}
+
+	if rawEntropy, err = entropyIn(ModeUnwhitened); err != nil {
+		_, _ = switchAndAwait(*device, ModeNormal)
+		return 0, 0, err
+	}
+	if whitenedEntropy, err = entropyIn(ModeNormal); err != nil {
+		return 0, 0, err
+	}
+	return rawEntropy, whitenedEntropy, nil
+}
+
+// ChannelQuality reads byteCount bytes of ModeUnwhitened output from the
+// first TrueRNG, treats it as the two noise sources interleaved byte by byte
+// (RNG1 first), and returns the Shannon entropy (bits per byte) of each
+// channel, so a degraded source stands out against the other. The device is
+// knocked into ModeUnwhitened and back to ModeNormal, waiting for it to be
+// enumerated again after each knock as ReadBytesWithModeSwitch does. Only the TrueRNGproV2
+// offers the unwhitened mode.
+func ChannelQuality(byteCount int) (rng1Entropy, rng2Entropy float64, err error) {
+	if byteCount < 2 {
+		return 0, 0, errors.New("byteCount must be at least 2")
+	}
+	device, err := FindDevice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if device.Model != DeviceModelTrueRNGproV2 {
+		return 0, 0, fmt.Errorf("%s does not support %s; a TrueRNGproV2 is required", device.Model, ModeUnwhitened)
+	}
+	port, err := switchAndAwait(*device, ModeUnwhitened)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		device.Port = port
+		_, _ = switchAndAwait(*device, ModeNormal)
+	}()
+
+	data, err := readBytesFromPort(port, ModeUnwhitened, byteCount)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read %s: %w", ModeUnwhitened, err)
+	}
+	rng1, rng2 := SplitChannels(data)
+	var e1, e2 EntropyEstimator
+	e1.Add(rng1)
+	e2.Add(rng2)
+	return e1.Entropy(), e2.Entropy(), nil
+}
+
+// SplitChannels de-interleaves data into its even-indexed (RNG1) and
+// odd-indexed (RNG2) bytes.
+func SplitChannels(data []byte) (rng1, rng2 []byte) {
+	rng1 = make([]byte, 0, (len(data)+1)/2)
+	rng2 = make([]byte, 0, len(data)/2)
+	for i, b := range data {
+		if i%2 == 0 {
+			rng1 = append(rng1, b)
+		} else {
+			rng2 = append(rng2, b)
+		}
+	}
+	return rng1, rng2
+}
+
+// ReadBytesWithModeSwitch knocks the first TrueRNG into mode and reads
+// blockSize bytes in it. The plain read functions never switch modes, so
+// this is the way to actually get output such as ModeRawBin or
+// ModeUnwhitened.
+//
+// The knock can make the device drop off the USB bus and re-enumerate,
+// possibly under a different port name, and anything else holding the port
+// loses it. After the knock the device is therefore looked up again (by
+// serial number when it has one) until it reappears, for up to 5 seconds
+// beyond the 1.5s settle time. The device stays in mode afterwards; switch
+// back with another call in ModeNormal when done. Modes the model does not
+// support fail with ErrUnsupported without touching the device.
+func ReadBytesWithModeSwitch(blockSize int, mode CaptureMode) ([]byte, error) {
+	if blockSize <= 0 {
+		return nil, errors.New("blockSize must be positive")
+	}
+	device, err := FindDevice()
+	if err != nil {
+		return nil, err
+	}
+	if !mode.SupportedBy(device.Model) {
+		return nil, fmt.Errorf("%s does not support %s: %w", device.Model, mode, ErrUnsupported)
+	}
+	port, err := switchAndAwait(*device, mode)
+	if err != nil {
+		return nil, err
+	}
+	return readBytesFromPort(port, mode, blockSize)
+}
+
+// switchAndAwait knocks device into mode, lets it settle and waits for it to
+// be enumerated again, returning the port it is on now.
+func switchAndAwait(device DeviceInfo, mode CaptureMode) (string, error) {
+	if err := switchMode(device.Port, mode); err != nil {
+		return "", fmt.Errorf("switch to %s: %w", mode, err)
+	}
+	time.Sleep(switchSettle)
+	return awaitDevice(device)
+}
+
+// awaitDevice waits for device to be enumerated again after a mode switch and
+// returns its current port. Devices without a serial number are matched as
+// the first TrueRNG found.
+func awaitDevice(device DeviceInfo) (string, error) {
+	deadline := time.Now().Add(reenumerateTimeout)
+	for {
+		var found *DeviceInfo
+		var err error
+		if device.SerialNumber != "" {
+			found, err = FindDeviceBySerial(device.SerialNumber)
+		} else {
+			found, err = FindDevice()
+		}
+		if err == nil {
+			return found.Port, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("device did not reappear within %s of the mode switch: %w", reenumerateTimeout, err)
+		}
+		time.Sleep(reenumeratePoll)
+	}
+}
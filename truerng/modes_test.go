@@ -0,0 +1,368 @@
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"go.bug.st/serial/enumerator"
+)
+
+// readerFunc adapts a function to io.Reader.
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+// proPort returns the enumerator entry of a TrueRNGpro on name.
+func proPort(name, serialNumber string) *enumerator.PortDetails {
+	return &enumerator.PortDetails{
+		Name:         name,
+		IsUSB:        true,
+		VID:          "16D0",
+		PID:          "0AA0",
+		SerialNumber: serialNumber,
+		Product:      "TrueRNGpro",
+	}
+}
+
+// fakeKnock replaces switchMode for the rest of the test. The device lands
+// in the requested mode unless stuck says otherwise; knocks into refused
+// modes fail outright. Every knock is counted.
+func fakeKnock(t *testing.T, current *CaptureMode, refused, stuck []CaptureMode) *int {
+	t.Helper()
+	knocks := 0
+	old := switchMode
+	switchMode = func(_ string, mode CaptureMode) error {
+		knocks++
+		if slices.Contains(refused, mode) {
+			return errors.New("knock failed")
+		}
+		if !slices.Contains(stuck, mode) {
+			*current = mode
+		}
+		return nil
+	}
+	t.Cleanup(func() {
+		switchMode = old
+		ForgetReachableModes("")
+	})
+	return &knocks
+}
+
+func TestReachableModes(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, proPort(port, "PRO1"))
+
+	// The fake device answers in the shape of whatever mode it is in.
+	current := ModeNormal
+	noise := randomBytes(8, modeVerifySize)
+	fakeSerial(t, map[string]*fakePort{port: {src: readerFunc(func(p []byte) (int, error) {
+		if current.IsASCII() {
+			return copy(p, "1234 5678\n1234 5678\n1234 5678\n1234 5678\n1234 5678\n1234 5678\n1234 5678\n"), nil
+		}
+		return copy(p, noise), nil
+	})}})
+	knocks := fakeKnock(t, &current, []CaptureMode{ModeRawBin}, []CaptureMode{ModeRawASC})
+
+	got, err := ReachableModes(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CaptureMode{ModeNormal, ModePSDebug, ModeRNGDebug, ModeRNG1White, ModeRNG2White}
+	if !slices.Equal(got, want) {
+		t.Errorf("ReachableModes = %v, want %v", got, want)
+	}
+	if current != ModeNormal {
+		t.Errorf("device left in %s, want it knocked back to normal", current)
+	}
+
+	before := *knocks
+	again, err := ReachableModes(port)
+	if err != nil || !slices.Equal(again, want) {
+		t.Errorf("cached ReachableModes = %v, %v; want %v", again, err, want)
+	}
+	if *knocks != before {
+		t.Error("second call probed the device again instead of using the cache")
+	}
+}
+
+// v2Port returns the enumerator entry of a TrueRNGproV2 on name.
+func v2Port(name, serialNumber string) *enumerator.PortDetails {
+	return &enumerator.PortDetails{
+		Name:         name,
+		IsUSB:        true,
+		VID:          "04D8",
+		PID:          "EBB5",
+		SerialNumber: serialNumber,
+		Product:      "TrueRNGpro V2",
+	}
+}
+
+// moveOnKnock makes every knock move the TrueRNGproV2 "V2A" between the two
+// ports, as a device that drops off the bus and comes back under another
+// name does, and checks that each knock goes to the port it is on. It wraps
+// the switchMode fake fakeKnock installed; *where is the current port.
+func moveOnKnock(t *testing.T, ports [2]string, where *string) {
+	t.Helper()
+	knock := switchMode
+	switchMode = func(port string, mode CaptureMode) error {
+		if port != *where {
+			t.Errorf("knocked %s while the device is on %s", port, *where)
+		}
+		if *where == ports[0] {
+			*where = ports[1]
+		} else {
+			*where = ports[0]
+		}
+		return knock(port, mode)
+	}
+	old, oldSettle := listPorts, switchSettle
+	listPorts = func() ([]*enumerator.PortDetails, error) {
+		return []*enumerator.PortDetails{v2Port(*where, "V2A")}, nil
+	}
+	switchSettle = 0
+	t.Cleanup(func() { listPorts, switchSettle = old, oldSettle })
+}
+
+// onlyOn wraps read so that it fails unless the device is on port.
+func onlyOn(port string, where *string, read readerFunc) readerFunc {
+	return func(p []byte) (int, error) {
+		if *where != port {
+			return 0, fmt.Errorf("read from %s, which the device has left", port)
+		}
+		return read(p)
+	}
+}
+
+func TestCompareWhitening(t *testing.T) {
+	ports := [2]string{"/dev/ttyFAKE0", "/dev/ttyFAKE1"}
+	where := ports[0]
+
+	// Scripted samples: unwhitened output only uses 16 byte values (4 bits
+	// of entropy per byte), whitened output is uniform.
+	raw := randomBytes(15, 4096)
+	for i := range raw {
+		raw[i] &= 0x0F
+	}
+	whitened := randomBytes(16, 4096)
+	current := ModeNormal
+	read := readerFunc(func(p []byte) (int, error) {
+		if current == ModeUnwhitened {
+			return copy(p, raw), nil
+		}
+		return copy(p, whitened), nil
+	})
+	fakeSerial(t, map[string]*fakePort{
+		ports[0]: {src: onlyOn(ports[0], &where, read)},
+		ports[1]: {src: onlyOn(ports[1], &where, read)},
+	})
+	fakeKnock(t, &current, nil, nil)
+	moveOnKnock(t, ports, &where)
+
+	rawH, whiteH, err := CompareWhitening(len(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rawH < 3.9 || rawH > 4 {
+		t.Errorf("raw entropy %.3f, want about 4", rawH)
+	}
+	if whiteH < 7.9 {
+		t.Errorf("whitened entropy %.3f, want about 7.95", whiteH)
+	}
+	if current != ModeNormal {
+		t.Errorf("device left in %s, want normal", current)
+	}
+}
+
+func TestCompareWhiteningNeedsV2(t *testing.T) {
+	fakePorts(t, proPort("/dev/ttyFAKE0", "PRO1"))
+	if _, _, err := CompareWhitening(64); err == nil {
+		t.Error("CompareWhitening accepted a TrueRNGpro")
+	}
+}
+
+func TestChannelQuality(t *testing.T) {
+	ports := [2]string{"/dev/ttyFAKE0", "/dev/ttyFAKE1"}
+	where := ports[0]
+
+	// RNG1 is healthy; RNG2 has degraded to 16 byte values.
+	interleaved := randomBytes(17, 16384)
+	for i := 1; i < len(interleaved); i += 2 {
+		interleaved[i] &= 0x0F
+	}
+	current := ModeNormal
+	read := readerFunc(func(p []byte) (int, error) {
+		if current != ModeUnwhitened {
+			return 0, errors.New("read outside ModeUnwhitened")
+		}
+		return copy(p, interleaved), nil
+	})
+	fakeSerial(t, map[string]*fakePort{
+		ports[0]: {src: onlyOn(ports[0], &where, read)},
+		ports[1]: {src: onlyOn(ports[1], &where, read)},
+	})
+	fakeKnock(t, &current, nil, nil)
+	moveOnKnock(t, ports, &where)
+
+	rng1, rng2, err := ChannelQuality(len(interleaved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rng1 < 7.9 {
+		t.Errorf("RNG1 entropy %.3f, want about 7.98", rng1)
+	}
+	if rng2 < 3.9 || rng2 > 4 {
+		t.Errorf("RNG2 entropy %.3f, want about 4", rng2)
+	}
+	if current != ModeNormal {
+		t.Errorf("device left in %s, want normal", current)
+	}
+
+	fakePorts(t, proPort(ports[0], "PRO1"))
+	if _, _, err := ChannelQuality(64); err == nil {
+		t.Error("ChannelQuality accepted a TrueRNGpro")
+	}
+}
+
+func TestModeTableCoversEveryMode(t *testing.T) {
+	defined := []CaptureMode{
+		ModeNormal, ModePSDebug, ModeRNGDebug, ModeRNG1White, ModeRNG2White,
+		ModeRawBin, ModeRawASC, ModeUnwhitened, ModeNormalASC, ModeNormalASCSlow,
+	}
+	table := ModeTable()
+	seen := map[CaptureMode]int{}
+	for i, d := range table {
+		seen[d.Mode]++
+		if d.BaudRate != d.Mode.GetBaudRate() {
+			t.Errorf("%s: table baud %d, GetBaudRate %d", d.Mode, d.BaudRate, d.Mode.GetBaudRate())
+		}
+		if m, err := ParseCaptureMode(d.ShortName); err != nil || m != d.Mode {
+			t.Errorf("%s: short name %q parses to %s, %v", d.Mode, d.ShortName, m, err)
+		}
+		if len(d.SupportedModels) == 0 {
+			t.Errorf("%s: no supported models", d.Mode)
+		}
+		if i > 0 && d.BaudRate <= table[i-1].BaudRate {
+			t.Errorf("%s: table not ordered by baud rate", d.Mode)
+		}
+	}
+	for _, m := range defined {
+		if seen[m] != 1 {
+			t.Errorf("%s appears %d times, want once", m, seen[m])
+		}
+	}
+	if len(table) != len(defined) {
+		t.Errorf("table has %d rows, want %d", len(table), len(defined))
+	}
+
+	table[0].SupportedModels[0] = DeviceModelUnknown
+	if ModeTable()[0].SupportedModels[0] == DeviceModelUnknown {
+		t.Error("ModeTable returned shared model slices")
+	}
+}
+
+func TestSupportedModes(t *testing.T) {
+	v2Only := []CaptureMode{ModeUnwhitened, ModeNormalASC, ModeNormalASCSlow}
+	for _, m := range v2Only {
+		if m.SupportedBy(DeviceModelTrueRNG) || m.SupportedBy(DeviceModelTrueRNGpro) || !m.SupportedBy(DeviceModelTrueRNGproV2) {
+			t.Errorf("%s should be TrueRNGproV2-only", m)
+		}
+	}
+	if CaptureMode("MODE_BOGUS").SupportedBy(DeviceModelTrueRNGproV2) {
+		t.Error("an unknown mode is supported")
+	}
+
+	if got := SupportedModes(DeviceModelTrueRNG); !slices.Equal(got, []CaptureMode{ModeNormal}) {
+		t.Errorf("TrueRNG modes = %v, want only normal", got)
+	}
+	pro, v2 := SupportedModes(DeviceModelTrueRNGpro), SupportedModes(DeviceModelTrueRNGproV2)
+	for _, m := range v2Only {
+		if slices.Contains(pro, m) || !slices.Contains(v2, m) {
+			t.Errorf("%s: in pro list %v, in V2 list %v", m, slices.Contains(pro, m), slices.Contains(v2, m))
+		}
+	}
+	if len(v2) != len(ModeTable()) {
+		t.Errorf("TrueRNGproV2 supports %d modes, want all %d", len(v2), len(ModeTable()))
+	}
+}
+
+func TestReadBytesWithModeSwitchChecksModel(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	old := switchSettle
+	switchSettle = 0
+	t.Cleanup(func() { switchSettle = old })
+	current := ModeNormal
+	knocks := fakeKnock(t, &current, nil, nil)
+	fakeSerial(t, map[string]*fakePort{port: randomPort(38)})
+
+	fakePorts(t, trueRNGPort(port, "A1"))
+	if _, err := ReadBytesWithModeSwitch(64, ModeUnwhitened); !errors.Is(err, ErrUnsupported) || *knocks != 0 {
+		t.Errorf("TrueRNG: err = %v after %d knocks, want ErrUnsupported and none", err, *knocks)
+	}
+
+	fakePorts(t, v2Port(port, "V2A"))
+	data, err := ReadBytesWithModeSwitch(64, ModeUnwhitened)
+	if err != nil || len(data) != 64 || current != ModeUnwhitened {
+		t.Errorf("TrueRNGproV2: %d bytes, err %v, device in %s", len(data), err, current)
+	}
+}
+
+func TestCanSwitchMode(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	old := switchSettle
+	switchSettle = 0
+	t.Cleanup(func() { switchSettle = old })
+
+	for _, tc := range []struct {
+		name      string
+		vanishes  bool
+		verifyErr error
+		want      bool
+	}{
+		{"survives", false, nil, true},
+		{"vanishes on re-open", true, nil, false},
+		{"comes back in the wrong shape", false, errors.New("output does not look like this mode"), false},
+	} {
+		present := true
+		oldList := listPorts
+		listPorts = func() ([]*enumerator.PortDetails, error) {
+			if !present {
+				return nil, nil
+			}
+			return []*enumerator.PortDetails{proPort(port, "PRO1")}, nil
+		}
+		current := ModeNormal
+		knocks := fakeKnock(t, &current, nil, nil)
+		oldSwitch, oldVerify := switchMode, verifyMode
+		switchMode = func(p string, mode CaptureMode) error {
+			if tc.vanishes {
+				present = false
+			}
+			return oldSwitch(p, mode)
+		}
+		verified := 0
+		verifyMode = func(string, CaptureMode) error {
+			verified++
+			return tc.verifyErr
+		}
+
+		ok, err := CanSwitchMode(port)
+		if err != nil || ok != tc.want {
+			t.Errorf("%s: CanSwitchMode = %v, %v; want %v, nil", tc.name, ok, err, tc.want)
+		}
+		if tc.vanishes && verified != 0 {
+			t.Errorf("%s: verified a device that was gone", tc.name)
+		}
+
+		// The verdict is cached: asking again does not knock.
+		present = true
+		before := *knocks
+		if again, err := CanSwitchMode(port); err != nil || again != tc.want || *knocks != before {
+			t.Errorf("%s: second call = %v, %v after %d more knocks; want the cached %v", tc.name, again, err, *knocks-before, tc.want)
+		}
+
+		listPorts, switchMode, verifyMode = oldList, oldSwitch, oldVerify
+		ForgetReachableModes("")
+	}
+}
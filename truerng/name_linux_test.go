@@ -0,0 +1,27 @@
+package truerng
+
+import (
+	"testing"
+
+	"go.bug.st/serial/enumerator"
+)
+
+func TestDeviceNameFallback(t *testing.T) {
+	fakeSysfs(t, "ttyFAKE0", "1-1.2", "12") // product descriptor "TrueRNGpro"
+
+	for _, tc := range []struct {
+		product, port string
+		model         DeviceModel
+		want          string
+	}{
+		{" TrueRNG V3 ", "/dev/ttyFAKE0", DeviceModelTrueRNG, "TrueRNG V3"},
+		{"", "/dev/ttyFAKE0", DeviceModelTrueRNG, "TrueRNGpro"},
+		{"", "/dev/ttyFAKE1", DeviceModelTrueRNGproV2, "TrueRNGproV2"},
+		{"", "/dev/ttyFAKE1", DeviceModelUnknown, DeviceNamePrefix + " device"},
+	} {
+		p := &enumerator.PortDetails{Name: tc.port, Product: tc.product}
+		if got := deviceName(p, tc.model); got != tc.want {
+			t.Errorf("deviceName(%q on %s, %s) = %q, want %q", tc.product, tc.port, tc.model, got, tc.want)
+		}
+	}
+}
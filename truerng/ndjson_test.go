@@ -0,0 +1,70 @@
+package truerng
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayNDJSON(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := []Batch{
+		{Time: t0, Data: []byte{0xde, 0xad, 0xbe, 0xef}, Entropy: 2, HasEntropy: true},
+		{Time: t0.Add(50 * time.Millisecond), Data: []byte{0x00, 0x01}},
+		{Time: t0.Add(100 * time.Millisecond), Data: []byte{0xff}},
+	}
+	var file bytes.Buffer
+	enc := json.NewEncoder(&file)
+	for _, b := range want {
+		if err := enc.Encode(NewBatchJSON(b, 8*len(b.Data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A base64 line, as other writers may produce, after a blank line.
+	file.WriteString("\n" + `{"time":"2024-05-01T12:00:00.15Z","bits":16,"bytes":2,"base64":"q80="}`)
+	want = append(want, Batch{Time: t0.Add(150 * time.Millisecond), Data: []byte{0xab, 0xcd}})
+
+	var got []Batch
+	start := time.Now()
+	err := ReplayNDJSON(&file, func(b Batch) error {
+		got = append(got, b)
+		return nil
+	}, WithRecordedTiming())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("timed replay took %v, want at least the recorded 150ms", elapsed)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("replayed %d batches, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Time.Equal(want[i].Time) || !bytes.Equal(got[i].Data, want[i].Data) ||
+			got[i].HasEntropy != want[i].HasEntropy || got[i].Entropy != want[i].Entropy {
+			t.Errorf("batch %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReplayNDJSONErrors(t *testing.T) {
+	stop := errors.New("stop")
+	line := `{"time":"2024-05-01T12:00:00Z","bits":8,"bytes":1,"hex":"ff"}` + "\n"
+	calls := 0
+	if err := ReplayNDJSON(strings.NewReader(line+line), func(Batch) error { calls++; return stop }); !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("callback error: err = %v after %d calls, want stop after 1", err, calls)
+	}
+
+	for _, bad := range []string{
+		line + "not json\n",
+		`{"bits":8,"bytes":2,"hex":"ff"}` + "\n",
+		`{"bits":8,"bytes":1,"hex":"zz"}` + "\n",
+	} {
+		if err := ReplayNDJSON(strings.NewReader(bad), func(Batch) error { return nil }); err == nil {
+			t.Errorf("replay of %q succeeded", bad)
+		}
+	}
+}
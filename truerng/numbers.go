@@ -0,0 +1,111 @@
+package truerng
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// ReadUint64 reads 8 bytes from the first TrueRNG and assembles them into a
+// uint64 using order (binary.LittleEndian or binary.BigEndian).
+func ReadUint64(mode CaptureMode, order binary.ByteOrder) (uint64, error) {
+	if order == nil {
+		return 0, errors.New("byte order must not be nil")
+	}
+	b, err := ReadBytesWithMode(8, mode)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint64(b), nil
+}
+
+// ReadUint64LE is ReadUint64 with little-endian byte order.
+func ReadUint64LE(mode CaptureMode) (uint64, error) {
+	return ReadUint64(mode, binary.LittleEndian)
+}
+
+// ReadUint64BE is ReadUint64 with big-endian byte order.
+func ReadUint64BE(mode CaptureMode) (uint64, error) {
+	return ReadUint64(mode, binary.BigEndian)
+}
+
+// ReadFloat64 reads 8 bytes from the first TrueRNG and returns a uniformly
+// distributed float64 in [0, 1). Only the top 53 bits are used, scaled by
+// 2^-53, so every result is exactly representable and equally likely;
+// dividing a full uint64 by 2^64 would instead round some values up to 1.0
+// and skew the low bits.
+func ReadFloat64(mode CaptureMode) (float64, error) {
+	u, err := ReadUint64BE(mode)
+	if err != nil {
+		return 0, err
+	}
+	return uint64ToFloat(u), nil
+}
+
+// Float64 returns a uniform float64 in [0, 1) from the session, as
+// ReadFloat64 does.
+func (s *Session) Float64() (float64, error) {
+	var b [8]byte
+	if err := s.fill(b[:]); err != nil {
+		return 0, err
+	}
+	return uint64ToFloat(binary.BigEndian.Uint64(b[:])), nil
+}
+
+// uint64ToFloat maps the top 53 bits of u onto [0, 1).
+func uint64ToFloat(u uint64) float64 {
+	return float64(u>>11) / (1 << 53)
+}
+
+// Source is a math/rand.Source64 drawing from a TrueRNG session, so
+// rand.New(src) yields hardware-random integers, shuffles and permutations.
+// The session buffers device reads, so each Uint64 usually costs no USB
+// round-trip. A read failure panics, since rand.Source has no way to report
+// errors. Seed is a no-op. Release the device with Close.
+type Source struct {
+	s *Session
+}
+
+var _ rand.Source64 = (*Source)(nil)
+
+// NewSource opens the first TrueRNG in mode as a Source.
+func NewSource(mode CaptureMode) (*Source, error) {
+	s, err := Open(mode)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{s: s}, nil
+}
+
+// MustSource is NewSource(ModeNormal) that panics if no device can be opened,
+// for use as rand.New(truerng.MustSource()).
+func MustSource() *Source {
+	src, err := NewSource(ModeNormal)
+	if err != nil {
+		panic(fmt.Sprintf("truerng: %v", err))
+	}
+	return src
+}
+
+// Uint64 returns 8 bytes from the device as a big-endian uint64.
+func (src *Source) Uint64() uint64 {
+	var b [8]byte
+	if err := src.s.fill(b[:]); err != nil {
+		panic(fmt.Sprintf("truerng: %v", err))
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// Int63 returns a non-negative int64 from the top 63 bits of Uint64.
+func (src *Source) Int63() int64 {
+	return int64(src.Uint64() >> 1)
+}
+
+// Seed does nothing: a hardware source cannot be seeded.
+func (src *Source) Seed(int64) {}
+
+// Close releases the device. Later calls to Uint64 and Int63 panic.
+func (src *Source) Close() error {
+	return src.s.Close()
+}
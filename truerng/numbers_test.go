@@ -0,0 +1,79 @@
+package truerng
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadUint64ByteOrder(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	known := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF}
+	fakePorts(t, trueRNGPort(port, "A1"))
+	dev := &fakePort{}
+	fakeSerial(t, map[string]*fakePort{port: dev})
+
+	for _, tc := range []struct {
+		name string
+		read func(CaptureMode) (uint64, error)
+		want uint64
+	}{
+		{"LE", ReadUint64LE, 0xEFCDAB8967452301},
+		{"BE", ReadUint64BE, 0x0123456789ABCDEF},
+	} {
+		dev.src = bytes.NewReader(known)
+		got, err := tc.read(ModeNormal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("ReadUint64%s = %#x, want %#x", tc.name, got, tc.want)
+		}
+	}
+	if _, err := ReadUint64(ModeNormal, nil); err == nil {
+		t.Error("nil byte order accepted")
+	}
+}
+
+func TestUint64ToFloat(t *testing.T) {
+	for _, u := range []uint64{0, 1 << 11, 1<<64 - 1} {
+		if f := uint64ToFloat(u); f < 0 || f >= 1 {
+			t.Errorf("uint64ToFloat(%#x) = %v, outside [0, 1)", u, f)
+		}
+	}
+	if f := uint64ToFloat(1 << 63); f != 0.5 {
+		t.Errorf("uint64ToFloat(1<<63) = %v, want 0.5", f)
+	}
+}
+
+func TestSessionFloat64Uniform(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(28)})
+	s, err := Open(ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	const draws, bins = 100000, 10
+	var counts [bins]int
+	for range draws {
+		f, err := s.Float64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f < 0 || f >= 1 {
+			t.Fatalf("Float64 = %v, outside [0, 1)", f)
+		}
+		counts[int(f*bins)]++
+	}
+	// Chi-square with 9 degrees of freedom; 27.88 is the 0.001 critical value.
+	chi2 := 0.0
+	for _, c := range counts {
+		d := float64(c) - draws/bins
+		chi2 += d * d / (draws / bins)
+	}
+	if chi2 > 27.88 {
+		t.Errorf("chi-square = %.2f over %v, want uniform", chi2, counts)
+	}
+}
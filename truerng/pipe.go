@@ -0,0 +1,102 @@
+package truerng
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// StreamTo reads continuously from the first TrueRNG and writes the bytes to
+// w as they arrive, never holding more than one device read in memory, until
+// ctx is cancelled or a read or write fails. It returns the number of bytes
+// written. Cancellation is the normal way to stop, so it returns a nil error
+// then.
+func StreamTo(ctx context.Context, w io.Writer, mode CaptureMode) (int64, error) {
+	s, err := Open(mode)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+	return s.StreamTo(ctx, w)
+}
+
+// StreamTo is the package-level StreamTo reading from this session.
+func (s *Session) StreamTo(ctx context.Context, w io.Writer) (int64, error) {
+	written, err := s.stream(ctx, w)
+	var we *writeError
+	if errors.As(err, &we) {
+		err = we.err
+	}
+	return written, err
+}
+
+// writeError marks an error from the writer stream feeds, as opposed to one
+// from reading the device, which may wrap the same os.ErrClosed or EPIPE.
+type writeError struct {
+	err error
+}
+
+func (e *writeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *writeError) Unwrap() error {
+	return e.err
+}
+
+// stream is StreamTo returning write errors as *writeError.
+func (s *Session) stream(ctx context.Context, w io.Writer) (int64, error) {
+	var written int64
+	buf := make([]byte, s.BlockSize())
+	for ctx.Err() == nil {
+		m, err := s.fillSome(buf)
+		if err != nil {
+			return written, err
+		}
+		if m == 0 {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		n, err := w.Write(buf[:m])
+		written += int64(n)
+		if err != nil {
+			return written, &writeError{err}
+		}
+	}
+	return written, nil
+}
+
+// PipeTo is StreamTo for feeding another process: a reader that goes away
+// (broken pipe or closed pipe) is the expected way for a consumer to finish,
+// so it too ends the stream with a nil error. A device that goes away is
+// still an error (ErrPortClosed).
+func PipeTo(ctx context.Context, w io.Writer, mode CaptureMode) (int64, error) {
+	s, err := Open(mode)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+	return s.PipeTo(ctx, w)
+}
+
+// PipeTo is the package-level PipeTo reading from this session.
+func (s *Session) PipeTo(ctx context.Context, w io.Writer) (int64, error) {
+	n, err := s.stream(ctx, w)
+	var we *writeError
+	if errors.As(err, &we) {
+		if isClosedPipe(we.err) {
+			return n, nil
+		}
+		return n, we.err
+	}
+	return n, err
+}
+
+// isClosedPipe reports whether err, returned by a write, means the reading
+// end of a pipe is gone.
+func isClosedPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) || errors.Is(err, io.ErrClosedPipe)
+}
@@ -0,0 +1,89 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestPipeToChildThatStopsEarly(t *testing.T) {
+	head, err := exec.LookPath("head")
+	if err != nil {
+		t.Skip("head not available")
+	}
+	const port, want = "/dev/ttyFAKE0", 10000
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(29)})
+
+	var out bytes.Buffer
+	cmd := exec.Command(head, "-c", "10000")
+	cmd.Stdout = &out
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := PipeTo(ctx, stdin, ModeNormal)
+		stdin.Close()
+		done <- err
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("child: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("PipeTo after the child closed its stdin: %v, want nil", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("PipeTo only stopped at the test timeout")
+	}
+	if out.Len() != want {
+		t.Errorf("child consumed %d bytes, want %d", out.Len(), want)
+	}
+}
+
+func TestPipeToWriteErrorOtherThanClosedPipe(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(30)})
+	w := writerFunc(func([]byte) (int, error) { return 0, io.ErrShortWrite })
+	if _, err := PipeTo(context.Background(), w, ModeNormal); err != io.ErrShortWrite {
+		t.Errorf("err = %v, want io.ErrShortWrite", err)
+	}
+}
+
+func TestPipeToReportsClosedPort(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	// Some data, then the port is closed under the read (device unplugged).
+	data := randomBytes(31, 100)
+	fakeSerial(t, map[string]*fakePort{port: {src: readerFunc(func(p []byte) (int, error) {
+		if len(data) == 0 {
+			return 0, os.ErrClosed
+		}
+		n := copy(p, data)
+		data = data[n:]
+		return n, nil
+	})}})
+
+	var out bytes.Buffer
+	n, err := PipeTo(context.Background(), &out, ModeNormal)
+	if !errors.Is(err, ErrPortClosed) {
+		t.Fatalf("err = %v, want ErrPortClosed", err)
+	}
+	if n != 100 || out.Len() != 100 {
+		t.Errorf("piped %d bytes (%d written), want 100", n, out.Len())
+	}
+}
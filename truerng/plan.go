@@ -0,0 +1,96 @@
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ReadPlan describes the read a one-shot or interval capture would perform,
+// worked out without opening the device.
+type ReadPlan struct {
+	Device    DeviceInfo
+	Mode      CaptureMode
+	BaudRate  int
+	BitCount  int
+	ByteCount int
+	// Interval is the delay between batches; zero for a one-shot read.
+	Interval  time.Duration
+	Reconnect bool
+	// PortTimeout is the serial read timeout set on the port. ReadDeadline is
+	// how long a batch (for one-shot reads: each chunk of up to 64 KiB) may
+	// take before the read fails.
+	PortTimeout  time.Duration
+	ReadDeadline time.Duration
+	// ExpectedDuration is how long ByteCount takes at the model's nominal
+	// output rate; zero when the model is unknown.
+	ExpectedDuration time.Duration
+	// Warnings lists reasons the read may fail or fall behind even though the
+	// parameters are valid.
+	Warnings []string
+}
+
+// nominalBytesPerSec is the rated output of each model.
+var nominalBytesPerSec = map[DeviceModel]int{
+	DeviceModelTrueRNG:      400_000 / 8,
+	DeviceModelTrueRNGpro:   3_200_000 / 8,
+	DeviceModelTrueRNGproV2: 3_200_000 / 8,
+}
+
+// PlanRead works out what reading bitCount bits from device in mode would
+// involve: a one-shot read when interval is zero, otherwise the collect loop
+// (with reconnect selecting CollectConfig.Reconnect). It returns an error for
+// parameters the read itself would reject; anything that is merely likely to
+// fail, such as a mode the model does not list or a batch the device cannot
+// deliver within the deadline, ends up in Warnings.
+func PlanRead(device DeviceInfo, mode CaptureMode, bitCount int, interval time.Duration, reconnect bool) (ReadPlan, error) {
+	if bitCount <= 0 {
+		return ReadPlan{}, errors.New("bitCount must be positive")
+	}
+	if interval < 0 {
+		return ReadPlan{}, errors.New("interval must not be negative")
+	}
+	if mode == "" {
+		mode = ModeNormal
+	}
+	d, ok := lookupMode(mode)
+	if !ok {
+		return ReadPlan{}, fmt.Errorf("unknown capture mode %q", mode)
+	}
+
+	p := ReadPlan{
+		Device:    device,
+		Mode:      mode,
+		BaudRate:  d.BaudRate,
+		BitCount:  bitCount,
+		ByteCount: (bitCount + 7) / 8,
+		Interval:  interval,
+		Reconnect: reconnect && interval > 0,
+	}
+	// Mirror the timeouts of streamFromPort (one-shot) and readBatch (collect
+	// loops).
+	deadlineBytes := min(p.ByteCount, readChunkSize)
+	if interval == 0 {
+		p.PortTimeout, p.ReadDeadline = time.Second, 10*time.Second
+	} else {
+		p.PortTimeout, p.ReadDeadline = 2*time.Second, batchReadTimeout
+		deadlineBytes = p.ByteCount
+	}
+
+	if device.Model != DeviceModelUnknown && !slices.Contains(d.SupportedModels, device.Model) {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("%s does not list support for %s", device.Model, mode))
+	}
+	if rate := nominalBytesPerSec[device.Model]; rate > 0 {
+		p.ExpectedDuration = time.Duration(float64(p.ByteCount) / float64(rate) * float64(time.Second))
+		if need := time.Duration(float64(deadlineBytes) / float64(rate) * float64(time.Second)); need > p.ReadDeadline {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("%d bytes take about %s at the nominal rate, over the %s read deadline",
+				deadlineBytes, need.Round(time.Millisecond), p.ReadDeadline))
+		}
+		if interval > 0 && p.ExpectedDuration > interval {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("a batch takes about %s, longer than the %s interval",
+				p.ExpectedDuration.Round(time.Millisecond), interval))
+		}
+	}
+	return p, nil
+}
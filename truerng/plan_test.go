@@ -0,0 +1,56 @@
+package truerng
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanRead(t *testing.T) {
+	v1 := DeviceInfo{Port: "/dev/ttyACM0", Model: DeviceModelTrueRNG}
+	pro := DeviceInfo{Port: "/dev/ttyACM1", Model: DeviceModelTrueRNGpro}
+
+	p, err := PlanRead(v1, "", 1000, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Mode != ModeNormal || p.BaudRate != 300 || p.ByteCount != 125 || p.Reconnect ||
+		p.PortTimeout != time.Second || p.ReadDeadline != 10*time.Second || len(p.Warnings) != 0 {
+		t.Errorf("one-shot plan = %+v", p)
+	}
+	if p.ExpectedDuration != 2500*time.Microsecond {
+		t.Errorf("ExpectedDuration = %v, want 2.5ms at 50 kB/s", p.ExpectedDuration)
+	}
+
+	p, err = PlanRead(pro, ModeRawBin, 8*4096, 2*time.Second, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BaudRate != 19200 || p.ByteCount != 4096 || !p.Reconnect ||
+		p.PortTimeout != 2*time.Second || p.ReadDeadline != batchReadTimeout || len(p.Warnings) != 0 {
+		t.Errorf("interval plan = %+v", p)
+	}
+
+	// A V1 cannot do RAW_BIN, and 1 MB per batch at 50 kB/s misses both the
+	// 5s batch deadline and a 1s interval.
+	p, err = PlanRead(v1, ModeRawBin, 8<<20, time.Second, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Warnings) != 3 {
+		t.Errorf("warnings = %q, want mode, deadline and interval warnings", p.Warnings)
+	}
+	if !strings.Contains(strings.Join(p.Warnings, "\n"), "does not list support") {
+		t.Errorf("no unsupported-mode warning in %q", p.Warnings)
+	}
+
+	for _, bad := range []struct {
+		bits     int
+		interval time.Duration
+		mode     CaptureMode
+	}{{0, 0, ModeNormal}, {8, -time.Second, ModeNormal}, {8, 0, "MODE_BOGUS"}} {
+		if _, err := PlanRead(v1, bad.mode, bad.bits, bad.interval, false); err == nil {
+			t.Errorf("PlanRead(%d bits, %v, %s) accepted", bad.bits, bad.interval, bad.mode)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// VonNeumann debiases the first bitCount bits of data (MSB-first) by reading
+// them in pairs: "10" emits a 1, "01" emits a 0, and "00"/"11" are dropped.
+// When bitCount is odd the final unpaired bit is always discarded. The result
+// is packed MSB-first; outBits reports how many of its bits are valid and any
+// unused trailing bits in the last byte are zero.
+//
+// bitCount is clamped to len(data)*8; a non-positive value yields no output.
+func VonNeumann(data []byte, bitCount int) (out []byte, outBits int) {
+	if limit := len(data) * 8; bitCount > limit {
+		bitCount = limit
+	}
+	if bitCount < 2 {
+		return nil, 0
+	}
+	pairs := bitCount / 2
+	out = make([]byte, (pairs+7)/8)
+	for i := 0; i < pairs; i++ {
+		first := bitAt(data, 2*i)
+		second := bitAt(data, 2*i+1)
+		if first == second {
+			continue
+		}
+		if first == 1 {
+			out[outBits/8] |= 0x80 >> (outBits % 8)
+		}
+		outBits++
+	}
+	return out[:(outBits+7)/8], outBits
+}
+
+// VonNeumannDebias applies VonNeumann to all of in and keeps only the whole
+// bytes of the result, so every returned bit is a debiased one. The output
+// length varies with the input: about a quarter of it for unbiased data, less
+// the more biased it is, possibly nothing.
+func VonNeumannDebias(in []byte) []byte {
+	out, outBits := VonNeumann(in, len(in)*8)
+	return out[:outBits/8]
+}
+
+// BalanceFold XORs the first half of data with the second half read
+// backwards bit by bit: output byte i is data[i] XOR the bit-reversed
+// data[len(data)-1-i]. The output is len(data)/2 bytes; with an odd length
+// the middle byte is dropped. XORing two independent halves shrinks a bias
+// (a ones ratio of 0.5+e becomes 0.5-2e²), and the reversal pairs each bit
+// with one far away in the stream, where the two are least likely to be
+// correlated. It is weaker than VonNeumannDebias, which removes bias of independent
+// bits entirely, but cheaper and with a fixed output length.
+func BalanceFold(data []byte) []byte {
+	half := len(data) / 2
+	out := make([]byte, half)
+	for i := range out {
+		out[i] = data[i] ^ bits.Reverse8(data[len(data)-1-i])
+	}
+	return out
+}
+
+// bitAt returns bit i of data counting MSB-first from the first byte.
+func bitAt(data []byte, i int) byte {
+	return (data[i/8] >> (7 - i%8)) & 1
+}
+
+// XORStreams XORs a and b byte by byte. If the lengths differ it returns an
+// error unless truncate is set, in which case the result has the length of the
+// shorter input and the excess of the longer one is ignored.
+func XORStreams(a, b []byte, truncate bool) ([]byte, error) {
+	n := len(a)
+	if len(b) != n {
+		if !truncate {
+			return nil, fmt.Errorf("length mismatch: %d vs %d bytes", len(a), len(b))
+		}
+		if len(b) < n {
+			n = len(b)
+		}
+	}
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = a[i] ^ b[i]
+	}
+	return out, nil
+}
+
+// CombineXOR XORs sources of equal length together byte by byte, e.g. the
+// outputs of ReadFromAll. If the sources are independent, the result is at
+// least as unpredictable as the best of them, so one failing device cannot
+// compromise it on its own. It fails when there are no sources or their
+// lengths differ.
+func CombineXOR(sources ...[]byte) ([]byte, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("no sources to combine")
+	}
+	out := append([]byte(nil), sources[0]...)
+	for i, src := range sources[1:] {
+		if len(src) != len(out) {
+			return nil, fmt.Errorf("length mismatch: source %d has %d bytes, source 0 has %d", i+1, len(src), len(out))
+		}
+		for j, b := range src {
+			out[j] ^= b
+		}
+	}
+	return out, nil
+}
+
+// FoldXOR splits data into groups consecutive segments of len(data)/groups
+// bytes and XORs them together, returning one segment. Trailing bytes that do
+// not fill a whole segment are ignored. It returns nil when groups is not
+// positive or data is shorter than groups.
+func FoldXOR(data []byte, groups int) []byte {
+	if groups <= 0 || len(data) < groups {
+		return nil
+	}
+	n := len(data) / groups
+	out := make([]byte, n)
+	for g := 0; g < groups; g++ {
+		seg := data[g*n : (g+1)*n]
+		for i, b := range seg {
+			out[i] ^= b
+		}
+	}
+	return out
+}
+
+// SplitRecords splits data into consecutive records of recordSize bytes. If
+// the final record is short it is zero-padded to recordSize when pad is set,
+// and dropped otherwise. The records share data's backing array except for a
+// padded tail, which is a fresh copy. It returns nil if recordSize is not
+// positive.
+func SplitRecords(data []byte, recordSize int, pad bool) [][]byte {
+	if recordSize <= 0 {
+		return nil
+	}
+	records := make([][]byte, 0, (len(data)+recordSize-1)/recordSize)
+	for len(data) >= recordSize {
+		records = append(records, data[:recordSize:recordSize])
+		data = data[recordSize:]
+	}
+	if len(data) > 0 && pad {
+		tail := make([]byte, recordSize)
+		copy(tail, data)
+		records = append(records, tail)
+	}
+	return records
+}
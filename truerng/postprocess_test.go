@@ -0,0 +1,132 @@
+package truerng
+
+import (
+	"bytes"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestVonNeumannOddBitCount(t *testing.T) {
+	// pairs 10 01 11 00 10, then an unpaired trailing 1
+	data := []byte{0b10011100, 0b10100000}
+	out, n := VonNeumann(data, 11)
+	if n != 3 || !bytes.Equal(out, []byte{0b10100000}) {
+		t.Fatalf("VonNeumann(11 bits) = %08b, %d bits; want 10100000, 3 bits", out, n)
+	}
+	// The unpaired bit never matters, whatever its value.
+	data[1] = 0b10000000
+	if out2, n2 := VonNeumann(data, 11); n2 != n || !bytes.Equal(out2, out) {
+		t.Errorf("trailing bit changed the output: %08b, %d bits", out2, n2)
+	}
+	if out, n := VonNeumann(data, 1); out != nil || n != 0 {
+		t.Errorf("VonNeumann(1 bit) = %v, %d; want nothing", out, n)
+	}
+	if _, n := VonNeumann([]byte{0x80}, 64); n != 1 {
+		t.Errorf("bitCount beyond the data: %d bits, want 1", n)
+	}
+}
+
+func TestXORStreamsLengths(t *testing.T) {
+	a := []byte{0xFF, 0x0F, 0xAA}
+	b := []byte{0x0F, 0xFF}
+	if _, err := XORStreams(a, b, false); err == nil {
+		t.Error("unequal lengths without truncate: want an error")
+	}
+	got, err := XORStreams(a, b, true)
+	if err != nil || !bytes.Equal(got, []byte{0xF0, 0xF0}) {
+		t.Errorf("truncate = %x, %v; want f0f0", got, err)
+	}
+	got, err = XORStreams(a[:2], b, false)
+	if err != nil || !bytes.Equal(got, []byte{0xF0, 0xF0}) {
+		t.Errorf("equal lengths = %x, %v; want f0f0", got, err)
+	}
+}
+
+func TestFoldXOR(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		groups int
+		want   []byte
+	}{
+		{[]byte{1, 2, 4, 8}, 2, []byte{1 ^ 4, 2 ^ 8}},
+		{[]byte{1, 2, 4, 8, 16}, 2, []byte{1 ^ 4, 2 ^ 8}}, // remainder ignored
+		{[]byte{1, 2, 4}, 3, []byte{1 ^ 2 ^ 4}},
+		{[]byte{1, 2}, 3, nil},
+		{[]byte{1, 2}, 0, nil},
+	}
+	for _, tt := range tests {
+		if got := FoldXOR(tt.data, tt.groups); !bytes.Equal(got, tt.want) {
+			t.Errorf("FoldXOR(%v, %d) = %v, want %v", tt.data, tt.groups, got, tt.want)
+		}
+	}
+}
+
+func TestBalanceFold(t *testing.T) {
+	// 0x01 reversed is 0x80, 0xF0 reversed is 0x0F; the middle byte of an
+	// odd-length input is dropped.
+	got := BalanceFold([]byte{0x12, 0x34, 0xAA, 0xF0, 0x01})
+	if want := []byte{0x12 ^ 0x80, 0x34 ^ 0x0F}; !bytes.Equal(got, want) {
+		t.Errorf("BalanceFold = %x, want %x", got, want)
+	}
+
+	// Bits set with probability 0.6 come out at about 0.5-2(0.1)² = 0.48.
+	rng := rand.New(rand.NewPCG(36, 0))
+	biased := make([]byte, 1<<15)
+	for i := range biased {
+		for bit := range 8 {
+			if rng.Float64() < 0.6 {
+				biased[i] |= 1 << bit
+			}
+		}
+	}
+	ones := func(data []byte) float64 {
+		var e EntropyEstimator
+		e.Add(data)
+		return e.OnesRatio()
+	}
+	in, out := ones(biased), ones(BalanceFold(biased))
+	if math.Abs(out-0.5) >= math.Abs(in-0.5)/2 {
+		t.Errorf("ones ratio %.4f folded to %.4f, want much closer to 0.5", in, out)
+	}
+	if math.Abs(out-0.48) > 0.01 {
+		t.Errorf("folded ones ratio %.4f, want about 0.48", out)
+	}
+}
+
+func TestSplitRecords(t *testing.T) {
+	data := []byte{1, 2, 3, 4, 5, 6, 7}
+	tests := []struct {
+		name string
+		data []byte
+		size int
+		pad  bool
+		want [][]byte
+	}{
+		{"exact multiple", data[:6], 3, false, [][]byte{{1, 2, 3}, {4, 5, 6}}},
+		{"exact multiple padded", data[:6], 3, true, [][]byte{{1, 2, 3}, {4, 5, 6}}},
+		{"short tail padded", data, 3, true, [][]byte{{1, 2, 3}, {4, 5, 6}, {7, 0, 0}}},
+		{"short tail dropped", data, 3, false, [][]byte{{1, 2, 3}, {4, 5, 6}}},
+		{"shorter than a record", data[:2], 3, false, [][]byte{}},
+		{"bad size", data, 0, true, nil},
+	}
+	for _, tt := range tests {
+		got := SplitRecords(tt.data, tt.size, tt.pad)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: %d records %v, want %v", tt.name, len(got), got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !bytes.Equal(got[i], tt.want[i]) {
+				t.Errorf("%s: record %d = %v, want %v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+
+	// Records are capped, so appending to one cannot overwrite the next.
+	recs := SplitRecords(data[:6], 3, false)
+	_ = append(recs[0], 9)
+	if recs[1][0] != 4 {
+		t.Error("appending to a record overwrote the following one")
+	}
+}
@@ -0,0 +1,80 @@
+package truerng
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// ProbeFallback enables last-resort detection for hosts where serial port
+// enumeration fails outright (e.g. minimal containers without udev). When set,
+// EnumerateDevices opens each path matching ProbeCandidates and reports any
+// that stream high-entropy data as a TrueRNG. Probing reads from every
+// candidate port, so it is opt-in.
+var ProbeFallback = false
+
+// ProbeCandidates lists the glob patterns probed when ProbeFallback is used.
+var ProbeCandidates = []string{"/dev/ttyACM*", "/dev/ttyUSB*"}
+
+const (
+	probeSampleSize = 4096
+	probeDeadline   = 3 * time.Second
+	// probeMinEntropy is the Shannon entropy (bits/byte) a 4 KiB sample must
+	// reach to be classified as a TrueRNG. Random data scores about 7.95.
+	probeMinEntropy = 7.8
+)
+
+// probeDevices opens every path matching patterns and returns those whose
+// output looks random. Paths that cannot be opened or read are skipped.
+func probeDevices(patterns []string) ([]DeviceInfo, error) {
+	var devices []DeviceInfo
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("probe pattern %q: %w", pattern, err)
+		}
+		for _, path := range paths {
+			if probePort(path) {
+				devices = append(devices, DeviceInfo{
+					Port:       path,
+					Model:      DeviceModelTrueRNG,
+					Name:       DeviceNamePrefix + " (probed)",
+					Confidence: ConfidenceLow,
+				})
+			}
+		}
+	}
+	return devices, nil
+}
+
+// probePort reports whether portName streams data that passes the entropy
+// threshold within probeDeadline.
+func probePort(portName string) bool {
+	port, err := serialOpen(portName, &serial.Mode{Parity: serial.NoParity, StopBits: serial.OneStopBit})
+	if err != nil {
+		return false
+	}
+	defer port.Close()
+	_ = port.SetDTR(true)
+	_ = port.SetReadTimeout(500 * time.Millisecond)
+	_ = port.ResetInputBuffer()
+
+	buf := make([]byte, probeSampleSize)
+	total := 0
+	deadline := time.Now().Add(probeDeadline)
+	for total < len(buf) && time.Now().Before(deadline) {
+		n, err := port.Read(buf[total:])
+		if err != nil {
+			return false
+		}
+		total += n
+	}
+	if total < len(buf) {
+		return false
+	}
+	var est EntropyEstimator
+	est.Add(buf)
+	return est.Entropy() >= probeMinEntropy
+}
@@ -0,0 +1,48 @@
+package truerng
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.bug.st/serial/enumerator"
+)
+
+func TestProbeFallbackWhenEnumerationFails(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "ttyACM0")
+	flat := filepath.Join(dir, "ttyUSB0")
+	gone := filepath.Join(dir, "ttyACM1")
+	for _, path := range []string{good, flat, gone} {
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fakeSerial(t, map[string]*fakePort{
+		good: randomPort(1),
+		flat: {chunks: [][]byte{make([]byte, probeSampleSize)}},
+	})
+
+	oldList, oldFallback, oldCandidates := listPorts, ProbeFallback, ProbeCandidates
+	t.Cleanup(func() { listPorts, ProbeFallback, ProbeCandidates = oldList, oldFallback, oldCandidates })
+	listPorts = func() ([]*enumerator.PortDetails, error) { return nil, errors.New("no udev") }
+	ProbeCandidates = []string{filepath.Join(dir, "ttyACM*"), filepath.Join(dir, "ttyUSB*")}
+
+	ProbeFallback = false
+	if _, err := EnumerateDevices(); err == nil {
+		t.Fatal("enumeration failure ignored without ProbeFallback")
+	}
+
+	ProbeFallback = true
+	devices, err := EnumerateDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("probed %d devices, want 1: %+v", len(devices), devices)
+	}
+	if d := devices[0]; d.Port != good || d.Model != DeviceModelTrueRNG || d.Confidence != ConfidenceLow {
+		t.Errorf("probed %+v, want low-confidence TrueRNG on %s", d, good)
+	}
+}
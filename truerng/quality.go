@@ -0,0 +1,307 @@
+package truerng
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// EntropyEstimator accumulates a byte histogram across batches so that the
+// entropy of a whole capture can be tracked without keeping the data around.
+// The zero value is ready to use.
+type EntropyEstimator struct {
+	counts [256]uint64
+	total  uint64
+	ones   uint64
+}
+
+// Add folds data into the running histogram.
+func (e *EntropyEstimator) Add(data []byte) {
+	for _, b := range data {
+		e.counts[b]++
+		e.ones += uint64(bits.OnesCount8(b))
+	}
+	e.total += uint64(len(data))
+}
+
+// Reset clears all accumulated state.
+func (e *EntropyEstimator) Reset() {
+	*e = EntropyEstimator{}
+}
+
+// Count returns the number of bytes seen so far.
+func (e *EntropyEstimator) Count() uint64 {
+	return e.total
+}
+
+// Entropy returns the Shannon entropy of the accumulated bytes in bits per
+// byte (0.0 to 8.0). It returns 0 when no data has been added.
+func (e *EntropyEstimator) Entropy() float64 {
+	if e.total == 0 {
+		return 0
+	}
+	n := float64(e.total)
+	h := 0.0
+	for _, c := range e.counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// MostCommonProportion returns the relative frequency of the most common byte
+// value seen so far.
+func (e *EntropyEstimator) MostCommonProportion() float64 {
+	if e.total == 0 {
+		return 0
+	}
+	var most uint64
+	for _, c := range e.counts {
+		if c > most {
+			most = c
+		}
+	}
+	return float64(most) / float64(e.total)
+}
+
+// MinEntropy returns the min-entropy of the accumulated bytes in bits per
+// byte, -log2(p_max), where p_max is MostCommonProportion. This is the
+// conservative figure used by NIST SP 800-90B and is never larger than the
+// Shannon entropy of the same data. It returns 0 when no data has been added.
+func (e *EntropyEstimator) MinEntropy() float64 {
+	p := e.MostCommonProportion()
+	if p == 0 {
+		return 0
+	}
+	return -math.Log2(p)
+}
+
+// OnesRatio returns the fraction of set bits in the accumulated bytes.
+func (e *EntropyEstimator) OnesRatio() float64 {
+	if e.total == 0 {
+		return 0
+	}
+	return float64(e.ones) / float64(e.total*8)
+}
+
+// ShannonEntropy returns the Shannon entropy of data's byte frequencies in
+// bits per byte (0.0 to 8.0). Values well below 8 on a large sample point to
+// a failing device, but small samples score low by nature: n bytes cannot
+// exceed log2(n) bits per byte, and a random 1 KiB block typically scores
+// around 7.8. Use EntropyEstimator to track a whole capture.
+func ShannonEntropy(data []byte) float64 {
+	var est EntropyEstimator
+	est.Add(data)
+	return est.Entropy()
+}
+
+// ByteHistogram counts how often each byte value occurs in data.
+func ByteHistogram(data []byte) [256]int {
+	var h [256]int
+	for _, b := range data {
+		h[b]++
+	}
+	return h
+}
+
+// OnesRatio returns the fraction of set bits in data (0.5 for unbiased input).
+func OnesRatio(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	ones := 0
+	for _, b := range data {
+		ones += bits.OnesCount8(b)
+	}
+	return float64(ones) / float64(len(data)*8)
+}
+
+// MonobitPValue runs the NIST SP 800-22 frequency (monobit) test over data and
+// returns its p-value. Values below ~0.01 indicate the ones/zeros balance is
+// unlikely for a random source.
+func MonobitPValue(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	n := len(data) * 8
+	sum := 0
+	for _, b := range data {
+		sum += 2*bits.OnesCount8(b) - 8
+	}
+	sObs := math.Abs(float64(sum)) / math.Sqrt(float64(n))
+	return math.Erfc(sObs / math.Sqrt2)
+}
+
+// Verdict is the outcome of a quality assessment.
+type Verdict int
+
+const (
+	VerdictPass Verdict = iota
+	VerdictWarn
+	VerdictFail
+)
+
+// String returns the upper-case verdict label.
+func (v Verdict) String() string {
+	switch v {
+	case VerdictPass:
+		return "PASS"
+	case VerdictWarn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// QualityThresholds sets the limits used by AssessQuality. Entropy values are
+// in bits per byte; p-values refer to the monobit test. The compression-ratio
+// limits (see CompressionRatio) are optional: zero skips the check.
+type QualityThresholds struct {
+	WarnEntropy          float64
+	FailEntropy          float64
+	WarnPValue           float64
+	FailPValue           float64
+	WarnCompressionRatio float64
+	FailCompressionRatio float64
+}
+
+// DefaultQualityThresholds returns thresholds suited to windows of a few tens
+// of KiB. Smaller windows naturally score lower entropy and need looser limits.
+func DefaultQualityThresholds() QualityThresholds {
+	return QualityThresholds{
+		WarnEntropy: 7.99,
+		FailEntropy: 7.9,
+		WarnPValue:  0.01,
+		FailPValue:  0.0001,
+	}
+}
+
+// QualityReport summarizes the quality of a block of data. CompressionRatio
+// is only computed when t sets a compression-ratio limit, and is 0 otherwise.
+type QualityReport struct {
+	Bytes            int
+	Entropy          float64
+	OnesRatio        float64
+	MonobitP         float64
+	CompressionRatio float64
+	Verdict          Verdict
+}
+
+// AssessQuality computes entropy, ones ratio and monobit p-value (plus the
+// compression ratio if t asks for it) for data and grades them against t. The
+// worst individual grade wins.
+func AssessQuality(data []byte, t QualityThresholds) QualityReport {
+	var est EntropyEstimator
+	est.Add(data)
+	r := QualityReport{
+		Bytes:     len(data),
+		Entropy:   est.Entropy(),
+		OnesRatio: est.OnesRatio(),
+		MonobitP:  MonobitPValue(data),
+	}
+	compressFail, compressWarn := false, false
+	if t.WarnCompressionRatio > 0 || t.FailCompressionRatio > 0 {
+		if ratio, err := CompressionRatio(data); err == nil {
+			r.CompressionRatio = ratio
+			compressFail = ratio < t.FailCompressionRatio
+			compressWarn = ratio < t.WarnCompressionRatio
+		}
+	}
+
+	switch {
+	case r.Entropy < t.FailEntropy || r.MonobitP < t.FailPValue || compressFail:
+		r.Verdict = VerdictFail
+	case r.Entropy < t.WarnEntropy || r.MonobitP < t.WarnPValue || compressWarn:
+		r.Verdict = VerdictWarn
+	default:
+		r.Verdict = VerdictPass
+	}
+	return r
+}
+
+// CompressionRatio gzips data and returns compressed/original length, a cheap
+// randomness proxy: random data does not compress, so its ratio sits at or a
+// little above 1.0 (gzip framing adds a few dozen bytes), while structured
+// output compresses and scores well below 1.0. Blocks of a few KiB or more
+// give a meaningful figure.
+func CompressionRatio(data []byte) (float64, error) {
+	if len(data) == 0 {
+		return 0, errors.New("data must not be empty")
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	return float64(buf.Len()) / float64(len(data)), nil
+}
+
+// ErrNoiseSourceDead reports output that is a constant 0x00 or 0xFF run: a
+// dead noise source or a dangling UART line, not entropy.
+var ErrNoiseSourceDead = errors.New("noise source dead")
+
+// stuckMinBytes is the shortest block CheckStuck judges; a shorter constant
+// run is too likely to occur by chance.
+const stuckMinBytes = 8
+
+// CheckStuck returns an error wrapping ErrNoiseSourceDead if data consists
+// entirely of 0x00 bytes (no signal) or entirely of 0xFF bytes (a
+// disconnected UART idles high). Blocks shorter than 8 bytes always pass.
+func CheckStuck(data []byte) error {
+	if len(data) < stuckMinBytes {
+		return nil
+	}
+	first := data[0]
+	if first != 0x00 && first != 0xFF {
+		return nil
+	}
+	for _, b := range data[1:] {
+		if b != first {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d bytes of 0x%02X", ErrNoiseSourceDead, len(data), first)
+}
+
+// ErrQualityUnverified reports a block that failed the quick check made by
+// ReadOptions.RequireQualityCheck; the data was discarded.
+var ErrQualityUnverified = errors.New("quality unverified")
+
+// Limits of VerifyQuality. The monobit bound is loose enough that a healthy
+// device trips it about once in 10,000 blocks; the entropy floor only
+// applies from qualityEntropyMinBytes on, since smaller blocks score low by
+// nature.
+const (
+	qualityMinMonobitP     = 1e-4
+	qualityEntropyMinBytes = 1024
+	qualityMinEntropy      = 7.0
+)
+
+// VerifyQuality is the quick fail-closed check behind
+// ReadOptions.RequireQualityCheck. It returns an error wrapping
+// ErrQualityUnverified when data is empty, when its monobit p-value is below
+// 0.0001, or when a block of at least 1 KiB has a Shannon entropy below 7
+// bits per byte. It is meant for binary modes; text-mode output always fails.
+func VerifyQuality(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: no data", ErrQualityUnverified)
+	}
+	if p := MonobitPValue(data); p < qualityMinMonobitP {
+		return fmt.Errorf("%w: monobit p-value %.2g", ErrQualityUnverified, p)
+	}
+	if len(data) >= qualityEntropyMinBytes {
+		if h := ShannonEntropy(data); h < qualityMinEntropy {
+			return fmt.Errorf("%w: entropy %.2f bits/byte", ErrQualityUnverified, h)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package truerng
+
+import (
+	"bytes"
+	"errors"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestAssessQualityVerdict(t *testing.T) {
+	const window = 64 << 10
+
+	// 240 symbols, closed under complement so the ones balance is unchanged:
+	// entropy log2(240) ~ 7.91 sits between the default fail and warn limits.
+	dropped := func(b byte) bool { return b < 0x80 && b&0x0F == 0x05 }
+	var symbols []byte
+	for v := range 256 {
+		if b := byte(v); !dropped(b) && !dropped(^b) {
+			symbols = append(symbols, b)
+		}
+	}
+	r := rand.New(rand.NewPCG(7, 7))
+	weak := make([]byte, window)
+	for i := range weak {
+		weak[i] = symbols[r.IntN(len(symbols))]
+	}
+
+	biased := randomBytes(3, window)
+	for i := range biased {
+		if i%4 == 0 {
+			biased[i] |= 0x01 // ones ratio ~0.53
+		}
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want Verdict
+	}{
+		{"random", randomBytes(1, window), VerdictPass},
+		{"reduced alphabet", weak, VerdictWarn},
+		{"biased bits", biased, VerdictFail},
+		{"constant", make([]byte, window), VerdictFail},
+	}
+	for _, tt := range tests {
+		got := AssessQuality(tt.data, DefaultQualityThresholds())
+		if got.Verdict != tt.want {
+			t.Errorf("%s: verdict %s, want %s (entropy %.4f, ones %.4f, p %.4g)",
+				tt.name, got.Verdict, tt.want, got.Entropy, got.OnesRatio, got.MonobitP)
+		}
+	}
+}
+
+func TestAssessQualityCompressionLimit(t *testing.T) {
+	data := randomBytes(2, 32<<10)
+	copy(data[16<<10:], make([]byte, 16<<10)) // half zeros compresses well
+
+	loose := DefaultQualityThresholds()
+	loose.FailEntropy, loose.WarnEntropy, loose.FailPValue, loose.WarnPValue = 0, 0, 0, 0
+	if r := AssessQuality(data, loose); r.Verdict != VerdictPass || r.CompressionRatio != 0 {
+		t.Fatalf("without a compression limit: verdict %s, ratio %v", r.Verdict, r.CompressionRatio)
+	}
+	loose.FailCompressionRatio = 0.9
+	if r := AssessQuality(data, loose); r.Verdict != VerdictFail {
+		t.Fatalf("ratio %.3f below 0.9: verdict %s, want FAIL", r.CompressionRatio, r.Verdict)
+	}
+}
+
+func TestShannonEntropy(t *testing.T) {
+	var every []byte
+	for i := range 256 {
+		every = append(every, byte(i), byte(i))
+	}
+	if h := ShannonEntropy(every); h != 8 {
+		t.Errorf("every byte value twice: entropy = %v, want 8", h)
+	}
+	if h := ShannonEntropy(bytes.Repeat([]byte{0x00, 0xff}, 100)); h != 1 {
+		t.Errorf("two values: entropy = %v, want 1", h)
+	}
+	if h := ShannonEntropy(nil); h != 0 {
+		t.Errorf("empty: entropy = %v, want 0", h)
+	}
+
+	hist := ByteHistogram([]byte{1, 2, 2, 255, 255, 255})
+	if hist[1] != 1 || hist[2] != 2 || hist[255] != 3 || hist[0] != 0 {
+		t.Errorf("histogram counts 1:%d 2:%d 255:%d 0:%d", hist[1], hist[2], hist[255], hist[0])
+	}
+}
+
+func TestCompressionRatio(t *testing.T) {
+	r, err := CompressionRatio(randomBytes(3, 16<<10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r < 0.99 || r > 1.01 {
+		t.Errorf("random input: ratio = %.4f, want about 1.0", r)
+	}
+
+	repetitive := bytes.Repeat([]byte("TrueRNG "), 2<<10)
+	if r, err := CompressionRatio(repetitive); err != nil || r > 0.05 {
+		t.Errorf("repetitive input: ratio = %.4f, %v; want well below 1", r, err)
+	}
+
+	if _, err := CompressionRatio(nil); err == nil {
+		t.Error("empty input accepted")
+	}
+}
+
+func TestMinEntropySkewed(t *testing.T) {
+	// Half the bytes are 0x00, the rest uniform: p_max is just over 1/2, so
+	// min-entropy is about 1 bit while Shannon entropy is about 5.
+	data := randomBytes(11, 1<<16)
+	for i := 0; i < len(data); i += 2 {
+		data[i] = 0
+	}
+	var e EntropyEstimator
+	e.Add(data)
+
+	p := e.MostCommonProportion()
+	if p < 0.5 || p > 0.51 {
+		t.Errorf("MostCommonProportion = %.4f, want about 0.502", p)
+	}
+	minH, h := e.MinEntropy(), e.Entropy()
+	if minH >= h {
+		t.Errorf("min-entropy %.3f not below Shannon entropy %.3f", minH, h)
+	}
+	if minH < 0.95 || minH > 1.0 {
+		t.Errorf("MinEntropy = %.3f, want about 0.99", minH)
+	}
+
+	var empty EntropyEstimator
+	if empty.MinEntropy() != 0 || empty.MostCommonProportion() != 0 {
+		t.Error("empty estimator should report 0")
+	}
+}
+
+func TestCheckStuck(t *testing.T) {
+	high := bytes.Repeat([]byte{0xFF}, 64)
+	for _, tc := range []struct {
+		name string
+		data []byte
+		dead bool
+	}{
+		{"all 0xFF", high, true},
+		{"all 0x00", make([]byte, 64), true},
+		{"normal", randomBytes(21, 64), false},
+		{"0xFF with one other byte", append(high[:63:63], 0xFE), false},
+		{"constant 0x55", bytes.Repeat([]byte{0x55}, 64), false},
+		{"short 0xFF", high[:stuckMinBytes-1], false},
+	} {
+		err := CheckStuck(tc.data)
+		if dead := errors.Is(err, ErrNoiseSourceDead); dead != tc.dead {
+			t.Errorf("%s: CheckStuck = %v, want dead %v", tc.name, err, tc.dead)
+		}
+	}
+}
+
+func TestReadRejectsAllHigh(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: {src: bytes.NewReader(bytes.Repeat([]byte{0xFF}, 256))}})
+	if _, err := ReadBytesWithOptions(256, ModeNormal, ReadOptions{RejectStuck: true}); !errors.Is(err, ErrNoiseSourceDead) {
+		t.Errorf("err = %v, want ErrNoiseSourceDead", err)
+	}
+}
+
+func TestRequireQualityCheckFailsClosed(t *testing.T) {
+	const port, block = "/dev/ttyFAKE0", 2048
+	fakePorts(t, trueRNGPort(port, "A1"))
+
+	// The fake device alternates healthy blocks with ones whose bytes only
+	// take 16 values (balanced bits, 4 bits of entropy per byte).
+	var stream []byte
+	for i := range 4 {
+		b := randomBytes(uint64(30+i), block)
+		if i%2 == 1 {
+			for j := range b {
+				b[j] = b[j]&0x0F | ^b[j]<<4&0xF0
+			}
+		}
+		stream = append(stream, b...)
+	}
+	dev := &fakePort{src: bytes.NewReader(stream)}
+	fakeSerial(t, map[string]*fakePort{port: dev})
+
+	for i := range 4 {
+		data, err := ReadBytesWithOptions(block, ModeNormal, ReadOptions{RequireQualityCheck: true})
+		if i%2 == 0 {
+			if err != nil || !bytes.Equal(data, stream[i*block:(i+1)*block]) {
+				t.Errorf("good block %d: %d bytes, err %v", i, len(data), err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrQualityUnverified) || data != nil {
+			t.Errorf("bad block %d: %d bytes, err %v; want no data and ErrQualityUnverified", i, len(data), err)
+		}
+	}
+
+	// Without the option the bad block is handed over.
+	dev.src = bytes.NewReader(stream[block:])
+	if data, err := ReadBytesWithOptions(block, ModeNormal, ReadOptions{}); err != nil || len(data) != block {
+		t.Errorf("unchecked read: %d bytes, err %v", len(data), err)
+	}
+}
@@ -0,0 +1,108 @@
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// rateDrainLimit bounds how long MeasureByteRate spends discarding output
+// that was already queued before it started.
+const rateDrainLimit = 500 * time.Millisecond
+
+// MeasureByteRate reads from the TrueRNG on port for sampleDuration and
+// returns the throughput it actually sustained, in bytes per second. Bytes
+// queued before the measurement are drained first, as they would overstate
+// the rate, and the clock starts at the first byte that arrives afterwards.
+// The device stays in whatever mode it is in. Use the result in place of the
+// nominal figure when sizing reads or deadlines; ReadPlan.ExpectedDuration is
+// based on the nominal rate.
+func MeasureByteRate(port string, sampleDuration time.Duration) (float64, error) {
+	if sampleDuration <= 0 {
+		return 0, errors.New("sampleDuration must be positive")
+	}
+	p, err := serialOpen(port, &serial.Mode{Parity: serial.NoParity, StopBits: serial.OneStopBit})
+	if err != nil {
+		return 0, fmt.Errorf("open %s: %w", port, err)
+	}
+	defer func() { _ = p.Close() }()
+	_ = p.SetDTR(true)
+	_ = p.ResetInputBuffer()
+
+	buf := make([]byte, 4096)
+	_ = p.SetReadTimeout(freshDrainPoll)
+	drainUntil := time.Now().Add(rateDrainLimit)
+	for time.Now().Before(drainUntil) {
+		n, err := p.Read(buf)
+		if err != nil {
+			return 0, readError(err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	_ = p.SetReadTimeout(100 * time.Millisecond)
+	var total int64
+	var start time.Time
+	firstDeadline := time.Now().Add(10 * time.Second)
+	for start.IsZero() || time.Since(start) < sampleDuration {
+		if start.IsZero() && time.Now().After(firstDeadline) {
+			return 0, fmt.Errorf("%w: no data from %s within 10s", ErrReadTimeout, port)
+		}
+		n, err := p.Read(buf)
+		if err != nil {
+			return 0, readError(err)
+		}
+		if n == 0 {
+			continue
+		}
+		if start.IsZero() {
+			// The first chunk only marks the start of the window.
+			start = time.Now()
+			continue
+		}
+		total += int64(n)
+	}
+	return float64(total) / time.Since(start).Seconds(), nil
+}
+
+// BenchmarkResult is the outcome of Benchmark.
+type BenchmarkResult struct {
+	Bytes       int64
+	Elapsed     time.Duration
+	BytesPerSec float64
+}
+
+// Benchmark opens the first TrueRNG, reads continuously in mode for duration
+// and reports how many bytes arrived and the resulting rate. Unlike
+// MeasureByteRate it includes the start-up of the stream, as an application
+// opening the device would see it; run it for a few seconds to compare modes
+// or check a unit against a known-good baseline. The device is not switched
+// into mode.
+func Benchmark(mode CaptureMode, duration time.Duration) (BenchmarkResult, error) {
+	if duration <= 0 {
+		return BenchmarkResult{}, errors.New("duration must be positive")
+	}
+	s, err := Open(mode)
+	if err != nil {
+		return BenchmarkResult{}, err
+	}
+	defer s.Close()
+
+	var res BenchmarkResult
+	buf := make([]byte, s.BlockSize())
+	start := time.Now()
+	for time.Since(start) < duration {
+		n, err := s.fillSome(buf)
+		if err != nil {
+			return res, err
+		}
+		res.Bytes += int64(n)
+	}
+	res.Elapsed = time.Since(start)
+	res.BytesPerSec = float64(res.Bytes) / res.Elapsed.Seconds()
+	return res, nil
+}
@@ -0,0 +1,35 @@
+package truerng
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMeasureByteRate(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	const chunk, every = 1000, 10 * time.Millisecond // 100 kB/s
+
+	// The stale buffer is drained up to the first empty read; after that
+	// the fake device paces its output.
+	fakeSerial(t, map[string]*fakePort{port: {
+		chunks: [][]byte{make([]byte, 4096), {}},
+		src: readerFunc(func(p []byte) (int, error) {
+			time.Sleep(every)
+			return min(len(p), chunk), nil
+		}),
+	}})
+
+	rate, err := MeasureByteRate(port, 300*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := float64(chunk) / every.Seconds()
+	if math.Abs(rate-want)/want > 0.2 {
+		t.Errorf("rate = %.0f B/s, want %.0f ± 20%%", rate, want)
+	}
+
+	if _, err := MeasureByteRate(port, 0); err == nil {
+		t.Error("zero sampleDuration accepted")
+	}
+}
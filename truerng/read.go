@@ -0,0 +1,117 @@
+package truerng
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option configures Read.
+type Option func(*readConfig)
+
+// readConfig holds the settings of a Read.
+type readConfig struct {
+	mode   CaptureMode
+	serial string
+	debias bool
+	opts   ReadOptions
+}
+
+// WithMode reads in mode instead of ModeNormal.
+func WithMode(mode CaptureMode) Option {
+	return func(c *readConfig) { c.mode = mode }
+}
+
+// WithSerial reads from the TrueRNG with this USB serial number instead of
+// the first one detected.
+func WithSerial(serial string) Option {
+	return func(c *readConfig) { c.serial = serial }
+}
+
+// WithReadTimeout sets ReadOptions.ReadTimeout, how long one port read waits
+// for data.
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *readConfig) { c.opts.ReadTimeout = d }
+}
+
+// WithDeadline sets ReadOptions.OverallDeadline, the time allowed for the
+// whole read.
+func WithDeadline(d time.Duration) Option {
+	return func(c *readConfig) { c.opts.OverallDeadline = d }
+}
+
+// WithDebias passes the data through VonNeumannDebias. The device is read
+// until blockSize debiased bytes have been collected.
+func WithDebias() Option {
+	return func(c *readConfig) { c.debias = true }
+}
+
+// WithReadOptions applies opts wholesale, replacing any ReadOptions set by
+// earlier options.
+func WithReadOptions(opts ReadOptions) Option {
+	return func(c *readConfig) { c.opts = opts }
+}
+
+// debiasMaxReads bounds the reads WithDebias makes to fill a block.
+const debiasMaxReads = 8
+
+// Read reads blockSize bytes from the first TrueRNG in ModeNormal, as
+// ReadBytes does, with opts applied. The ReadBytesWith* functions are
+// shorthands for particular options.
+//
+// With WithDebias each read asks for four times the bytes still missing
+// (von Neumann keeps about a quarter of unbiased input); if 8 reads do not
+// fill the block the read fails. ReadOptions.MinBytes does not apply then.
+func Read(blockSize int, opts ...Option) ([]byte, error) {
+	cfg := readConfig{mode: ModeNormal}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := validateReadOptions(blockSize, cfg.opts); err != nil {
+		return nil, err
+	}
+	portName, err := findReadPort(cfg.serial)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.debias {
+		data, err := readBytesFromPortOpts(portName, cfg.mode, blockSize, cfg.opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkReadData(data, cfg.opts); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	raw := cfg.opts
+	raw.MinBytes = 0
+	out := make([]byte, 0, blockSize)
+	for range debiasMaxReads {
+		data, err := readBytesFromPortOpts(portName, cfg.mode, 4*(blockSize-len(out)), raw)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkReadData(data, raw); err != nil {
+			return nil, err
+		}
+		out = append(out, VonNeumannDebias(data)...)
+		if len(out) >= blockSize {
+			return out[:blockSize], nil
+		}
+	}
+	return nil, fmt.Errorf("debiased only %d of %d bytes in %d reads", len(out), blockSize, debiasMaxReads)
+}
+
+// findReadPort returns the port of the device with the given serial number,
+// or of the first device when serial is empty.
+func findReadPort(serial string) (string, error) {
+	if serial == "" {
+		return FindPort()
+	}
+	device, err := FindDeviceBySerial(serial)
+	if err != nil {
+		return "", err
+	}
+	return device.Port, nil
+}
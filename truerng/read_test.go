@@ -0,0 +1,163 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+)
+
+// chunkRecorder is an io.Writer remembering the size of every write.
+type chunkRecorder struct {
+	bytes.Buffer
+	sizes []int
+}
+
+func (c *chunkRecorder) Write(p []byte) (int, error) {
+	c.sizes = append(c.sizes, len(p))
+	return c.Buffer.Write(p)
+}
+
+func TestLargeReadAssembledFromChunks(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	want := randomBytes(5, 3*readChunkSize+1234)
+	fakePorts(t, trueRNGPort(port, "A1"))
+	dev := &fakePort{}
+	fakeSerial(t, map[string]*fakePort{port: dev})
+
+	dev.src = bytes.NewReader(want)
+	got, err := ReadBytesWithMode(len(want), ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("ReadBytesWithMode did not reassemble the stream")
+	}
+
+	dev.src = bytes.NewReader(want)
+	var w chunkRecorder
+	n, err := ReadBytesTo(context.Background(), &w, len(want), ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(want)) || !bytes.Equal(w.Bytes(), want) {
+		t.Errorf("ReadBytesTo wrote %d bytes, want %d identical ones", n, len(want))
+	}
+	wantSizes := []int{readChunkSize, readChunkSize, readChunkSize, 1234}
+	if len(w.sizes) != len(wantSizes) {
+		t.Fatalf("chunk sizes %v, want %v", w.sizes, wantSizes)
+	}
+	for i := range wantSizes {
+		if w.sizes[i] != wantSizes[i] {
+			t.Fatalf("chunk sizes %v, want %v", w.sizes, wantSizes)
+		}
+	}
+}
+
+func TestReadBytesToCancelsBetweenChunks(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(6)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := writerFunc(func(p []byte) (int, error) {
+		cancel()
+		return len(p), nil
+	})
+	n, err := ReadBytesTo(ctx, w, 10*readChunkSize, ModeNormal)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if n != readChunkSize {
+		t.Errorf("wrote %d bytes before stopping, want one chunk (%d)", n, readChunkSize)
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestMinBytesAcceptsPartialRead(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	partial := randomBytes(14, 100)
+	fakePorts(t, trueRNGPort(port, "A1"))
+	dev := &fakePort{}
+	fakeSerial(t, map[string]*fakePort{port: dev})
+
+	dev.src = bytes.NewReader(partial)
+	got, err := ReadBytesWithOptions(256, ModeNormal, ReadOptions{MinBytes: 64, OverallDeadline: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, partial) {
+		t.Errorf("got %d bytes, want the %d that arrived", len(got), len(partial))
+	}
+
+	dev.src = bytes.NewReader(partial)
+	if _, err := ReadBytesWithOptions(256, ModeNormal, ReadOptions{MinBytes: 128, OverallDeadline: 50 * time.Millisecond}); !errors.Is(err, ErrReadTimeout) {
+		t.Errorf("below MinBytes: err = %v, want ErrReadTimeout", err)
+	}
+
+	dev.src = bytes.NewReader(partial)
+	if _, err := ReadBytesWithOptions(256, ModeNormal, ReadOptions{OverallDeadline: 50 * time.Millisecond}); !errors.Is(err, ErrReadTimeout) {
+		t.Errorf("default MinBytes: err = %v, want ErrReadTimeout", err)
+	}
+}
+
+func TestReadBitsHashed(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(26)})
+
+	data, digest, err := ReadBitsHashed(1001, ModeNormal, sha256.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 126 || data[125]&0x7F != 0 {
+		t.Errorf("data = %d bytes ending %#x, want 126 with the last 7 bits masked", len(data), data[len(data)-1])
+	}
+	if want := sha256.Sum256(data); !bytes.Equal(digest, want[:]) {
+		t.Errorf("digest = %x, want %x", digest, want)
+	}
+
+	// Whatever is already in h is part of the digest.
+	h := sha256.New()
+	h.Write([]byte("salt"))
+	data, digest, err = ReadBitsHashed(256, ModeNormal, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256.Sum256(append([]byte("salt"), data...)); !bytes.Equal(digest, want[:]) {
+		t.Errorf("salted digest = %x, want %x", digest, want)
+	}
+
+	if _, _, err := ReadBitsHashed(8, ModeNormal, nil); err == nil {
+		t.Error("nil hash accepted")
+	}
+}
+
+func TestReadBitsFromSerial(t *testing.T) {
+	fakePorts(t,
+		trueRNGPort("/dev/ttyFAKE0", "TR0001"),
+		trueRNGPort("/dev/ttyFAKE1", "TR0002"),
+	)
+	fakeSerial(t, map[string]*fakePort{
+		"/dev/ttyFAKE0": {src: bytes.NewReader(bytes.Repeat([]byte{0x00, 0xff}, 64))},
+		"/dev/ttyFAKE1": {src: bytes.NewReader(bytes.Repeat([]byte{0xa5}, 128))},
+	})
+
+	// Serial numbers match regardless of case.
+	data, err := ReadBitsFromSerial("tr0002", 64, ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, bytes.Repeat([]byte{0xa5}, 8)) {
+		t.Errorf("read %x, want the bytes of /dev/ttyFAKE1", data)
+	}
+
+	if _, err := ReadBitsFromSerial("TR0099", 64, ModeNormal); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("absent serial: err = %v, want ErrDeviceNotFound", err)
+	}
+}
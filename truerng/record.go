@@ -0,0 +1,163 @@
+package truerng
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// recordMagic starts every session recording.
+const recordMagic = "TRNGREC1"
+
+// A session recording is recordMagic followed by one record per device read:
+//
+//	uint64 big-endian  nanoseconds since the previous record
+//	uint32 big-endian  payload length
+//	payload            the bytes the read returned
+
+// maxRecordSize and maxRecordDelay bound what a replay accepts from a record
+// header. A device read never comes near either, so a header beyond them
+// means a corrupt (or hostile) file rather than a reason to allocate
+// gigabytes or sleep for hours.
+const (
+	maxRecordSize  = 16 << 20
+	maxRecordDelay = time.Minute
+)
+
+// RecordSession reads from the first TrueRNG in mode and writes every read,
+// with its timing, to w until ctx ends. The result can be played back with
+// NewReplaySessionFromFile. Cancellation is the normal way to stop, so it
+// returns nil then; read and write errors are returned.
+func RecordSession(ctx context.Context, mode CaptureMode, w io.Writer) error {
+	s, err := Open(mode)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(recordMagic); err != nil {
+		return fmt.Errorf("write recording: %w", err)
+	}
+	last := time.Now()
+	var hdr [12]byte
+	buf := make([]byte, s.BlockSize())
+	for ctx.Err() == nil {
+		n, err := s.fillSome(buf)
+		if err != nil {
+			_ = bw.Flush()
+			return err
+		}
+		if n == 0 {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		data := buf[:n]
+		now := time.Now()
+		binary.BigEndian.PutUint64(hdr[0:], uint64(now.Sub(last)))
+		binary.BigEndian.PutUint32(hdr[8:], uint32(len(data)))
+		last = now
+		if _, err := bw.Write(hdr[:]); err != nil {
+			return fmt.Errorf("write recording: %w", err)
+		}
+		if _, err := bw.Write(data); err != nil {
+			return fmt.Errorf("write recording: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("write recording: %w", err)
+	}
+	return nil
+}
+
+// ReplayOption configures NewReplaySessionFromFile and ReplayNDJSON.
+type ReplayOption func(*replayConfig)
+
+// replayConfig holds the settings of a replay.
+type replayConfig struct {
+	timed bool
+}
+
+// WithRecordedTiming makes the replay wait before each record as long as the
+// device took to produce it, reproducing the recorded pacing.
+func WithRecordedTiming() ReplayOption {
+	return func(c *replayConfig) { c.timed = true }
+}
+
+// replaySession plays back a RecordSession recording as a byte stream.
+type replaySession struct {
+	replayConfig
+	f       *os.File
+	r       *bufio.Reader
+	pending []byte
+}
+
+// NewReplaySessionFromFile opens a recording made by RecordSession. Reading
+// from it yields the recorded device bytes in order, then io.EOF.
+func NewReplaySessionFromFile(path string, opts ...ReplayOption) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open recording: %w", err)
+	}
+	r := &replaySession{f: f, r: bufio.NewReader(f)}
+	for _, opt := range opts {
+		opt(&r.replayConfig)
+	}
+	magic := make([]byte, len(recordMagic))
+	if _, err := io.ReadFull(r.r, magic); err != nil || string(magic) != recordMagic {
+		f.Close()
+		return nil, fmt.Errorf("%s is not a session recording", path)
+	}
+	return r, nil
+}
+
+// Read implements io.Reader.
+func (r *replaySession) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// next loads the following record into pending.
+func (r *replaySession) next() error {
+	var hdr [12]byte
+	if _, err := io.ReadFull(r.r, hdr[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("truncated recording: %w", err)
+		}
+		return err
+	}
+	rawDelay := binary.BigEndian.Uint64(hdr[0:])
+	size := binary.BigEndian.Uint32(hdr[8:])
+	if size > maxRecordSize {
+		return fmt.Errorf("corrupt recording: record of %d bytes exceeds %d", size, maxRecordSize)
+	}
+	if rawDelay > uint64(maxRecordDelay) {
+		return fmt.Errorf("corrupt recording: record delay %d ns exceeds %s", rawDelay, maxRecordDelay)
+	}
+	delay := time.Duration(rawDelay)
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return fmt.Errorf("truncated recording: %w", err)
+	}
+	if r.timed && delay > 0 {
+		time.Sleep(delay)
+	}
+	r.pending = data
+	return nil
+}
+
+// Close closes the recording file.
+func (r *replaySession) Close() error {
+	return r.f.Close()
+}
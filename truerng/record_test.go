@@ -0,0 +1,105 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	const port, pause = "/dev/ttyFAKE0", 50 * time.Millisecond
+	fakePorts(t, trueRNGPort(port, "A1"))
+	a, b, c := randomBytes(25, 100), randomBytes(26, 300), randomBytes(27, 50)
+
+	// The device delivers a and b at once, c after a pause, then the
+	// recording is stopped.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	last := false
+	fakeSerial(t, map[string]*fakePort{port: {
+		chunks: [][]byte{a, {}, b},
+		src: readerFunc(func(p []byte) (int, error) {
+			if last {
+				cancel()
+				return 0, nil
+			}
+			last = true
+			time.Sleep(pause)
+			return copy(p, c), nil
+		}),
+	}})
+
+	path := filepath.Join(t.TempDir(), "session.rec")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordSession(ctx, ModeNormal, f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(append(append([]byte(nil), a...), b...), c...)
+	for _, timed := range []bool{false, true} {
+		var opts []ReplayOption
+		if timed {
+			opts = append(opts, WithRecordedTiming())
+		}
+		r, err := NewReplaySessionFromFile(path, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		got, err := io.ReadAll(r)
+		elapsed := time.Since(start)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("timed %v: replayed %d bytes, want the %d recorded", timed, len(got), len(want))
+		}
+		if timed && elapsed < pause {
+			t.Errorf("timed replay took %v, want at least the recorded %v pause", elapsed, pause)
+		}
+	}
+}
+
+func TestReplayRejectsForeignFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junk.bin")
+	if err := os.WriteFile(path, []byte("not a recording"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewReplaySessionFromFile(path); err == nil {
+		t.Error("opened a file without the recording magic")
+	}
+}
+
+func TestReplayRejectsCorruptHeader(t *testing.T) {
+	for name, hdr := range map[string][]byte{
+		"size":  {0, 0, 0, 0, 0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff},
+		"delay": {0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 1},
+	} {
+		path := filepath.Join(t.TempDir(), "corrupt.rec")
+		file := append([]byte(recordMagic), hdr...)
+		if err := os.WriteFile(path, append(file, 0xAB), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		r, err := NewReplaySessionFromFile(path, WithRecordedTiming())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.Read(make([]byte, 16))
+		r.Close()
+		if err == nil || !strings.Contains(err.Error(), "corrupt recording") {
+			t.Errorf("%s out of range: err = %v, want a corrupt recording error", name, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package truerng
+
+import "sync"
+
+// RingTap keeps the most recent bytes seen by a collect loop in a fixed-size
+// circular buffer, so that after a read error the data the device emitted just
+// before failing can be inspected. It is safe for concurrent use.
+type RingTap struct {
+	mu   sync.Mutex
+	buf  []byte
+	next int  // index the next byte is written to
+	full bool // buf has wrapped at least once
+}
+
+// NewRingTap returns a RingTap holding the last size bytes. A size of zero or
+// less yields a tap that keeps nothing.
+func NewRingTap(size int) *RingTap {
+	return &RingTap{buf: make([]byte, max(size, 0))}
+}
+
+// Write records p. It never fails and implements io.Writer.
+func (r *RingTap) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(p)
+	size := len(r.buf)
+	if size == 0 {
+		return n, nil
+	}
+	if len(p) >= size {
+		copy(r.buf, p[len(p)-size:])
+		r.next = 0
+		r.full = true
+		return n, nil
+	}
+	c := copy(r.buf[r.next:], p)
+	if c < len(p) {
+		copy(r.buf, p[c:])
+		r.full = true
+	}
+	r.next = (r.next + len(p)) % size
+	if r.next == 0 {
+		r.full = true
+	}
+	return n, nil
+}
+
+// LastBytes returns a copy of the retained bytes, oldest first. It holds fewer
+// than size bytes until that many have been written.
+func (r *RingTap) LastBytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]byte(nil), r.buf[:r.next]...)
+	}
+	out := make([]byte, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	return append(out, r.buf[:r.next]...)
+}
@@ -0,0 +1,53 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRingTapKeepsLastBytes(t *testing.T) {
+	const size = 10
+	r := NewRingTap(size)
+	var all []byte
+	for i, n := range []int{3, 4, 5, 1, 10, 23, 7, 0, 9} {
+		p := randomBytes(uint64(i), n)
+		if _, err := r.Write(p); err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, p...)
+		want := all[max(len(all)-size, 0):]
+		if got := r.LastBytes(); !bytes.Equal(got, want) {
+			t.Fatalf("after %d bytes LastBytes = %x, want %x", len(all), got, want)
+		}
+	}
+
+	empty := NewRingTap(0)
+	empty.Write([]byte{1, 2, 3})
+	if got := empty.LastBytes(); len(got) != 0 {
+		t.Errorf("zero-size tap kept %x", got)
+	}
+}
+
+func TestCollectFeedsRingBeforeError(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	stream := randomBytes(13, 3*16)
+	// Three good batches, then the device streams zeros and the stuck check
+	// fails the loop.
+	fakeSerial(t, map[string]*fakePort{port: {src: bytes.NewReader(append(append([]byte(nil), stream...), make([]byte, 16)...))}})
+
+	ring := NewRingTap(24)
+	cfg := CollectConfig{Ring: ring, RejectStuck: true}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := CollectBitsAtIntervalWithConfig(ctx, 16*8, time.Millisecond, cfg, func([]byte) {})
+	if !errors.Is(err, ErrNoiseSourceDead) {
+		t.Fatalf("err = %v, want ErrNoiseSourceDead", err)
+	}
+	if got, want := ring.LastBytes(), stream[len(stream)-24:]; !bytes.Equal(got, want) {
+		t.Errorf("ring = %x, want the last good bytes %x", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package truerng
+
+import "runtime"
+
+// SecureBytes holds random bytes meant as key material, with a way to zero
+// them once they are no longer needed. Go may already have copied them (a
+// grown slice, an encoded form), so wiping narrows the window in which they
+// sit in memory rather than closing it; callers should still avoid making
+// copies of their own.
+type SecureBytes struct {
+	b []byte
+}
+
+// ReadSecure reads bits bits as ReadBitsWithMode does and returns them as
+// SecureBytes. Wipe them when done, usually right away with
+//
+//	sb, err := truerng.ReadSecure(256, truerng.ModeNormal)
+//	if err != nil {
+//		return err
+//	}
+//	defer sb.Wipe()
+func ReadSecure(bits int, mode CaptureMode) (*SecureBytes, error) {
+	data, err := ReadBitsWithMode(bits, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &SecureBytes{b: data}, nil
+}
+
+// Bytes returns the underlying bytes, not a copy, so Wipe clears what it
+// returned too. It returns nil after Wipe.
+func (s *SecureBytes) Bytes() []byte {
+	if s == nil {
+		return nil
+	}
+	return s.b
+}
+
+// Len returns the number of bytes held, 0 after Wipe.
+func (s *SecureBytes) Len() int {
+	return len(s.Bytes())
+}
+
+// Wipe zeroes the bytes and drops them. Calling it again, or on nil, does
+// nothing.
+func (s *SecureBytes) Wipe() {
+	if s == nil || s.b == nil {
+		return
+	}
+	clear(s.b)
+	runtime.KeepAlive(s.b)
+	s.b = nil
+}
@@ -0,0 +1,39 @@
+package truerng
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecureBytesWipe(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: {src: bytes.NewReader(bytes.Repeat([]byte{0xa5}, 64))}})
+
+	sb, err := ReadSecure(256, ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.Len() != 32 {
+		t.Fatalf("Len() = %d, want 32", sb.Len())
+	}
+	held := sb.Bytes()
+	if !bytes.Equal(held, bytes.Repeat([]byte{0xa5}, 32)) {
+		t.Fatalf("Bytes() = %x, want the bytes read", held)
+	}
+
+	sb.Wipe()
+	if !bytes.Equal(held, make([]byte, 32)) {
+		t.Errorf("after Wipe the buffer holds %x, want zeros", held)
+	}
+	if sb.Bytes() != nil || sb.Len() != 0 {
+		t.Errorf("after Wipe: Bytes() = %x, Len() = %d; want nil, 0", sb.Bytes(), sb.Len())
+	}
+	sb.Wipe() // again: no-op
+
+	var none *SecureBytes
+	none.Wipe()
+	if none.Bytes() != nil || none.Len() != 0 {
+		t.Error("nil SecureBytes is not empty")
+	}
+}
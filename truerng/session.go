@@ -0,0 +1,334 @@
+package truerng
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// Session keeps a TrueRNG serial port open across many reads, avoiding the
+// reopen (and LED flicker) of the one-shot helpers. Bytes are pulled from the
+// device in blocks of BlockSize and handed out from an internal buffer, so
+// small reads do not cost a USB round-trip each. A Session is safe for
+// concurrent use.
+type Session struct {
+	mu     sync.Mutex
+	port   serial.Port
+	device DeviceInfo
+	mode   CaptureMode
+
+	buf     []byte // read buffer, BlockSize long
+	pending []byte // unread bytes within buf
+}
+
+// Open starts a session with the first detected TrueRNG.
+func Open(mode CaptureMode) (*Session, error) {
+	device, err := FindDevice()
+	if err != nil {
+		return nil, err
+	}
+	return OpenDevice(*device, mode)
+}
+
+// OpenDevice starts a session with device. The internal buffer is sized to
+// the model's RecommendedBlockSize.
+func OpenDevice(device DeviceInfo, mode CaptureMode) (*Session, error) {
+	return openDevice(device, mode, device.Model.RecommendedBlockSize())
+}
+
+// openDevice starts a session with device and a bufSize-byte read buffer.
+func openDevice(device DeviceInfo, mode CaptureMode, bufSize int) (*Session, error) {
+	if mode == "" {
+		mode = ModeNormal
+	}
+	port, err := openSessionPort(device.Port)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		port:   port,
+		device: device,
+		mode:   mode,
+		buf:    make([]byte, bufSize),
+	}, nil
+}
+
+// OpenBuffered is Open with a read buffer of bufSize bytes instead of the
+// model's RecommendedBlockSize. A larger buffer means fewer USB round-trips
+// for workloads made of many small reads; a smaller one keeps less device
+// output sitting unused in memory.
+func OpenBuffered(mode CaptureMode, bufSize int) (*Session, error) {
+	if bufSize <= 0 {
+		return nil, errors.New("bufSize must be positive")
+	}
+	device, err := FindDevice()
+	if err != nil {
+		return nil, err
+	}
+	return openDevice(*device, mode, bufSize)
+}
+
+// openSessionPort opens and prepares portName for a session.
+func openSessionPort(portName string) (serial.Port, error) {
+	port, err := serialOpen(portName, &serial.Mode{Parity: serial.NoParity, StopBits: serial.OneStopBit})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", portName, err)
+	}
+	_ = port.SetDTR(true)
+	_ = port.SetReadTimeout(1000 * time.Millisecond)
+	_ = port.ResetInputBuffer()
+	return port, nil
+}
+
+// errSessionClosed is returned by reads on a closed session.
+var errSessionClosed = errors.New("session is closed")
+
+// sessionReconnects is how many times ReadWithReconnect reconnects before
+// giving up.
+const sessionReconnects = 3
+
+// Device returns the device the session is reading from. After
+// ReadWithReconnect has reconnected, its port may have changed.
+func (s *Session) Device() DeviceInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.device
+}
+
+// Mode returns the capture mode the session was opened with.
+func (s *Session) Mode() CaptureMode {
+	return s.mode
+}
+
+// BlockSize returns the size of the internal read buffer.
+func (s *Session) BlockSize() int {
+	return len(s.buf)
+}
+
+// Read returns bitCount bits packed MSB-first, as ReadBits does. Each block
+// fetched from the device must arrive within 10 seconds.
+func (s *Session) Read(bitCount int) ([]byte, error) {
+	if bitCount <= 0 {
+		return nil, errors.New("bitCount must be positive")
+	}
+	out := make([]byte, (bitCount+7)/8)
+	if err := s.fill(out); err != nil {
+		return nil, err
+	}
+	if extraBits := (8 - (bitCount % 8)) % 8; extraBits != 0 {
+		out[len(out)-1] &= byte(0xFF << extraBits)
+	}
+	return out, nil
+}
+
+// ReadContext is Read that gives up with ctx's error once ctx ends. The
+// session is locked for one device read at a time rather than for the whole
+// call, so callers sharing a session take turns block by block instead of
+// waiting out each other's reads; every byte still goes to only one caller.
+// Cancellation is noticed between device reads, within the port's 1 second
+// read timeout.
+func (s *Session) ReadContext(ctx context.Context, bitCount int) ([]byte, error) {
+	if bitCount <= 0 {
+		return nil, errors.New("bitCount must be positive")
+	}
+	out := make([]byte, (bitCount+7)/8)
+	done := 0
+	deadline := time.Now().Add(10 * time.Second)
+	for done < len(out) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, &shortReadError{got: done, want: len(out)}
+		}
+		n, err := s.fillSome(out[done:])
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		done += n
+		deadline = time.Now().Add(10 * time.Second)
+	}
+	maskTrailingBits(out, bitCount)
+	return out, nil
+}
+
+// ReadWithReconnect is Read for long-running use, with the recovery of
+// CollectBitsAtIntervalWithReconnect: when a read fails or times out, the
+// port is closed, the device is looked up again (by serial number when it has
+// one, so a unit that re-enumerated under another port is still found) and
+// reopened, and the read is retried from scratch. It gives up after 3
+// reconnects, or when ctx ends while waiting between attempts.
+func (s *Session) ReadWithReconnect(ctx context.Context, bitCount int) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= sessionReconnects; attempt++ {
+		if attempt > 0 {
+			if err := s.reconnect(ctx); err != nil {
+				if errors.Is(err, errSessionClosed) || ctx.Err() != nil {
+					return nil, err
+				}
+				lastErr = err
+				continue
+			}
+		}
+		data, err := s.Read(bitCount)
+		if err == nil || errors.Is(err, errSessionClosed) || bitCount <= 0 {
+			return data, err
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("read failed after %d reconnects: %w", sessionReconnects, lastErr)
+}
+
+// reconnect closes the port, waits briefly for the device to settle and opens
+// it again wherever it is now enumerated.
+func (s *Session) reconnect(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.port == nil {
+		return errSessionClosed
+	}
+	_ = s.port.Close()
+	s.pending = nil
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	var found *DeviceInfo
+	var err error
+	if s.device.SerialNumber != "" {
+		found, err = FindDeviceBySerial(s.device.SerialNumber)
+	} else {
+		found, err = FindDevice()
+	}
+	if err != nil {
+		return fmt.Errorf("device not found during reconnection: %w", err)
+	}
+	port, err := openSessionPort(found.Port)
+	if err != nil {
+		return fmt.Errorf("reconnection failed: %w", err)
+	}
+	s.port = port
+	s.device = *found
+	return nil
+}
+
+// fill copies len(p) bytes into p, refilling the internal buffer as needed.
+func (s *Session) fill(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.port == nil {
+		return errSessionClosed
+	}
+	done := 0
+	deadline := time.Now().Add(10 * time.Second)
+	for done < len(p) {
+		if len(s.pending) > 0 {
+			n := copy(p[done:], s.pending)
+			s.pending = s.pending[n:]
+			done += n
+			deadline = time.Now().Add(10 * time.Second)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return &shortReadError{got: done, want: len(p)}
+		}
+		n, err := s.port.Read(s.buf)
+		if err != nil {
+			return readError(err)
+		}
+		s.pending = s.buf[:n]
+		if n == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// fillSome copies buffered bytes into p, reading once from the device first
+// if none are buffered. It may copy nothing. The copy is made under the lock,
+// so p stays the caller's even while other goroutines read the session; with
+// p of BlockSize bytes it takes all one device read delivers.
+func (s *Session) fillSome(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.port == nil {
+		return 0, errSessionClosed
+	}
+	if len(s.pending) == 0 {
+		n, err := s.port.Read(s.buf)
+		if err != nil {
+			return 0, readError(err)
+		}
+		s.pending = s.buf[:n]
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// Close releases the serial port. Further reads fail.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.port == nil {
+		return nil
+	}
+	err := s.port.Close()
+	s.port = nil
+	s.pending = nil
+	return err
+}
+
+var (
+	defaultMu      sync.Mutex
+	defaultSession *Session
+)
+
+// openDefault opens the session Default hands out. It is a variable so the
+// caching can be exercised without hardware.
+var openDefault = func() (*Session, error) {
+	return Open(ModeNormal)
+}
+
+// Default returns a package-wide session to the first detected TrueRNG in
+// ModeNormal, opening it on first use. It is meant for simple single-device
+// apps that would rather not pass a Session around; anything that picks a
+// device or mode should use Open or OpenDevice. Concurrent first callers
+// share one session. A failed open is not cached, so a later call retries.
+// Release the session with CloseDefault rather than Close.
+func Default() (*Session, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultSession != nil {
+		return defaultSession, nil
+	}
+	s, err := openDefault()
+	if err != nil {
+		return nil, err
+	}
+	defaultSession = s
+	return s, nil
+}
+
+// CloseDefault closes the session returned by Default, if one is open. The
+// next call to Default opens a fresh one.
+func CloseDefault() error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultSession == nil {
+		return nil
+	}
+	err := defaultSession.Close()
+	defaultSession = nil
+	return err
+}
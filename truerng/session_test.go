@@ -0,0 +1,208 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+)
+
+func TestRecommendedBlockSize(t *testing.T) {
+	want := map[DeviceModel]int{
+		DeviceModelTrueRNG:      4096,
+		DeviceModelTrueRNGpro:   16384,
+		DeviceModelTrueRNGproV2: 16384,
+		DeviceModelUnknown:      1024,
+	}
+	for model, size := range want {
+		if got := model.RecommendedBlockSize(); got != size {
+			t.Errorf("%s: RecommendedBlockSize = %d, want %d", model, got, size)
+		}
+	}
+
+	for _, p := range []*enumerator.PortDetails{
+		trueRNGPort("/dev/ttyFAKE0", "A1"),
+		proPort("/dev/ttyFAKE0", "PRO1"),
+		v2Port("/dev/ttyFAKE0", "V2A"),
+	} {
+		fakePorts(t, p)
+		var requested []int
+		noise := randomPort(20)
+		fakeSerial(t, map[string]*fakePort{p.Name: {src: readerFunc(func(b []byte) (int, error) {
+			requested = append(requested, len(b))
+			return noise.src.Read(b)
+		})}})
+
+		s, err := Open(ModeNormal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		model := s.Device().Model
+		if s.BlockSize() != want[model] {
+			t.Errorf("%s session: BlockSize = %d, want %d", model, s.BlockSize(), want[model])
+		}
+		if _, err := s.Read(8); err != nil {
+			t.Fatal(err)
+		}
+		if len(requested) != 1 || requested[0] != want[model] {
+			t.Errorf("%s session: device reads of %v bytes, want one of %d", model, requested, want[model])
+		}
+		s.Close()
+	}
+}
+
+func TestDefaultOpensOnce(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	fakeSerial(t, map[string]*fakePort{port: randomPort(21)})
+
+	var opens atomic.Int32
+	fail := true
+	old := openDefault
+	openDefault = func() (*Session, error) {
+		opens.Add(1)
+		if fail {
+			return nil, errors.New("no device")
+		}
+		// Widen the window in which a second open could slip in.
+		time.Sleep(10 * time.Millisecond)
+		return Open(ModeNormal)
+	}
+	t.Cleanup(func() {
+		_ = CloseDefault()
+		openDefault = old
+	})
+
+	if _, err := Default(); err == nil {
+		t.Fatal("Default succeeded with openDefault failing")
+	}
+	fail = false
+
+	const callers = 16
+	sessions := make([]*Session, callers)
+	var wg sync.WaitGroup
+	for i := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := Default()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			sessions[i] = s
+		}()
+	}
+	wg.Wait()
+	if opens.Load() != 2 {
+		t.Errorf("openDefault called %d times, want 2 (one failure, one success)", opens.Load())
+	}
+	for _, s := range sessions[1:] {
+		if s != sessions[0] {
+			t.Fatal("callers got different sessions")
+		}
+	}
+	if _, err := sessions[0].Read(64); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CloseDefault(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions[0].Read(8); err == nil {
+		t.Error("read from the closed default session succeeded")
+	}
+	s, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s == sessions[0] || opens.Load() != 3 {
+		t.Errorf("Default after CloseDefault reused the old session (opens = %d)", opens.Load())
+	}
+}
+
+func TestSessionReadContext(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	stream := randomBytes(37, 3*4096)
+	dev := &fakePort{src: bytes.NewReader(stream)}
+	fakeSerial(t, map[string]*fakePort{port: dev})
+	s, err := Open(ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Reads spanning several device blocks hand out the stream in order.
+	a, err := s.ReadContext(context.Background(), 8*5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.ReadContext(context.Background(), 8*100+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, stream[:5000]) || !bytes.Equal(b[:100], stream[5000:5100]) || b[100] != stream[5100]&0x80 {
+		t.Error("ReadContext did not return the stream in order with the tail masked")
+	}
+
+	// A device that has gone quiet: only the context ends the read. The
+	// rest of the two blocks fetched so far is still buffered.
+	dev.src = readerFunc(func([]byte) (int, error) { return 0, nil })
+	if _, err := s.ReadContext(context.Background(), 8*(2*4096-5101)); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.ReadContext(ctx, 8); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSessionStreamToOwnsItsBuffer(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	stream := randomBytes(39, 4*4096)
+	fakeSerial(t, map[string]*fakePort{port: {src: bytes.NewReader(stream)}})
+	s, err := Open(ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Another goroutine reads the session while the writer holds the bytes
+	// StreamTo gave it; they must not change under the writer, and the two
+	// readers must not be handed the same bytes.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var streamed, other []byte
+	w := writerFunc(func(p []byte) (int, error) {
+		held := bytes.Clone(p)
+		done := make(chan error)
+		go func() {
+			b, err := s.Read(8 * len(p))
+			other = append(other, b...)
+			done <- err
+		}()
+		if err := <-done; err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(p, held) {
+			t.Error("bytes handed to the writer were overwritten by a concurrent Read")
+		}
+		streamed = append(streamed, p...)
+		cancel()
+		return len(p), nil
+	})
+	if _, err := s.StreamTo(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(streamed) + len(other); !bytes.Equal(append(streamed, other...), stream[:n]) {
+		t.Error("StreamTo and Read did not split the stream between them")
+	}
+}
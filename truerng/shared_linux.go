@@ -0,0 +1,33 @@
+//go:build linux
+
+package truerng
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"go.bug.st/serial"
+)
+
+// openSharedPort opens portName like serial.Open but leaves the tty open to
+// other readers. serial.Open always sets TIOCEXCL, so a second descriptor is
+// opened first (before the flag is set) and used to clear it again with
+// TIOCNXCL once the port is configured.
+func openSharedPort(portName string, mode *serial.Mode) (serial.Port, error) {
+	side, err := os.OpenFile(portName, os.O_RDONLY|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer side.Close()
+
+	port, err := serialOpen(portName, mode)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, side.Fd(), syscall.TIOCNXCL, 0); errno != 0 {
+		_ = port.Close()
+		return nil, fmt.Errorf("TIOCNXCL %s: %w", portName, errno)
+	}
+	return port, nil
+}
@@ -0,0 +1,72 @@
+//go:build linux
+
+package truerng
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"go.bug.st/serial"
+)
+
+// openPty returns the master side of a new pseudo-terminal and the path of
+// its slave, which stands in for a CDC-ACM tty. It skips the test where no
+// pty can be allocated.
+func openPty(t *testing.T) (*os.File, string) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+	var unlock, n uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		t.Skipf("TIOCSPTLCK: %v", errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		t.Skipf("TIOCGPTN: %v", errno)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestSharedReaders(t *testing.T) {
+	master, name := openPty(t)
+	mode := &serial.Mode{BaudRate: 115200, Parity: serial.NoParity, StopBits: serial.OneStopBit}
+
+	var readers []serial.Port
+	for i := 0; i < 2; i++ {
+		p, err := openSharedPort(name, mode)
+		if err != nil {
+			t.Fatalf("shared open %d of %s: %v", i+1, name, err)
+		}
+		defer p.Close()
+		_ = p.SetReadTimeout(50 * time.Millisecond)
+		readers = append(readers, p)
+	}
+
+	// Every byte goes to exactly one of the readers.
+	want := randomBytes(22, 1024)
+	if _, err := master.Write(want); err != nil {
+		t.Fatal(err)
+	}
+	var got [2]bytes.Buffer
+	buf := make([]byte, 256)
+	deadline := time.Now().Add(2 * time.Second)
+	for got[0].Len()+got[1].Len() < len(want) && time.Now().Before(deadline) {
+		for i, p := range readers {
+			n, err := p.Read(buf)
+			if err != nil {
+				t.Fatalf("reader %d: %v", i+1, err)
+			}
+			got[i].Write(buf[:n])
+		}
+	}
+	if total := got[0].Len() + got[1].Len(); total != len(want) {
+		t.Errorf("readers got %d + %d bytes, want %d in total", got[0].Len(), got[1].Len(), len(want))
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package truerng
+
+import (
+	"fmt"
+
+	"go.bug.st/serial"
+)
+
+// openSharedPort is only implemented on Linux, where tty exclusivity is an
+// advisory flag that can be cleared.
+func openSharedPort(portName string, mode *serial.Mode) (serial.Port, error) {
+	return nil, fmt.Errorf("shared read of %s: %w", portName, ErrUnsupported)
+}
@@ -0,0 +1,445 @@
+package truerng
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink consumes collected batches, typically persisting them somewhere.
+// Sinks are fed from a single collect loop and need not be safe for
+// concurrent use.
+type Sink interface {
+	WriteBatch(batch []byte) error
+	Close() error
+}
+
+// ShardPeriod selects the wall-clock boundary at which a TimeShardedSink
+// starts a new file.
+type ShardPeriod int
+
+const (
+	ShardHourly ShardPeriod = iota
+	ShardDaily
+)
+
+// layout returns the time layout used in shard filenames for the period.
+func (p ShardPeriod) layout() string {
+	if p == ShardDaily {
+		return "2006-01-02"
+	}
+	return "2006-01-02-15"
+}
+
+// TimeShardedSink writes batches to one file per hour or day, named after the
+// period the batch was captured in, e.g. "capture-2024-01-01-13.bin". When a
+// batch arrives in a new period the previous shard is closed and the next one
+// opened. A batch is never split: it goes entirely to the shard that is active
+// at the moment WriteBatch is called.
+type TimeShardedSink struct {
+	prefix string
+	ext    string
+	period ShardPeriod
+	now    func() time.Time
+
+	file  *os.File
+	shard string
+}
+
+// NewTimeShardedSink creates a sink writing files named "<prefix>-<stamp><ext>".
+// prefix may include a directory, which is created if missing. Shards are
+// opened in append mode so restarting within a period continues the same file.
+func NewTimeShardedSink(prefix, ext string, period ShardPeriod) (*TimeShardedSink, error) {
+	if prefix == "" {
+		return nil, errors.New("prefix must not be empty")
+	}
+	if period != ShardHourly && period != ShardDaily {
+		return nil, fmt.Errorf("invalid shard period: %d", period)
+	}
+	if dir := filepath.Dir(prefix); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating shard directory: %w", err)
+		}
+	}
+	return &TimeShardedSink{prefix: prefix, ext: ext, period: period, now: time.Now}, nil
+}
+
+// ShardPath returns the file path used for batches captured at t.
+func (s *TimeShardedSink) ShardPath(t time.Time) string {
+	return s.prefix + "-" + t.Format(s.period.layout()) + s.ext
+}
+
+// WriteBatch writes batch to the shard for the current time, rotating first if
+// a period boundary has been crossed since the previous batch.
+func (s *TimeShardedSink) WriteBatch(batch []byte) error {
+	path := s.ShardPath(s.now())
+	if s.file == nil || path != s.shard {
+		if err := s.rotate(path); err != nil {
+			return err
+		}
+	}
+	if _, err := s.file.Write(batch); err != nil {
+		return fmt.Errorf("write %s: %w", s.shard, err)
+	}
+	return nil
+}
+
+// rotate closes the active shard (if any) and opens path.
+func (s *TimeShardedSink) rotate(path string) error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open shard %s: %w", path, err)
+	}
+	s.file = f
+	s.shard = path
+	return nil
+}
+
+// Close closes the active shard. The sink may be written to again afterwards,
+// in which case a shard is reopened.
+func (s *TimeShardedSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	s.shard = ""
+	return err
+}
+
+// FileSink writes batches to a single file. It supports log rotation: after
+// the file has been renamed away, Reopen closes the old handle and starts a
+// fresh file at the original path. A sink created with NewRotatingFileSink
+// also rotates by size on its own. WriteBatch and Reopen may be called from
+// different goroutines, e.g. Reopen from a SIGHUP handler.
+type FileSink struct {
+	path string
+	rot  FileRotation
+
+	mu   sync.Mutex
+	file *os.File
+	size int64 // bytes in the current file
+	next int   // index of the next rotated file
+}
+
+// FileRotation configures size-based rotation for NewRotatingFileSink.
+type FileRotation struct {
+	// MaxBytes is the size at which the file is rotated: when a batch would
+	// take it past MaxBytes, the file is renamed to "<path>.<n>" and a new
+	// one started. A batch is never split, so a single batch larger than
+	// MaxBytes still makes a file of its own.
+	MaxBytes int64
+	// MaxFiles caps how many rotated files are kept. After each rotation the
+	// rotated files beyond the newest MaxFiles (by index) are deleted. Zero
+	// keeps them all.
+	MaxFiles int
+}
+
+// NewFileSink opens path for writing, creating it if missing. With truncate
+// any existing content is discarded; otherwise batches are appended.
+func NewFileSink(path string, truncate bool) (*FileSink, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if truncate {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	s := &FileSink{path: path, file: f}
+	if err := s.statLocked(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewRotatingFileSink opens path in append mode like NewFileSink and rotates
+// it by size as configured by rot. Rotated files are numbered upwards from
+// one past the highest "<path>.<n>" already present, so a restart continues
+// the sequence and the highest index is always the newest.
+func NewRotatingFileSink(path string, rot FileRotation) (*FileSink, error) {
+	if rot.MaxBytes <= 0 {
+		return nil, errors.New("MaxBytes must be positive")
+	}
+	if rot.MaxFiles < 0 {
+		return nil, errors.New("MaxFiles must not be negative")
+	}
+	s, err := NewFileSink(path, false)
+	if err != nil {
+		return nil, err
+	}
+	s.rot = rot
+	indexes, err := s.rotatedIndexes()
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	s.next = 1
+	if len(indexes) > 0 {
+		s.next = indexes[len(indexes)-1] + 1
+	}
+	return s, nil
+}
+
+// Path returns the path the sink writes to.
+func (s *FileSink) Path() string {
+	return s.path
+}
+
+// WriteBatch appends batch to the current file, rotating first if the sink
+// rotates by size and batch would overflow it.
+func (s *FileSink) WriteBatch(batch []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("write %s: sink is closed", s.path)
+	}
+	if s.rot.MaxBytes > 0 && s.size > 0 && s.size+int64(len(batch)) > s.rot.MaxBytes {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(batch)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// rotateLocked renames the current file to the next index, starts a new one
+// and enforces MaxFiles.
+func (s *FileSink) rotateLocked() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", s.path, err)
+	}
+	s.file = nil
+	rotated := fmt.Sprintf("%s.%d", s.path, s.next)
+	if err := os.Rename(s.path, rotated); err != nil {
+		return fmt.Errorf("rotate %s: %w", s.path, err)
+	}
+	s.next++
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", s.path, err)
+	}
+	s.file = f
+	s.size = 0
+	if s.rot.MaxFiles == 0 {
+		return nil
+	}
+	indexes, err := s.rotatedIndexes()
+	if err != nil {
+		return err
+	}
+	for len(indexes) > s.rot.MaxFiles {
+		old := fmt.Sprintf("%s.%d", s.path, indexes[0])
+		if err := os.Remove(old); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove %s: %w", old, err)
+		}
+		indexes = indexes[1:]
+	}
+	return nil
+}
+
+// Split ends the current file and starts a new one, as a size rotation
+// would: the file is renamed to the next "<path>.<n>" and writing continues
+// in a fresh file at path. It is meant to be called on a reconnect (see
+// CollectConfig.OnReconnect) so each file holds one uninterrupted run. An
+// empty file is left alone, and MaxFiles applies to a rotating sink.
+func (s *FileSink) Split() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("split %s: sink is closed", s.path)
+	}
+	if s.size == 0 {
+		return nil
+	}
+	if s.next == 0 {
+		indexes, err := s.rotatedIndexes()
+		if err != nil {
+			return err
+		}
+		s.next = 1
+		if len(indexes) > 0 {
+			s.next = indexes[len(indexes)-1] + 1
+		}
+	}
+	return s.rotateLocked()
+}
+
+// rotatedIndexes returns the indexes n of the existing "<path>.<n>" files in
+// ascending order. The directory is listed rather than globbed, so path may
+// contain glob metacharacters.
+func (s *FileSink) rotatedIndexes() ([]int, error) {
+	entries, err := os.ReadDir(filepath.Dir(s.path))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(s.path) + "."
+	var indexes []int
+	for _, e := range entries {
+		suffix, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok || suffix == "" || strings.ContainsFunc(suffix, func(r rune) bool { return r < '0' || r > '9' }) {
+			continue
+		}
+		n, err := strconv.Atoi(suffix)
+		if err != nil || n <= 0 {
+			continue
+		}
+		indexes = append(indexes, n)
+	}
+	slices.Sort(indexes)
+	return indexes, nil
+}
+
+// statLocked records the size of the open file.
+func (s *FileSink) statLocked() error {
+	fi, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", s.path, err)
+	}
+	s.size = fi.Size()
+	return nil
+}
+
+// Reopen closes the current handle and opens the path again in append mode.
+// If the file was moved away (as logrotate does before sending SIGHUP) a new,
+// empty file is created; otherwise writing continues at the end of the
+// existing one.
+func (s *FileSink) Reopen() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return fmt.Errorf("close %s: %w", s.path, err)
+		}
+		s.file = nil
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("reopen %s: %w", s.path, err)
+	}
+	s.file = f
+	return s.statLocked()
+}
+
+// Close closes the file. Later writes fail until Reopen is called.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// TeeSink fans every batch out to several sinks, each of which may format the
+// data its own way (raw file, hex log, ...). A failing member does not stop
+// the batch reaching the others; the errors are joined.
+type TeeSink struct {
+	sinks []Sink
+}
+
+// NewTeeSink returns a TeeSink writing to sinks in order.
+func NewTeeSink(sinks ...Sink) *TeeSink {
+	return &TeeSink{sinks: sinks}
+}
+
+// Add appends a sink to the fan-out.
+func (t *TeeSink) Add(s Sink) {
+	t.sinks = append(t.sinks, s)
+}
+
+// Len returns the number of member sinks.
+func (t *TeeSink) Len() int {
+	return len(t.sinks)
+}
+
+// WriteBatch writes batch to every member sink.
+func (t *TeeSink) WriteBatch(batch []byte) error {
+	var errs []error
+	for _, s := range t.sinks {
+		if err := s.WriteBatch(batch); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reopen reopens every member sink that supports it (see FileSink.Reopen).
+func (t *TeeSink) Reopen() error {
+	var errs []error
+	for _, s := range t.sinks {
+		if r, ok := s.(interface{ Reopen() error }); ok {
+			if err := r.Reopen(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every member sink.
+func (t *TeeSink) Close() error {
+	var errs []error
+	for _, s := range t.sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// BatchFormatter renders a batch onto w.
+type BatchFormatter func(w io.Writer, batch []byte) error
+
+// FormatRaw writes the batch bytes unchanged.
+func FormatRaw(w io.Writer, batch []byte) error {
+	_, err := w.Write(batch)
+	return err
+}
+
+// FormatHexLine writes the batch as lower-case hex followed by a newline.
+func FormatHexLine(w io.Writer, batch []byte) error {
+	_, err := fmt.Fprintln(w, hex.EncodeToString(batch))
+	return err
+}
+
+// WriterSink formats batches onto an io.Writer such as os.Stdout. The caller
+// owns w: Close does not close it.
+type WriterSink struct {
+	w      io.Writer
+	format BatchFormatter
+}
+
+// NewWriterSink returns a sink writing each batch to w with format.
+func NewWriterSink(w io.Writer, format BatchFormatter) *WriterSink {
+	return &WriterSink{w: w, format: format}
+}
+
+// WriteBatch formats batch onto the writer.
+func (s *WriterSink) WriteBatch(batch []byte) error {
+	return s.format(s.w, batch)
+}
+
+// Close is a no-op; see WriterSink.
+func (s *WriterSink) Close() error {
+	return nil
+}
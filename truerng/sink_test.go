@@ -0,0 +1,280 @@
+package truerng
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readFile returns the content of path, failing the test if it cannot be read.
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestTimeShardedSinkCrossesHour(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "shards", "capture")
+	s, err := NewTimeShardedSink(prefix, ".bin", ShardHourly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 13, 59, 58, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	if err := s.WriteBatch([]byte("ab")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Second)
+	if err := s.WriteBatch([]byte("cd")); err != nil {
+		t.Fatal(err)
+	}
+	first := s.file
+	now = now.Add(2 * time.Second) // 14:00:01
+	if err := s.WriteBatch([]byte("ef")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := first.Close(); err == nil {
+		t.Error("previous shard was left open after rotation")
+	}
+	if got := readFile(t, prefix+"-2024-01-01-13.bin"); !bytes.Equal(got, []byte("abcd")) {
+		t.Errorf("13h shard = %q, want %q", got, "abcd")
+	}
+	if got := readFile(t, prefix+"-2024-01-01-14.bin"); !bytes.Equal(got, []byte("ef")) {
+		t.Errorf("14h shard = %q, want %q", got, "ef")
+	}
+}
+
+func TestTimeShardedSinkDaily(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "capture")
+	s, err := NewTimeShardedSink(prefix, ".bin", ShardDaily)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	at := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	if got, want := s.ShardPath(at), prefix+"-2024-01-01.bin"; got != want {
+		t.Errorf("ShardPath = %q, want %q", got, want)
+	}
+	if got, want := s.ShardPath(at.Add(2*time.Hour)), prefix+"-2024-01-02.bin"; got != want {
+		t.Errorf("ShardPath after midnight = %q, want %q", got, want)
+	}
+}
+
+func TestFileSinkReopenAfterRename(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.bin")
+	s, err := NewFileSink(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.WriteBatch([]byte("old")); err != nil {
+		t.Fatal(err)
+	}
+
+	// logrotate renames the file, then signals the process to reopen it.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	old := s.file
+	if err := s.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Write([]byte("x")); err == nil {
+		t.Error("old handle still open after Reopen")
+	}
+	if err := s.WriteBatch([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path+".1"); string(got) != "old" {
+		t.Errorf("rotated file = %q, want %q", got, "old")
+	}
+	if got := readFile(t, path); string(got) != "new" {
+		t.Errorf("reopened file = %q, want %q", got, "new")
+	}
+
+	// Reopen also revives a closed sink.
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteBatch([]byte("!")); err != nil {
+		t.Errorf("write after Close+Reopen: %v", err)
+	}
+}
+
+func TestRotatingFileSinkMaxFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.bin")
+	// A file left by an earlier run: numbering continues after it, and it
+	// is the oldest, so it goes first.
+	if err := os.WriteFile(path+".7", []byte("prev"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewRotatingFileSink(path, FileRotation{MaxBytes: 4, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, batch := range []string{"aaaa", "bbbb", "cccc", "dddd", "eeee"} {
+		if err := s.WriteBatch([]byte(batch)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, gone := range []string{".7", ".8", ".9"} {
+		if _, err := os.Stat(path + gone); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s still present (err %v)", gone, err)
+		}
+	}
+	for suffix, want := range map[string]string{".10": "cccc", ".11": "dddd", "": "eeee"} {
+		if got := readFile(t, path+suffix); string(got) != want {
+			t.Errorf("capture.bin%s = %q, want %q", suffix, got, want)
+		}
+	}
+
+	if _, err := NewRotatingFileSink(path, FileRotation{MaxBytes: 4, MaxFiles: -1}); err == nil {
+		t.Error("negative MaxFiles accepted")
+	}
+}
+
+func TestRotatingFileSinkGlobCharsInPath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run[1]*.bin")
+	// The glob run[1]*.bin.* would match this unrelated file.
+	decoy := filepath.Join(dir, "run1-other.bin.5")
+	if err := os.WriteFile(decoy, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewRotatingFileSink(path, FileRotation{MaxBytes: 4, MaxFiles: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, batch := range []string{"aaaa", "bbbb", "cccc"} {
+		if err := s.WriteBatch([]byte(batch)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := readFile(t, decoy); string(got) != "keep" {
+		t.Errorf("unrelated file rewritten to %q", got)
+	}
+	if _, err := os.Stat(path + ".1"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("%s.1 still present (err %v)", path, err)
+	}
+	for suffix, want := range map[string]string{".2": "bbbb", "": "cccc"} {
+		if got := readFile(t, path+suffix); string(got) != want {
+			t.Errorf("%s%s = %q, want %q", filepath.Base(path), suffix, got, want)
+		}
+	}
+}
+
+func TestFileSinkSplitsOnReconnect(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	// The device drops off after the first batch and comes back.
+	stream := randomBytes(35, 24)
+	served, unplugged := 0, false
+	fakeSerial(t, map[string]*fakePort{port: {src: readerFunc(func(p []byte) (int, error) {
+		if served == 8 && !unplugged {
+			unplugged = true
+			return 0, os.ErrClosed
+		}
+		n := copy(p, stream[served:])
+		served += n
+		return n, nil
+	})}})
+
+	path := filepath.Join(t.TempDir(), "capture.bin")
+	sink, err := NewFileSink(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	first := sink.file
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg := CollectConfig{
+		Reconnect:        true,
+		ReconnectBackoff: ReconnectConfig{InitialDelay: time.Millisecond},
+		OnReconnect: func(string) {
+			if err := sink.Split(); err != nil {
+				t.Error(err)
+			}
+		},
+	}
+	batches := 0
+	err = CollectBitsAtIntervalWithConfig(ctx, 64, time.Millisecond, cfg, func(b []byte) {
+		if err := sink.WriteBatch(b); err != nil {
+			t.Error(err)
+		}
+		if batches++; batches == 3 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	if _, err := first.Write([]byte("x")); err == nil {
+		t.Error("file of the first run still open after the reconnect")
+	}
+	if got := readFile(t, path+".1"); !bytes.Equal(got, stream[:8]) {
+		t.Errorf("first run file = %x, want %x", got, stream[:8])
+	}
+	if got := readFile(t, path); !bytes.Equal(got, stream[8:]) {
+		t.Errorf("second run file = %x, want %x", got, stream[8:])
+	}
+}
+
+// failingSink rejects every batch.
+type failingSink struct{ closed bool }
+
+func (s *failingSink) WriteBatch([]byte) error { return errors.New("sink full") }
+func (s *failingSink) Close() error            { s.closed = true; return nil }
+
+func TestTeeSinkFormatsPerTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.bin")
+	file, err := NewFileSink(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var screen bytes.Buffer
+	broken := &failingSink{}
+	tee := NewTeeSink(NewWriterSink(&screen, FormatHexLine), broken)
+	tee.Add(file)
+
+	batches := [][]byte{{0xde, 0xad}, {0xbe, 0xef, 0x01}}
+	for _, b := range batches {
+		if err := tee.WriteBatch(b); err == nil {
+			t.Error("failing member's error was swallowed")
+		}
+	}
+	if err := tee.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := screen.String(), "dead\nbeef01\n"; got != want {
+		t.Errorf("hex target = %q, want %q", got, want)
+	}
+	if got := readFile(t, path); !bytes.Equal(got, []byte{0xde, 0xad, 0xbe, 0xef, 0x01}) {
+		t.Errorf("raw target = %x, want deadbeef01", got)
+	}
+	if !broken.closed {
+		t.Error("Close skipped a member")
+	}
+}
@@ -0,0 +1,58 @@
+package truerng
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StatusMeter tracks the progress of a running capture: bytes, batches and
+// throughput. It is safe for concurrent use.
+type StatusMeter struct {
+	mu      sync.Mutex
+	start   time.Time
+	bytes   int64
+	batches int64
+}
+
+// NewStatusMeter returns a meter whose clock starts now.
+func NewStatusMeter() *StatusMeter {
+	return &StatusMeter{start: time.Now()}
+}
+
+// Add records a batch of n bytes.
+func (m *StatusMeter) Add(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes += int64(n)
+	m.batches++
+}
+
+// StatusSnapshot is a point-in-time copy of a StatusMeter.
+type StatusSnapshot struct {
+	Elapsed     time.Duration
+	Bytes       int64
+	Batches     int64
+	BytesPerSec float64
+}
+
+// Snapshot returns the current counters and average throughput.
+func (m *StatusMeter) Snapshot() StatusSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := StatusSnapshot{
+		Elapsed: time.Since(m.start),
+		Bytes:   m.bytes,
+		Batches: m.batches,
+	}
+	if secs := s.Elapsed.Seconds(); secs > 0 {
+		s.BytesPerSec = float64(s.Bytes) / secs
+	}
+	return s
+}
+
+// String formats the snapshot as a single status line.
+func (s StatusSnapshot) String() string {
+	return fmt.Sprintf("%s  %d bytes  %d batches  %.1f B/s",
+		s.Elapsed.Truncate(time.Second), s.Bytes, s.Batches, s.BytesPerSec)
+}
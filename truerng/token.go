@@ -0,0 +1,94 @@
+package truerng
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Encoding selects the text form of a token from GenerateToken.
+type Encoding int
+
+const (
+	// EncodingHex is lower-case hexadecimal, 2 characters per byte.
+	EncodingHex Encoding = iota
+	// EncodingBase32 is RFC 4648 base32 without padding.
+	EncodingBase32
+	// EncodingBase64URL is RFC 4648 URL-safe base64 without padding.
+	EncodingBase64URL
+)
+
+// String returns the encoding name as accepted by ParseEncoding.
+func (e Encoding) String() string {
+	switch e {
+	case EncodingHex:
+		return "hex"
+	case EncodingBase32:
+		return "base32"
+	case EncodingBase64URL:
+		return "base64url"
+	default:
+		return fmt.Sprintf("Encoding(%d)", int(e))
+	}
+}
+
+// ParseEncoding resolves an encoding name: hex, base32 or base64url
+// (case-insensitive).
+func ParseEncoding(s string) (Encoding, error) {
+	for _, e := range []Encoding{EncodingHex, EncodingBase32, EncodingBase64URL} {
+		if strings.EqualFold(s, e.String()) {
+			return e, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown encoding %q (want hex, base32 or base64url)", s)
+}
+
+// GenerateToken reads bits bits from the first TrueRNG and returns them
+// encoded with encoding, e.g. for API keys. The bits are rounded up to whole
+// bytes, the extra bits being zero (as ReadBitsWithMode leaves them), so the
+// length depends only on bits and encoding: 2 characters per byte for hex,
+// ceil(8n/5) for base32 and ceil(8n/6) for base64url with n bytes.
+func GenerateToken(bits int, encoding Encoding, mode CaptureMode) (string, error) {
+	return generateToken(bits, encoding, func() ([]byte, error) {
+		return ReadBitsWithMode(bits, mode)
+	})
+}
+
+// GenerateTokenFromPort is GenerateToken reading from the TrueRNG on
+// portName, e.g. a device picked with FindDeviceBySerial.
+func GenerateTokenFromPort(portName string, bits int, encoding Encoding, mode CaptureMode) (string, error) {
+	return generateToken(bits, encoding, func() ([]byte, error) {
+		return ReadBitsFromPort(portName, bits, mode)
+	})
+}
+
+// generateToken validates the arguments of GenerateToken, then encodes the
+// bits read returns.
+func generateToken(bits int, encoding Encoding, read func() ([]byte, error)) (string, error) {
+	if bits <= 0 {
+		return "", errors.New("bits must be positive")
+	}
+	if encoding < EncodingHex || encoding > EncodingBase64URL {
+		return "", fmt.Errorf("invalid encoding %v", encoding)
+	}
+	data, err := read()
+	if err != nil {
+		return "", err
+	}
+	return encodeToken(data, encoding), nil
+}
+
+// encodeToken encodes data with encoding, which must be valid.
+func encodeToken(data []byte, encoding Encoding) string {
+	switch encoding {
+	case EncodingBase32:
+		return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(data)
+	case EncodingBase64URL:
+		return base64.RawURLEncoding.EncodeToString(data)
+	default:
+		return hex.EncodeToString(data)
+	}
+}
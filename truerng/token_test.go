@@ -0,0 +1,75 @@
+package truerng
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestGenerateTokenLength(t *testing.T) {
+	fakePorts(t, trueRNGPort("/dev/ttyFAKE0", "A1"))
+	fakeSerial(t, map[string]*fakePort{"/dev/ttyFAKE0": randomPort(11)})
+
+	decoders := map[Encoding]func(string) ([]byte, error){
+		EncodingHex:       hex.DecodeString,
+		EncodingBase32:    base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString,
+		EncodingBase64URL: base64.RawURLEncoding.DecodeString,
+	}
+	for _, bits := range []int{1, 8, 64, 128, 130, 256} {
+		n := (bits + 7) / 8
+		want := map[Encoding]int{
+			EncodingHex:       2 * n,
+			EncodingBase32:    (8*n + 4) / 5,
+			EncodingBase64URL: (8*n + 5) / 6,
+		}
+		for enc, decode := range decoders {
+			tok, err := GenerateToken(bits, enc, ModeNormal)
+			if err != nil {
+				t.Fatalf("%d bits %v: %v", bits, enc, err)
+			}
+			if len(tok) != want[enc] {
+				t.Errorf("%d bits %v: token %q has length %d, want %d", bits, enc, tok, len(tok), want[enc])
+			}
+			data, err := decode(tok)
+			if err != nil {
+				t.Errorf("%d bits %v: token %q does not decode: %v", bits, enc, tok, err)
+				continue
+			}
+			if len(data) != n {
+				t.Errorf("%d bits %v: decoded %d bytes, want %d", bits, enc, len(data), n)
+			}
+			if extra := bits % 8; extra != 0 && data[n-1]&(0xff>>extra) != 0 {
+				t.Errorf("%d bits %v: trailing bits of %08b not zero", bits, enc, data[n-1])
+			}
+		}
+	}
+}
+
+func TestGenerateTokenRejectsBadArguments(t *testing.T) {
+	read := func() ([]byte, error) {
+		t.Fatal("read called for invalid arguments")
+		return nil, nil
+	}
+	if _, err := generateToken(0, EncodingHex, read); err == nil {
+		t.Error("0 bits accepted")
+	}
+	if _, err := generateToken(64, Encoding(42), read); err == nil {
+		t.Error("unknown encoding accepted")
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	for _, enc := range []Encoding{EncodingHex, EncodingBase32, EncodingBase64URL} {
+		got, err := ParseEncoding(enc.String())
+		if err != nil || got != enc {
+			t.Errorf("ParseEncoding(%q) = %v, %v", enc.String(), got, err)
+		}
+	}
+	if got, err := ParseEncoding("Base64URL"); err != nil || got != EncodingBase64URL {
+		t.Errorf("ParseEncoding is case-sensitive: %v, %v", got, err)
+	}
+	if _, err := ParseEncoding("base58"); err == nil {
+		t.Error("ParseEncoding accepted base58")
+	}
+}
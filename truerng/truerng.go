@@ -4,7 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"go.bug.st/serial"
@@ -40,6 +46,21 @@ func (m DeviceModel) String() string {
 	}
 }
 
+// RecommendedBlockSize returns an efficient read size in bytes for the model:
+// large enough that a fast device is not throttled by USB round-trips, small
+// enough that a slow one fills it quickly. Unknown models get a conservative
+// size.
+func (m DeviceModel) RecommendedBlockSize() int {
+	switch m {
+	case DeviceModelTrueRNG:
+		return 4096 // ~350 kbit/s
+	case DeviceModelTrueRNGpro, DeviceModelTrueRNGproV2:
+		return 16384 // ~3.2 Mbit/s
+	default:
+		return 1024
+	}
+}
+
 // CaptureMode represents the different capture modes supported by TrueRNG devices
 type CaptureMode string
 
@@ -56,39 +77,49 @@ const (
 	ModeNormalASCSlow CaptureMode = "MODE_NORMAL_ASC_SLOW" // 230400 baud - Normal in ASCII Mode - Slow for small devices (TrueRNGproV2 Only)
 )
 
-// GetBaudRate returns the baud rate for the given capture mode
+// GetBaudRate returns the baud rate for the given capture mode (the
+// MODE_NORMAL rate for unknown modes).
 func (m CaptureMode) GetBaudRate() int {
-	switch m {
-	case ModeNormal:
-		return 300
-	case ModePSDebug:
-		return 1200
-	case ModeRNGDebug:
-		return 2400
-	case ModeRNG1White:
-		return 4800
-	case ModeRNG2White:
-		return 9600
-	case ModeRawBin:
-		return 19200
-	case ModeRawASC:
-		return 38400
-	case ModeUnwhitened:
-		return 57600
-	case ModeNormalASC:
-		return 115200
-	case ModeNormalASCSlow:
-		return 230400
-	default:
-		return 300 // Default to MODE_NORMAL
+	if d, ok := lookupMode(m); ok {
+		return d.BaudRate
 	}
+	return 300 // Default to MODE_NORMAL
 }
 
 // DeviceInfo holds information about a detected TrueRNG device
 type DeviceInfo struct {
-	Port  string
-	Model DeviceModel
-	Name  string
+	Port         string
+	Model        DeviceModel
+	Name         string
+	SerialNumber string
+	// Confidence grades how the device was recognized: High for an exact
+	// VID/PID match, Medium for a USB product/serial string match and Low for
+	// a port-name match or probe.
+	Confidence Confidence
+}
+
+// Confidence describes how certain device detection is.
+type Confidence int
+
+const (
+	ConfidenceNone Confidence = iota
+	ConfidenceLow
+	ConfidenceMedium
+	ConfidenceHigh
+)
+
+// String returns the confidence level name.
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "Low"
+	case ConfidenceMedium:
+		return "Medium"
+	case ConfidenceHigh:
+		return "High"
+	default:
+		return "None"
+	}
 }
 
 // Detect returns true if a TrueRNG serial device is present on the system.
@@ -99,29 +130,132 @@ func Detect() (bool, error) {
 	return len(devices) > 0, err
 }
 
-// EnumerateDevices returns information about all detected TrueRNG devices
+// listPorts enumerates the serial ports. It is a variable so detection can
+// run against a fake enumerator.
+var listPorts = enumerator.GetDetailedPortsList
+
+// serialOpen opens a serial port. It is a variable so reads can run against a
+// fake port.
+var serialOpen = serial.Open
+
+// EnumerationTimeout bounds how long EnumerateDevices (and so Detect,
+// FindPort, FindDevice, ...) waits for the OS to list the serial ports; some
+// virtual USB stacks, e.g. on CI runners, can stall for half a minute or
+// more. On timeout enumeration fails with ErrEnumerationTimeout (or falls
+// back to probing when ProbeFallback is set). The stalled enumeration is
+// left to finish in the background. Zero or less waits indefinitely.
+var EnumerationTimeout = 30 * time.Second
+
+// ErrEnumerationTimeout reports port enumeration exceeding EnumerationTimeout.
+var ErrEnumerationTimeout = errors.New("enumeration timeout")
+
+// listPortsTimeout calls listPorts, giving up after EnumerationTimeout.
+func listPortsTimeout() ([]*enumerator.PortDetails, error) {
+	timeout := EnumerationTimeout
+	if timeout <= 0 {
+		return listPorts()
+	}
+	type result struct {
+		ports []*enumerator.PortDetails
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		ports, err := listPorts()
+		done <- result{ports, err}
+	}()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case r := <-done:
+		return r.ports, r.err
+	case <-t.C:
+		return nil, fmt.Errorf("%w after %s", ErrEnumerationTimeout, timeout)
+	}
+}
+
+// EnumerateDevices returns information about all detected TrueRNG devices.
+// If enumeration fails and ProbeFallback is enabled, candidate device paths
+// are probed directly instead (see probeDevices).
 func EnumerateDevices() ([]DeviceInfo, error) {
-	ports, err := enumerator.GetDetailedPortsList()
+	detailed, err := EnumerateDevicesDetailed()
+	if err != nil {
+		return nil, err
+	}
+	var devices []DeviceInfo
+	for _, d := range detailed {
+		devices = append(devices, d.DeviceInfo)
+	}
+	return devices, nil
+}
+
+// DetailedDevice is a detected TrueRNG together with the enumerator's raw
+// record of its port, for callers that need more than DeviceInfo keeps.
+type DetailedDevice struct {
+	DeviceInfo
+	// PortDetails is the enumerator entry the device was detected from. It
+	// is nil for devices found by probing (see ProbeFallback). Which fields
+	// are filled in depends on the OS.
+	PortDetails *enumerator.PortDetails
+}
+
+// EnumerateDevicesDetailed is EnumerateDevices keeping each device's raw
+// enumerator.PortDetails.
+func EnumerateDevicesDetailed() ([]DetailedDevice, error) {
+	ports, err := listPortsTimeout()
 	if err != nil {
+		if ProbeFallback {
+			probed, err := probeDevices(ProbeCandidates)
+			if err != nil {
+				return nil, err
+			}
+			var devices []DetailedDevice
+			for _, d := range probed {
+				devices = append(devices, DetailedDevice{DeviceInfo: d})
+			}
+			return devices, nil
+		}
 		return nil, fmt.Errorf("enumerating ports: %w", err)
 	}
 
-	var devices []DeviceInfo
+	var devices []DetailedDevice
 	for _, p := range ports {
 		if p == nil {
 			continue
 		}
-		if model := getTrueRNGModel(p); model != DeviceModelUnknown {
-			devices = append(devices, DeviceInfo{
-				Port:  p.Name,
-				Model: model,
-				Name:  p.Product,
+		if model, confidence := getTrueRNGModel(p); model != DeviceModelUnknown {
+			devices = append(devices, DetailedDevice{
+				DeviceInfo: DeviceInfo{
+					Port:         p.Name,
+					Model:        model,
+					Name:         deviceName(p, model),
+					SerialNumber: p.SerialNumber,
+					Confidence:   confidence,
+				},
+				PortDetails: p,
 			})
 		}
 	}
 	return devices, nil
 }
 
+// deviceName picks the Name for a device enumerated from p: the enumerator's
+// product string, then the product string descriptor read through sysfs
+// (Linux often leaves the former empty), then the model name and finally
+// "TrueRNG device", so it is never empty.
+func deviceName(p *enumerator.PortDetails, model DeviceModel) string {
+	if name := strings.TrimSpace(p.Product); name != "" {
+		return name
+	}
+	if name := usbProduct(p.Name); name != "" {
+		return name
+	}
+	if model != DeviceModelUnknown {
+		return model.String()
+	}
+	return DeviceNamePrefix + " device"
+}
+
 // FindPort returns the first serial port path for a detected TrueRNG device, e.g.
 // "/dev/ttyUSB0" on Linux.
 func FindPort() (string, error) {
@@ -130,7 +264,7 @@ func FindPort() (string, error) {
 		return "", err
 	}
 	if len(devices) == 0 {
-		return "", errors.New("TrueRNG device not found")
+		return "", ErrDeviceNotFound
 	}
 	return devices[0].Port, nil
 }
@@ -142,11 +276,29 @@ func FindDevice() (*DeviceInfo, error) {
 		return nil, err
 	}
 	if len(devices) == 0 {
-		return nil, errors.New("TrueRNG device not found")
+		return nil, ErrDeviceNotFound
 	}
 	return &devices[0], nil
 }
 
+// FindDeviceBySerial returns the detected TrueRNG whose USB serial number
+// matches serial (case-insensitive).
+func FindDeviceBySerial(serial string) (*DeviceInfo, error) {
+	if serial == "" {
+		return nil, errors.New("serial number must not be empty")
+	}
+	devices, err := EnumerateDevices()
+	if err != nil {
+		return nil, err
+	}
+	for i := range devices {
+		if strings.EqualFold(devices[i].SerialNumber, serial) {
+			return &devices[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w with serial %q", ErrDeviceNotFound, serial)
+}
+
 // ReadBytes opens the TrueRNG serial port, sets DTR, flushes input, and reads
 // blockSize bytes. The behavior mirrors `truerng.py`'s read_bytes.
 func ReadBytes(blockSize int) ([]byte, error) {
@@ -154,22 +306,262 @@ func ReadBytes(blockSize int) ([]byte, error) {
 }
 
 // ReadBytesWithMode opens the TrueRNG serial port with the specified capture mode,
-// sets DTR, flushes input, and reads blockSize bytes.
+// sets DTR, flushes input, and reads blockSize bytes, allowing 10 seconds per
+// 64 KiB. Use ReadBytesWithOptions for other timeouts.
 func ReadBytesWithMode(blockSize int, mode CaptureMode) ([]byte, error) {
+	return ReadBytesWithOptions(blockSize, mode, ReadOptions{})
+}
+
+// ReadBitsFromPort reads bitCount bits from the TrueRNG on portName, e.g. a
+// port returned by FindDeviceBySerial. Bits are packed as in ReadBits.
+func ReadBitsFromPort(portName string, bitCount int, mode CaptureMode) ([]byte, error) {
+	if bitCount <= 0 {
+		return nil, errors.New("bitCount must be positive")
+	}
+	data, err := readBytesFromPort(portName, mode, (bitCount+7)/8)
+	if err != nil {
+		return nil, err
+	}
+	extraBits := (8 - (bitCount % 8)) % 8
+	if extraBits != 0 {
+		data[len(data)-1] &= byte(0xFF << extraBits)
+	}
+	return data, nil
+}
+
+// ReadBitsFromSerial reads bitCount bits from the TrueRNG with the given USB
+// serial number, wherever it is currently enumerated, so scripts can target a
+// known unit among several. Bits are packed as in ReadBits.
+func ReadBitsFromSerial(serial string, bitCount int, mode CaptureMode) ([]byte, error) {
+	device, err := FindDeviceBySerial(serial)
+	if err != nil {
+		return nil, err
+	}
+	return ReadBitsFromPort(device.Port, bitCount, mode)
+}
+
+// ReadFromAll reads bitCount bits from every detected TrueRNG at once, one
+// goroutine per device, and returns the data keyed by port name. Devices
+// whose read fails are left out of the map and their errors, prefixed with
+// the port, are joined into the returned error, so a partial result comes
+// with a non-nil error. With no device connected it returns
+// ErrDeviceNotFound. Merge the outputs with CombineXOR.
+func ReadFromAll(bitCount int, mode CaptureMode) (map[string][]byte, error) {
+	if bitCount <= 0 {
+		return nil, errors.New("bitCount must be positive")
+	}
+	devices, err := EnumerateDevices()
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, ErrDeviceNotFound
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+	out := make(map[string][]byte, len(devices))
+	for _, d := range devices {
+		wg.Add(1)
+		go func(port string) {
+			defer wg.Done()
+			data, err := ReadBitsFromPort(port, bitCount, mode)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", port, err))
+				return
+			}
+			out[port] = data
+		}(d.Port)
+	}
+	wg.Wait()
+	return out, errors.Join(errs...)
+}
+
+// readChunkSize bounds how much a large read fetches (and allocates) per step.
+// Cancellation and the read deadline are checked once per chunk.
+const readChunkSize = 64 << 10
+
+// ReadBytesTo reads blockSize bytes from the TrueRNG and writes them to w in
+// chunks of at most 64 KiB, so arbitrarily large captures never sit in memory
+// at once. ctx is checked between chunks. It returns the number of bytes
+// written.
+func ReadBytesTo(ctx context.Context, w io.Writer, blockSize int, mode CaptureMode) (int64, error) {
 	if blockSize <= 0 {
-		return nil, errors.New("blockSize must be positive")
+		return 0, errors.New("blockSize must be positive")
 	}
 	portName, err := FindPort()
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	var written int64
+	err = streamFromPort(ctx, portName, mode, blockSize, ReadOptions{}, func(chunk []byte) error {
+		n, err := w.Write(chunk)
+		written += int64(n)
+		return err
+	})
+	return written, err
+}
+
+// ReadOptions relaxes the requirements of a read.
+type ReadOptions struct {
+	// MinBytes is the smallest result accepted when the read deadline hits
+	// before blockSize bytes arrived; the partial buffer is then returned
+	// without error. Zero means blockSize, i.e. short reads fail.
+	MinBytes int
+	// RejectStuck fails the read with ErrNoiseSourceDead when the data is an
+	// all-0x00 or all-0xFF block (see CheckStuck).
+	RejectStuck bool
+	// RequireQualityCheck makes the read fail closed: the data is run through
+	// VerifyQuality and, if it fails, discarded and the read returns an error
+	// wrapping ErrQualityUnverified, so suspect bytes never reach the caller.
+	// Binary modes only.
+	RequireQualityCheck bool
+	// HardTimeout arms a watchdog for every port read: if a single read
+	// blocks longer than this (a wedged driver can ignore the software
+	// deadline), the port is force-closed from another goroutine to unblock
+	// it and the read fails with ErrReadTimeout. Zero disables the watchdog.
+	HardTimeout time.Duration
+	// SharedRead opens the port without claiming exclusive access, so another
+	// program that does the same (or already has the port open without
+	// exclusivity) can read alongside, e.g. for a passive monitor. The device
+	// output is not duplicated: every byte goes to exactly one of the readers,
+	// in whatever split the kernel delivers, so neither sees a contiguous
+	// stream and each gets roughly a share of the throughput. Flushing on
+	// open also discards bytes the other reader has not picked up yet. Linux
+	// only; elsewhere the open fails with ErrUnsupported.
+	SharedRead bool
+	// ReadTimeout is the serial read timeout, i.e. how long one port read
+	// waits for data before returning empty-handed. Zero means 1s.
+	ReadTimeout time.Duration
+	// OverallDeadline bounds the whole read: all blockSize bytes must arrive
+	// within it, however large the block. Zero keeps the default of 10s per
+	// 64 KiB chunk, which is too tight for large captures in slow modes.
+	OverallDeadline time.Duration
+}
+
+// defaultChunkDeadline is how long each chunk of a read may take when
+// ReadOptions.OverallDeadline is not set.
+const defaultChunkDeadline = 10 * time.Second
+
+// ErrReadTimeout reports a read that did not complete in time, either at the
+// 10s software deadline or when the HardTimeout watchdog fired.
+var ErrReadTimeout = errors.New("read timeout")
+
+// ErrDeviceNotFound reports that no matching TrueRNG is connected.
+var ErrDeviceNotFound = errors.New("TrueRNG device not found")
+
+// ErrPortClosed reports a read failing because the serial port went away
+// under it, typically because the device was unplugged.
+var ErrPortClosed = errors.New("port closed")
+
+// readError wraps a failed port read as "read error", adding ErrPortClosed
+// when the port was closed under the read.
+func readError(err error) error {
+	var pe *serial.PortError
+	if (errors.As(err, &pe) && pe.Code() == serial.PortClosed) ||
+		errors.Is(err, os.ErrClosed) || errors.Is(err, syscall.EPIPE) {
+		return fmt.Errorf("read error: %w: %w", ErrPortClosed, err)
+	}
+	return fmt.Errorf("read error: %w", err)
+}
+
+// ReadBytesWithOptions is ReadBytesWithMode with opts applied. With MinBytes
+// set, the returned slice may be shorter than blockSize. ReadBytesWithMode is
+// this with the zero ReadOptions.
+func ReadBytesWithOptions(blockSize int, mode CaptureMode, opts ReadOptions) ([]byte, error) {
+	return Read(blockSize, WithMode(mode), WithReadOptions(opts))
+}
+
+// validateReadOptions rejects a blockSize or opts that ReadBytesWithOptions
+// cannot honour.
+func validateReadOptions(blockSize int, opts ReadOptions) error {
+	if blockSize <= 0 {
+		return errors.New("blockSize must be positive")
+	}
+	if opts.MinBytes < 0 || opts.MinBytes > blockSize {
+		return fmt.Errorf("MinBytes must be between 0 and blockSize (%d)", blockSize)
+	}
+	if opts.ReadTimeout < 0 || opts.OverallDeadline < 0 {
+		return errors.New("timeouts must not be negative")
 	}
+	return nil
+}
 
-	// Use the efficient helper function for single reads
-	return readBytesFromPort(portName, mode, blockSize)
+// checkReadData applies opts.RejectStuck and opts.RequireQualityCheck to the
+// data of a completed read.
+func checkReadData(data []byte, opts ReadOptions) error {
+	if opts.RejectStuck {
+		if err := CheckStuck(data); err != nil {
+			return err
+		}
+	}
+	if opts.RequireQualityCheck {
+		if err := VerifyQuality(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shortReadError reports a read that hit its deadline before all bytes came.
+type shortReadError struct {
+	got, want int
+	after     time.Duration // the deadline that expired; zero means 10s
+}
+
+func (e *shortReadError) Error() string {
+	after := e.after
+	if after == 0 {
+		after = defaultChunkDeadline
+	}
+	return fmt.Sprintf("read timeout after %s: read %d/%d bytes", after, e.got, e.want)
+}
+
+func (e *shortReadError) Unwrap() error {
+	return ErrReadTimeout
 }
 
 // readBytesFromPort is a helper function that opens a port and reads bytes efficiently
 func readBytesFromPort(portName string, mode CaptureMode, blockSize int) ([]byte, error) {
+	return readBytesFromPortOpts(portName, mode, blockSize, ReadOptions{})
+}
+
+// readBytesFromPortOpts reads up to blockSize bytes, accepting a short read
+// of at least opts.MinBytes (0 meaning blockSize) and arming the
+// opts.HardTimeout watchdog.
+func readBytesFromPortOpts(portName string, mode CaptureMode, blockSize int, opts ReadOptions) ([]byte, error) {
+	minBytes := opts.MinBytes
+	if minBytes <= 0 {
+		minBytes = blockSize
+	}
+	out := make([]byte, 0, min(blockSize, readChunkSize))
+	err := streamFromPort(context.Background(), portName, mode, blockSize, opts, func(chunk []byte) error {
+		out = append(out, chunk...)
+		return nil
+	})
+	var short *shortReadError
+	if errors.As(err, &short) && len(out) >= minBytes {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// streamFromPort opens portName and reads blockSize bytes, handing them to
+// onChunk in chunks of at most readChunkSize. The chunk slice is reused and
+// must not be retained. Each chunk must arrive within 10 seconds, or the whole
+// block within opts.OverallDeadline if set; on timeout the bytes of the
+// partial chunk are still handed over and a *shortReadError is returned. The
+// other opts apply as described at ReadOptions (MinBytes is up to the
+// caller).
+func streamFromPort(ctx context.Context, portName string, mode CaptureMode, blockSize int, opts ReadOptions, onChunk func([]byte) error) error {
 	// Skip mode change for now to avoid triggering USB re-enumeration
 	// if err := changeMode(portName, mode); err != nil {
 	//     // Mode change failed, but we can still try to read in normal mode
@@ -183,36 +575,99 @@ func readBytesFromPort(portName string, mode CaptureMode, blockSize int) ([]byte
 		StopBits: serial.OneStopBit,
 	}
 
-	port, err := serial.Open(portName, serialMode)
+	port, err := openPort(portName, serialMode, opts.SharedRead)
 	if err != nil {
-		return nil, fmt.Errorf("open %s: %w", portName, err)
+		return fmt.Errorf("open %s: %w", portName, err)
 	}
 	defer func() { _ = port.Close() }()
 
 	// Set DTR true (as in Python), then flush any buffered input before reading.
 	_ = port.SetDTR(true)
-	_ = port.SetReadTimeout(1000 * time.Millisecond)
+	readTimeout := opts.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = 1000 * time.Millisecond
+	}
+	_ = port.SetReadTimeout(readTimeout)
 	if err := port.ResetInputBuffer(); err != nil {
 		// not fatal, proceed
 	}
 
-	buf := make([]byte, blockSize)
-	total := 0
-	deadline := time.Now().Add(10 * time.Second) // match Python's 10s timeout intent
-	for total < blockSize {
-		if time.Now().After(deadline) {
-			return nil, fmt.Errorf("read timeout after 10s: read %d/%d bytes", total, blockSize)
+	var overall time.Time
+	if opts.OverallDeadline > 0 {
+		overall = time.Now().Add(opts.OverallDeadline)
+	}
+	buf := make([]byte, min(blockSize, readChunkSize))
+	done := 0
+	for done < blockSize {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		n, err := port.Read(buf[total:])
-		if err != nil {
-			return nil, fmt.Errorf("read error: %w", err)
+		chunk := buf[:min(blockSize-done, len(buf))]
+		total := 0
+		deadline, after := time.Now().Add(defaultChunkDeadline), time.Duration(0) // match Python's 10s timeout intent
+		if !overall.IsZero() {
+			deadline, after = overall, opts.OverallDeadline
+		}
+		for total < len(chunk) {
+			if time.Now().After(deadline) {
+				if total > 0 {
+					if err := onChunk(chunk[:total]); err != nil {
+						return err
+					}
+				}
+				return &shortReadError{got: done + total, want: blockSize, after: after}
+			}
+			n, err := readWithWatchdog(port, chunk[total:], opts.HardTimeout)
+			if err != nil {
+				return err
+			}
+			total += n
+			if n == 0 {
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+		if err := onChunk(chunk); err != nil {
+			return err
 		}
-		total += n
-		if n == 0 {
-			time.Sleep(5 * time.Millisecond)
+		done += len(chunk)
+	}
+	return nil
+}
+
+// openPort opens portName, leaving it open to other readers when shared is
+// set (see ReadOptions.SharedRead).
+func openPort(portName string, mode *serial.Mode, shared bool) (serial.Port, error) {
+	if shared {
+		return openSharedPort(portName, mode)
+	}
+	return serialOpen(portName, mode)
+}
+
+// readWithWatchdog performs one port read. With a positive hardTimeout the
+// port is closed from a timer goroutine if the read is still blocked after
+// that long, and ErrReadTimeout is returned; the port is unusable afterwards.
+func readWithWatchdog(port serial.Port, p []byte, hardTimeout time.Duration) (int, error) {
+	if hardTimeout <= 0 {
+		n, err := port.Read(p)
+		if err != nil {
+			return n, readError(err)
 		}
+		return n, nil
+	}
+	var fired atomic.Bool
+	t := time.AfterFunc(hardTimeout, func() {
+		fired.Store(true)
+		_ = port.Close()
+	})
+	n, err := port.Read(p)
+	t.Stop()
+	if fired.Load() {
+		return n, fmt.Errorf("%w: read blocked for more than %s, port closed by watchdog", ErrReadTimeout, hardTimeout)
 	}
-	return buf, nil
+	if err != nil {
+		return n, readError(err)
+	}
+	return n, nil
 }
 
 // ReadBits reads bitCount bits from the TrueRNG and returns them as a byte
@@ -240,32 +695,58 @@ func ReadBitsWithMode(bitCount int, mode CaptureMode) ([]byte, error) {
 	return data, nil
 }
 
+// ReadBitsHashed reads bitCount bits like ReadBitsWithMode, writes the
+// returned bytes (trailing bits already masked) to h and returns them together
+// with h.Sum(nil). h is not reset first, so anything already written to it,
+// such as a salt, is part of the digest.
+func ReadBitsHashed(bitCount int, mode CaptureMode, h hash.Hash) (data []byte, digest []byte, err error) {
+	if h == nil {
+		return nil, nil, errors.New("hash must not be nil")
+	}
+	data, err = ReadBitsWithMode(bitCount, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	h.Write(data)
+	return data, h.Sum(nil), nil
+}
+
 // CollectBitsAtInterval reads bitCount bits every interval, invoking onBatch
 // with the bytes each time. It runs until the context is cancelled or a read
 // error occurs. Any error is returned.
+//
+// The slice passed to onBatch is reused for the next read; copy it if it must
+// outlive the callback. The same holds for every collect function.
 func CollectBitsAtInterval(ctx context.Context, bitCount int, interval time.Duration, onBatch func([]byte)) error {
 	return CollectBitsAtIntervalWithMode(ctx, bitCount, interval, ModeNormal, onBatch)
 }
 
 // CollectBitsAtIntervalWithMode reads bitCount bits every interval with the specified mode
 func CollectBitsAtIntervalWithMode(ctx context.Context, bitCount int, interval time.Duration, mode CaptureMode, onBatch func([]byte)) error {
-	if bitCount <= 0 {
-		return errors.New("bitCount must be positive")
-	}
-	if interval <= 0 {
-		return errors.New("interval must be positive")
-	}
-	if onBatch == nil {
-		return errors.New("onBatch callback must not be nil")
-	}
+	return CollectBitsAtIntervalWithConfig(ctx, bitCount, interval, CollectConfig{Mode: mode}, onBatch)
+}
 
+// collectPerRead is the CollectBitsAtIntervalWithConfig loop that opens the
+// port for every read.
+func collectPerRead(ctx context.Context, bitCount int, interval time.Duration, cfg CollectConfig, onBatch func([]byte)) error {
 	// Use per-read connection approach to avoid long-running connection issues
 
 	byteCount := (bitCount + 7) / 8
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	// Batches are delivered synchronously, so one buffer serves every read;
+	// onBatch must copy it to keep the data (or set CollectConfig.CopyBatch).
+	buf := make([]byte, byteCount)
+	opts := ReadOptions{RejectStuck: cfg.RejectStuck, SharedRead: cfg.SharedRead}
+	var ct ContinuousTest
+	sched := newIntervalSchedule(interval, cfg.IntervalJitter)
+	defer sched.stop()
+	if cfg.delayFirstRead() {
+		if err := sched.wait(ctx); err != nil {
+			return err
+		}
+	}
 
-	// Do an immediate first read, then on each tick thereafter.
+	// Do an immediate first read (unless delayed above), then on each tick thereafter.
+	wait := cfg.WaitForDevice
 	for {
 		select {
 		case <-ctx.Done():
@@ -274,60 +755,18 @@ func CollectBitsAtIntervalWithMode(ctx context.Context, bitCount int, interval t
 		}
 
 		// Open port for each read to avoid long-running connection issues
-		currentPortName, err := FindPort()
+		currentPortName, err := waitForPort(ctx, cfg, wait)
+		wait = 0
 		if err != nil {
 			return fmt.Errorf("device not found: %w", err)
 		}
-
-		// Use default serial mode to avoid USB re-enumeration issues
-		serialMode := &serial.Mode{
-			Parity:   serial.NoParity,
-			StopBits: serial.OneStopBit,
+		if err := readBatchFromPort(ctx, currentPortName, buf, bitCount, opts); err != nil {
+			return err
 		}
-
-		port, err := serial.Open(currentPortName, serialMode)
-		if err != nil {
-			return fmt.Errorf("open %s: %w", currentPortName, err)
-		}
-
-		// Quick setup and read
-		_ = port.SetDTR(true)
-		_ = port.SetReadTimeout(2000 * time.Millisecond)
-		if err := port.ResetInputBuffer(); err != nil {
-			port.Close()
-			// not fatal, proceed
-		}
-
-		// Read data
-		buf := make([]byte, byteCount)
-		total := 0
-		deadline := time.Now().Add(5 * time.Second)
-
-		for total < byteCount {
-			if time.Now().After(deadline) {
-				port.Close()
-				return fmt.Errorf("read timeout after 5s: read %d/%d bytes", total, byteCount)
-			}
-
-			n, err := port.Read(buf[total:])
-			if err != nil {
-				port.Close()
-				return fmt.Errorf("read error: %w", err)
+		if cfg.ContinuousTest {
+			if err := ct.Check(buf); err != nil {
+				return err
 			}
-
-			total += n
-			if n == 0 {
-				time.Sleep(5 * time.Millisecond)
-			}
-		}
-
-		// Close port immediately after read
-		port.Close()
-
-		// Process bits (zero out unused trailing bits)
-		extraBits := (8 - (bitCount % 8)) % 8
-		if extraBits != 0 {
-			buf[len(buf)-1] &= byte(0xFF << extraBits)
 		}
 
 		onBatch(buf)
@@ -335,54 +774,57 @@ func CollectBitsAtIntervalWithMode(ctx context.Context, bitCount int, interval t
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-ticker.C:
+		case <-sched.next():
 			// Continue to next iteration
 		}
 	}
 }
 
+// usbID ties a USB vendor/product pair to a TrueRNG model.
+type usbID struct {
+	Model    DeviceModel
+	VID, PID string
+}
+
+// usbIDs lists the known TrueRNG VID:PID combinations (from the Python code).
+var usbIDs = []usbID{
+	{DeviceModelTrueRNG, "04D8", "F5FE"},
+	{DeviceModelTrueRNGpro, "16D0", "0AA0"},
+	{DeviceModelTrueRNGproV2, "04D8", "EBB5"},
+	// Additional TrueRNGpro variants
+	{DeviceModelTrueRNGpro, "16D0", "0AA2"},
+	{DeviceModelTrueRNGpro, "16D0", "0AA4"},
+}
+
 // getTrueRNGModel determines the TrueRNG device model from port details
-// Based on the Python implementation's VID/PID detection
-func getTrueRNGModel(p *enumerator.PortDetails) DeviceModel {
+// Based on the Python implementation's VID/PID detection. The confidence
+// reflects which matching rule fired.
+func getTrueRNGModel(p *enumerator.PortDetails) (DeviceModel, Confidence) {
 	if p == nil {
-		return DeviceModelUnknown
+		return DeviceModelUnknown, ConfidenceNone
 	}
 
 	// Check VID/PID combinations from Python code
 	if p.IsUSB {
-		vid := strings.ToUpper(p.VID)
-		pid := strings.ToUpper(p.PID)
-
-		// TrueRNG VID:PID combinations
-		if vid == "04D8" && pid == "F5FE" {
-			return DeviceModelTrueRNG
-		}
-		// TrueRNGpro VID:PID combinations
-		if vid == "16D0" && pid == "0AA0" {
-			return DeviceModelTrueRNGpro
-		}
-		// TrueRNGproV2 VID:PID combinations
-		if vid == "04D8" && pid == "EBB5" {
-			return DeviceModelTrueRNGproV2
-		}
-		// Additional TrueRNGpro variants
-		if vid == "16D0" && (pid == "0AA2" || pid == "0AA4") {
-			return DeviceModelTrueRNGpro
+		for _, id := range usbIDs {
+			if strings.EqualFold(p.VID, id.VID) && strings.EqualFold(p.PID, id.PID) {
+				return id.Model, ConfidenceHigh
+			}
 		}
 	}
 
 	// Fallback: check product name or description
 	if p.IsUSB && p.Product != "" && strings.Contains(strings.ToUpper(p.Product), "TRUERNG") {
-		return DeviceModelTrueRNGpro // Assume pro model for generic TrueRNG names
+		return DeviceModelTrueRNGpro, ConfidenceMedium // Assume pro model for generic TrueRNG names
 	}
 	if p.IsUSB && p.SerialNumber != "" && strings.Contains(strings.ToUpper(p.SerialNumber), "TRUERNG") {
-		return DeviceModelTrueRNGpro
+		return DeviceModelTrueRNGpro, ConfidenceMedium
 	}
 	if p.Name != "" && strings.Contains(strings.ToUpper(p.Name), "TRUERNG") {
-		return DeviceModelTrueRNGpro
+		return DeviceModelTrueRNGpro, ConfidenceLow
 	}
 
-	return DeviceModelUnknown
+	return DeviceModelUnknown, ConfidenceNone
 }
 
 // changeMode implements the "knock sequence" to change TrueRNG capture modes
@@ -401,7 +843,7 @@ func changeMode(portName string, mode CaptureMode) error {
 			StopBits: serial.OneStopBit,
 		}
 
-		port, err := serial.Open(portName, mode)
+		port, err := serialOpen(portName, mode)
 		if err != nil {
 			return fmt.Errorf("failed to open port for mode change: %w", err)
 		}
@@ -416,7 +858,7 @@ func changeMode(portName string, mode CaptureMode) error {
 		StopBits: serial.OneStopBit,
 	}
 
-	port, err := serial.Open(portName, finalMode)
+	port, err := serialOpen(portName, finalMode)
 	if err != nil {
 		return fmt.Errorf("failed to set final mode: %w", err)
 	}
@@ -439,7 +881,17 @@ func ListDevices() error {
 
 	fmt.Println("Found TrueRNG devices:")
 	for i, device := range devices {
-		fmt.Printf("%d. %s on %s (Model: %s)\n", i+1, device.Name, device.Port, device.Model.String())
+		extra := ""
+		if device.SerialNumber != "" {
+			extra = ", Serial: " + device.SerialNumber
+		}
+		if loc, err := device.USBLocation(); err == nil {
+			extra += ", Location: " + loc
+		}
+		fmt.Printf("%d. %s on %s (Model: %s%s, Confidence: %s)\n", i+1, device.Name, device.Port, device.Model.String(), extra, device.Confidence)
+		if speed, err := device.USBSpeed(); err == nil && !speed.Sustains(device.Model.Throughput()) {
+			fmt.Printf("   Warning: running at %s USB speed, too slow for the %d kbit/s a %s produces\n", speed, device.Model.Throughput()/1000, device.Model)
+		}
 	}
 
 	return nil
@@ -448,30 +900,32 @@ func ListDevices() error {
 // CollectBitsAtIntervalWithReconnect is a more robust version that can handle
 // device disconnections and attempt reconnection
 func CollectBitsAtIntervalWithReconnect(ctx context.Context, bitCount int, interval time.Duration, mode CaptureMode, onBatch func([]byte)) error {
-	if bitCount <= 0 {
-		return errors.New("bitCount must be positive")
-	}
-	if interval <= 0 {
-		return errors.New("interval must be positive")
-	}
-	if onBatch == nil {
-		return errors.New("onBatch callback must not be nil")
-	}
+	return CollectBitsAtIntervalWithConfig(ctx, bitCount, interval, CollectConfig{Mode: mode, Reconnect: true}, onBatch)
+}
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// collectWithReconnect is the CollectBitsAtIntervalWithConfig loop that keeps
+// one port open and reconnects when the device goes away.
+func collectWithReconnect(ctx context.Context, bitCount int, interval time.Duration, cfg CollectConfig, onBatch func([]byte)) error {
+	mode := cfg.Mode
+	sched := newIntervalSchedule(interval, cfg.IntervalJitter)
+	defer sched.stop()
+	if cfg.delayFirstRead() {
+		if err := sched.wait(ctx); err != nil {
+			return err
+		}
+	}
 
 	var port serial.Port
 	var portName string
 	var err error
 
 	// Initial device connection
-	portName, err = FindPort()
+	portName, err = waitForPort(ctx, cfg, cfg.WaitForDevice)
 	if err != nil {
 		return err
 	}
 
-	port, err = connectToDevice(portName, mode)
+	port, err = connectToDevice(portName, mode, cfg.SharedRead)
 	if err != nil {
 		return fmt.Errorf("initial connection failed: %w", err)
 	}
@@ -482,6 +936,12 @@ func CollectBitsAtIntervalWithReconnect(ctx context.Context, bitCount int, inter
 	}()
 
 	byteCount := (bitCount + 7) / 8
+	// Batches are delivered synchronously, so one buffer serves every read;
+	// onBatch must copy it to keep the data (or set CollectConfig.CopyBatch).
+	buf := make([]byte, byteCount)
+	opts := ReadOptions{RejectStuck: cfg.RejectStuck, SharedRead: cfg.SharedRead}
+	var ct ContinuousTest
+	backoff := NewBackoff(cfg.ReconnectBackoff)
 	consecutiveErrors := 0
 	maxConsecutiveErrors := 3
 
@@ -493,63 +953,47 @@ func CollectBitsAtIntervalWithReconnect(ctx context.Context, bitCount int, inter
 		}
 
 		// Try to read from current port
-		buf := make([]byte, byteCount)
-		total := 0
-		deadline := time.Now().Add(5 * time.Second)
-		readAttempts := 0
-		maxReadAttempts := 30
-
-		readSuccessful := false
-
-		for total < byteCount && readAttempts < maxReadAttempts && !readSuccessful {
-			if time.Now().After(deadline) {
-				break // Timeout
-			}
-
-			n, err := port.Read(buf[total:])
-			if err != nil {
-				// Check for port closed errors
-				if strings.Contains(err.Error(), "closed") || strings.Contains(err.Error(), "broken pipe") {
-					fmt.Printf("Port closed, attempting reconnection...\n")
-					port.Close()
-					port = nil
-					break
-				}
-				consecutiveErrors++
-				if consecutiveErrors >= maxConsecutiveErrors {
-					return fmt.Errorf("too many consecutive read errors: %w", err)
-				}
-				break
-			}
-
-			total += n
-			readAttempts++
-
-			if n == 0 {
-				time.Sleep(20 * time.Millisecond)
-			} else {
-				consecutiveErrors = 0 // Reset error counter on successful read
-				if total >= byteCount {
-					readSuccessful = true
-				}
+		err := errNoPort
+		if port != nil {
+			err = readBatch(ctx, port, buf, bitCount, opts)
+		}
+		switch {
+		case err == nil:
+			consecutiveErrors = 0
+		case err == errNoPort:
+			// A previous reconnection failed; retry it below
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, ErrNoiseSourceDead):
+			return err
+		case errors.Is(err, ErrPortClosed):
+			// Port closed; fall through to reconnection
+			fmt.Printf("Port closed, attempting reconnection...\n")
+		case !errors.Is(err, ErrReadTimeout):
+			consecutiveErrors++
+			if consecutiveErrors >= maxConsecutiveErrors {
+				return fmt.Errorf("too many consecutive read errors: %w", err)
 			}
 		}
 
 		// If read failed, try to reconnect
-		if !readSuccessful || port == nil {
+		if err != nil {
 			if port != nil {
 				port.Close()
 				port = nil
 			}
 
-			// Wait a bit before attempting reconnection
-			time.Sleep(500 * time.Millisecond)
+			// Back off before each attempt, longer after every failure
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff.Next()):
+			}
 
 			// Try to find device again
-			newPortName, err := FindPort()
+			newPortName, err := cfg.findPort()
 			if err != nil {
 				fmt.Printf("Device not found during reconnection attempt: %v\n", err)
-				time.Sleep(1 * time.Second)
 				continue
 			}
 
@@ -560,37 +1004,43 @@ func CollectBitsAtIntervalWithReconnect(ctx context.Context, bitCount int, inter
 			}
 
 			// Attempt reconnection
-			port, err = connectToDevice(portName, mode)
+			port, err = connectToDevice(portName, mode, cfg.SharedRead)
 			if err != nil {
 				fmt.Printf("Reconnection failed: %v\n", err)
-				time.Sleep(1 * time.Second)
 				continue
 			}
 
+			backoff.Reset()
 			fmt.Printf("Successfully reconnected to device\n")
 			consecutiveErrors = 0
+			if cfg.OnReconnect != nil {
+				cfg.OnReconnect(portName)
+			}
 			continue // Skip this iteration and try again
 		}
 
-		// Process successful read
-		extraBits := (8 - (bitCount % 8)) % 8
-		if extraBits != 0 {
-			buf[len(buf)-1] &= byte(0xFF << extraBits)
+		if cfg.ContinuousTest {
+			if err := ct.Check(buf); err != nil {
+				return err
+			}
 		}
-
 		onBatch(buf)
 
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-ticker.C:
+		case <-sched.next():
 			// Continue to next iteration
 		}
 	}
 }
 
-// connectToDevice establishes a connection to a TrueRNG device
-func connectToDevice(portName string, mode CaptureMode) (serial.Port, error) {
+// errNoPort marks a reconnect-loop iteration that starts without an open port.
+var errNoPort = errors.New("no open port")
+
+// connectToDevice establishes a connection to a TrueRNG device, without
+// exclusive access when shared is set.
+func connectToDevice(portName string, mode CaptureMode, shared bool) (serial.Port, error) {
 	// Skip mode change for now to avoid triggering USB re-enumeration
 	// if err := changeMode(portName, mode); err != nil {
 	//     return nil, fmt.Errorf("failed to change mode: %w", err)
@@ -604,7 +1054,7 @@ func connectToDevice(portName string, mode CaptureMode) (serial.Port, error) {
 		StopBits: serial.OneStopBit,
 	}
 
-	port, err := serial.Open(portName, serialMode)
+	port, err := openPort(portName, serialMode, shared)
 	if err != nil {
 		return nil, fmt.Errorf("open %s: %w", portName, err)
 	}
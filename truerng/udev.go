@@ -0,0 +1,82 @@
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.bug.st/serial"
+)
+
+// UdevRulePath is where SuggestUdevRule's output is meant to be installed.
+const UdevRulePath = "/etc/udev/rules.d/99-TrueRNG.rules"
+
+// udevRuleDirs are searched by CheckUdevRules, in udev's precedence order.
+var udevRuleDirs = []string{"/etc/udev/rules.d", "/run/udev/rules.d", "/usr/lib/udev/rules.d", "/lib/udev/rules.d"}
+
+// CheckUdevRules reports whether an installed udev rules file mentions a
+// TrueRNG VID/PID pair. When one does, rulePath is that file; otherwise it is
+// UdevRulePath, the place to install the rule from SuggestUdevRule.
+// Directories that do not exist are skipped.
+func CheckUdevRules() (installed bool, rulePath string, err error) {
+	for _, dir := range udevRuleDirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.rules"))
+		if err != nil {
+			return false, "", err
+		}
+		for _, path := range files {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+					continue
+				}
+				return false, "", fmt.Errorf("read %s: %w", path, err)
+			}
+			if mentionsTrueRNG(strings.ToLower(string(data))) {
+				return true, path, nil
+			}
+		}
+	}
+	return false, UdevRulePath, nil
+}
+
+// mentionsTrueRNG reports whether lower-cased rules text matches a known
+// TrueRNG vendor and product id.
+func mentionsTrueRNG(rules string) bool {
+	for _, id := range usbIDs {
+		if strings.Contains(rules, `"`+strings.ToLower(id.VID)+`"`) &&
+			strings.Contains(rules, `"`+strings.ToLower(id.PID)+`"`) {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestUdevRule returns udev rules granting all users access to the given
+// model's serial device (MODE 0666, like the bundled 99-TrueRNG.rules). For
+// DeviceModelUnknown it covers every known TrueRNG.
+func SuggestUdevRule(model DeviceModel) string {
+	var b strings.Builder
+	for _, id := range usbIDs {
+		if model != DeviceModelUnknown && id.Model != model {
+			continue
+		}
+		fmt.Fprintf(&b, "# %s\n", id.Model)
+		fmt.Fprintf(&b, "SUBSYSTEM==\"tty\", ATTRS{idVendor}==\"%s\", ATTRS{idProduct}==\"%s\", MODE=\"0666\"\n",
+			strings.ToLower(id.VID), strings.ToLower(id.PID))
+	}
+	return b.String()
+}
+
+// IsPermissionDenied reports whether err comes from the OS refusing access to
+// the serial device, which usually means the udev rule is missing.
+func IsPermissionDenied(err error) bool {
+	var pe *serial.PortError
+	if errors.As(err, &pe) && pe.Code() == serial.PermissionDenied {
+		return true
+	}
+	return errors.Is(err, fs.ErrPermission)
+}
@@ -0,0 +1,63 @@
+package truerng
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuggestUdevRuleIDs(t *testing.T) {
+	for _, tc := range []struct {
+		model   DeviceModel
+		want    []string
+		notWant []string
+	}{
+		{DeviceModelTrueRNG, []string{`"04d8"`, `"f5fe"`}, []string{`"ebb5"`, `"16d0"`}},
+		{DeviceModelTrueRNGpro, []string{`"16d0"`, `"0aa0"`, `"0aa2"`, `"0aa4"`}, []string{`"f5fe"`}},
+		{DeviceModelTrueRNGproV2, []string{`"04d8"`, `"ebb5"`}, []string{`"f5fe"`, `"16d0"`}},
+		{DeviceModelUnknown, []string{`"f5fe"`, `"0aa0"`, `"ebb5"`}, nil},
+	} {
+		rule := SuggestUdevRule(tc.model)
+		for _, s := range tc.want {
+			if !strings.Contains(rule, s) {
+				t.Errorf("%s rule lacks %s:\n%s", tc.model, s, rule)
+			}
+		}
+		for _, s := range tc.notWant {
+			if strings.Contains(rule, s) {
+				t.Errorf("%s rule mentions %s:\n%s", tc.model, s, rule)
+			}
+		}
+		if !strings.Contains(rule, `MODE="0666"`) {
+			t.Errorf("%s rule grants no access:\n%s", tc.model, rule)
+		}
+	}
+}
+
+func TestCheckUdevRules(t *testing.T) {
+	dir := t.TempDir()
+	old := udevRuleDirs
+	udevRuleDirs = []string{filepath.Join(dir, "missing"), dir}
+	t.Cleanup(func() { udevRuleDirs = old })
+
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("50-other.rules", `SUBSYSTEM=="tty", ATTRS{idVendor}=="0403", ATTRS{idProduct}=="6001"`+"\n")
+	installed, path, err := CheckUdevRules()
+	if err != nil || installed || path != UdevRulePath {
+		t.Errorf("without a TrueRNG rule: %v %q %v, want false %q nil", installed, path, err, UdevRulePath)
+	}
+
+	want := write("99-TrueRNG.rules", SuggestUdevRule(DeviceModelTrueRNGproV2))
+	installed, path, err = CheckUdevRules()
+	if err != nil || !installed || path != want {
+		t.Errorf("with the suggested rule: %v %q %v, want true %q nil", installed, path, err, want)
+	}
+}
@@ -0,0 +1,69 @@
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTooManyCollisions is returned by UniqueReader.ReadUnique when every try
+// produced a value that had already been handed out.
+var ErrTooManyCollisions = errors.New("too many collisions")
+
+// UniqueReader hands out random values that are guaranteed not to repeat any
+// of the last capacity values it returned, as a belt-and-braces check for
+// token generation. Remembered values are evicted oldest first once capacity
+// is reached. It is safe for concurrent use.
+type UniqueReader struct {
+	mu       sync.Mutex
+	capacity int
+	maxTries int
+	seen     map[string]struct{}
+	order    []string // remembered values, oldest first
+	read     func(bitCount int, mode CaptureMode) ([]byte, error)
+}
+
+// NewUniqueReader returns a reader remembering up to capacity values and
+// trying up to maxTries reads per call before giving up.
+func NewUniqueReader(capacity, maxTries int) (*UniqueReader, error) {
+	if capacity <= 0 {
+		return nil, errors.New("capacity must be positive")
+	}
+	if maxTries <= 0 {
+		return nil, errors.New("maxTries must be positive")
+	}
+	return &UniqueReader{
+		capacity: capacity,
+		maxTries: maxTries,
+		seen:     make(map[string]struct{}, capacity),
+		read:     ReadBitsWithMode,
+	}, nil
+}
+
+// ReadUnique reads bits bits like ReadBitsWithMode and returns them if they
+// differ from every remembered value, re-reading on a collision. After
+// maxTries collisions it returns an error wrapping ErrTooManyCollisions, which
+// for any reasonable token size means the device is not producing random
+// output.
+func (u *UniqueReader) ReadUnique(bits int, mode CaptureMode) ([]byte, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for try := 0; try < u.maxTries; try++ {
+		data, err := u.read(bits, mode)
+		if err != nil {
+			return nil, err
+		}
+		key := string(data)
+		if _, dup := u.seen[key]; dup {
+			continue
+		}
+		if len(u.order) == u.capacity {
+			delete(u.seen, u.order[0])
+			u.order = u.order[1:]
+		}
+		u.seen[key] = struct{}{}
+		u.order = append(u.order, key)
+		return data, nil
+	}
+	return nil, fmt.Errorf("%w: %d reads of %d bits all repeated earlier values", ErrTooManyCollisions, u.maxTries, bits)
+}
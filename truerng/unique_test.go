@@ -0,0 +1,63 @@
+package truerng
+
+import (
+	"errors"
+	"testing"
+)
+
+// replayValues returns a read function handing out values in order, and a
+// pointer to the number of reads made.
+func replayValues(values ...string) (func(int, CaptureMode) ([]byte, error), *int) {
+	reads := 0
+	return func(int, CaptureMode) ([]byte, error) {
+		if reads == len(values) {
+			return nil, errors.New("replay exhausted")
+		}
+		reads++
+		return []byte(values[reads-1]), nil
+	}, &reads
+}
+
+func TestReadUniqueRereadsOnCollision(t *testing.T) {
+	u, err := NewUniqueReader(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reads *int
+	u.read, reads = replayValues("A", "A", "B", "B", "A", "C", "A")
+
+	for i, want := range []string{"A", "B", "C", "A"} {
+		got, err := u.ReadUnique(8, ModeNormal)
+		if err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+		if string(got) != want {
+			t.Errorf("call %d = %q, want %q", i+1, got, want)
+		}
+	}
+	// A is handed out again only after B and C evicted it.
+	if *reads != 7 {
+		t.Errorf("%d reads, want 7", *reads)
+	}
+}
+
+func TestReadUniqueTooManyCollisions(t *testing.T) {
+	u, err := NewUniqueReader(8, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.read, _ = replayValues("A", "A", "A", "A")
+	if _, err := u.ReadUnique(8, ModeNormal); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.ReadUnique(8, ModeNormal); !errors.Is(err, ErrTooManyCollisions) {
+		t.Errorf("err = %v, want ErrTooManyCollisions", err)
+	}
+
+	if _, err := NewUniqueReader(0, 3); err == nil {
+		t.Error("zero capacity accepted")
+	}
+	if _, err := NewUniqueReader(8, 0); err == nil {
+		t.Error("zero maxTries accepted")
+	}
+}
@@ -0,0 +1,95 @@
+package truerng
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Speed is a negotiated USB bus speed.
+type Speed int
+
+const (
+	SpeedUnknown   Speed = iota
+	SpeedLow             // 1.5 Mbit/s, USB 1.x low speed
+	SpeedFull            // 12 Mbit/s, USB 1.x full speed
+	SpeedHigh            // 480 Mbit/s, USB 2.0
+	SpeedSuper           // 5 Gbit/s, USB 3.x
+	SpeedSuperPlus       // 10 Gbit/s and up, USB 3.1+
+)
+
+// String returns the speed name.
+func (s Speed) String() string {
+	switch s {
+	case SpeedLow:
+		return "low (1.5 Mbit/s)"
+	case SpeedFull:
+		return "full (12 Mbit/s)"
+	case SpeedHigh:
+		return "high (480 Mbit/s)"
+	case SpeedSuper:
+		return "super (5 Gbit/s)"
+	case SpeedSuperPlus:
+		return "super+ (10+ Gbit/s)"
+	default:
+		return "unknown"
+	}
+}
+
+// Throughput returns the rate the model produces in bits per second, or 0
+// for unknown models.
+func (m DeviceModel) Throughput() int {
+	switch m {
+	case DeviceModelTrueRNG:
+		return 350_000
+	case DeviceModelTrueRNGpro, DeviceModelTrueRNGproV2:
+		return 3_200_000
+	default:
+		return 0
+	}
+}
+
+// Sustains reports whether a link at speed s can carry bitsPerSecond of bulk
+// data, i.e. whether a device producing that much is not held back by its
+// bus. Low speed has no bulk transfers at all; full and high speed are
+// limited by how many bulk packets fit in a frame. It is true when either
+// the speed or the rate is unknown, so that callers only warn on certainty.
+func (s Speed) Sustains(bitsPerSecond int) bool {
+	if s == SpeedUnknown || bitsPerSecond <= 0 {
+		return true
+	}
+	return bitsPerSecond <= s.bulkRate()
+}
+
+// bulkRate is the most bulk payload a speed carries in bits per second.
+func (s Speed) bulkRate() int {
+	switch s {
+	case SpeedLow:
+		return 0
+	case SpeedFull:
+		return 19 * 64 * 8 * 1000 // 19 packets of 64 bytes per 1 ms frame
+	case SpeedHigh:
+		return 13 * 512 * 8 * 8000 // 13 packets of 512 bytes per 125 µs microframe
+	default:
+		return math.MaxInt // SuperSpeed and up: more than any RNG produces
+	}
+}
+
+// parseSysfsSpeed maps the content of a sysfs USB "speed" attribute (the
+// rate in Mbit/s) to a Speed.
+func parseSysfsSpeed(s string) (Speed, error) {
+	switch strings.TrimSpace(s) {
+	case "1.5":
+		return SpeedLow, nil
+	case "12":
+		return SpeedFull, nil
+	case "480":
+		return SpeedHigh, nil
+	case "5000":
+		return SpeedSuper, nil
+	case "10000", "20000":
+		return SpeedSuperPlus, nil
+	default:
+		return SpeedUnknown, fmt.Errorf("unrecognised USB speed %q", strings.TrimSpace(s))
+	}
+}
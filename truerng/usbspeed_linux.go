@@ -0,0 +1,70 @@
+//go:build linux
+
+package truerng
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sysfsRoot is where sysfs is mounted. It is a variable so the lookup can
+// run against a fake tree.
+var sysfsRoot = "/sys"
+
+// USBSpeed returns the speed the device negotiated with its hub, read from
+// sysfs.
+func (d *DeviceInfo) USBSpeed() (Speed, error) {
+	dir, err := usbDeviceDir(d.Port)
+	if err != nil {
+		return SpeedUnknown, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "speed"))
+	if err != nil {
+		return SpeedUnknown, err
+	}
+	return parseSysfsSpeed(string(data))
+}
+
+// usbDeviceDir returns the sysfs directory of the USB device behind the tty
+// at port. The tty's device node is followed up to the USB device that
+// carries a "speed" attribute.
+func usbDeviceDir(port string) (string, error) {
+	name := port
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		name = resolved // e.g. a /dev/serial/by-id link
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Join(sysfsRoot, "class", "tty", filepath.Base(name), "device"))
+	if err != nil {
+		return "", fmt.Errorf("sysfs entry of %s: %w", port, err)
+	}
+	// tty -> interface (1-1.2:1.0) -> device (1-1.2); allow a little slack
+	for range 4 {
+		_, err := os.Stat(filepath.Join(dir, "speed"))
+		if err == nil {
+			return dir, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		dir = filepath.Dir(dir)
+	}
+	return "", fmt.Errorf("no USB device in sysfs for %s", port)
+}
+
+// usbProduct returns the product string descriptor of the USB device behind
+// the tty at port, as the kernel read it, or "" if sysfs does not have one.
+func usbProduct(port string) string {
+	dir, err := usbDeviceDir(port)
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "product"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
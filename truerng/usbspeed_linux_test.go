@@ -0,0 +1,67 @@
+package truerng
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSysfs builds a sysfs tree under a temporary directory in which the
+// tty name hangs off interface 1.0 of the USB device at bus location loc,
+// whose speed attribute reads speed, and points sysfsRoot at it for the
+// rest of the test.
+func fakeSysfs(t *testing.T, name, loc, speed string) {
+	t.Helper()
+	root := t.TempDir()
+	dev := filepath.Join(root, "devices", "pci0000:00", "0000:00:14.0", "usb1", loc)
+	iface := filepath.Join(dev, loc+":1.0")
+	if err := os.MkdirAll(filepath.Join(iface, "tty", name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dev, "speed"), []byte(speed+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dev, "product"), []byte("TrueRNGpro\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	class := filepath.Join(root, "class", "tty", name)
+	if err := os.MkdirAll(class, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(iface, filepath.Join(class, "device")); err != nil {
+		t.Fatal(err)
+	}
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+}
+
+func TestUSBSpeedFromSysfs(t *testing.T) {
+	for raw, want := range map[string]Speed{"1.5": SpeedLow, "12": SpeedFull, "480": SpeedHigh} {
+		fakeSysfs(t, "ttyFAKE0", "1-1.2", raw)
+		d := &DeviceInfo{Port: "/dev/ttyFAKE0"}
+		got, err := d.USBSpeed()
+		if err != nil || got != want {
+			t.Errorf("speed %s: USBSpeed() = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+
+	d := &DeviceInfo{Port: "/dev/ttyFAKE1"}
+	if _, err := d.USBSpeed(); err == nil {
+		t.Error("USBSpeed found a tty that is not in sysfs")
+	}
+}
+
+func TestUSBLocationAndProductFromSysfs(t *testing.T) {
+	fakeSysfs(t, "ttyFAKE0", "3-1.4", "12")
+	d := &DeviceInfo{Port: "/dev/ttyFAKE0"}
+	if loc, err := d.USBLocation(); err != nil || loc != "3-1.4" {
+		t.Errorf("USBLocation() = %q, %v; want 3-1.4", loc, err)
+	}
+	if name := usbProduct("/dev/ttyFAKE0"); name != "TrueRNGpro" {
+		t.Errorf("usbProduct() = %q, want TrueRNGpro", name)
+	}
+	if name := usbProduct("/dev/ttyFAKE1"); name != "" {
+		t.Errorf("usbProduct() of a missing tty = %q, want empty", name)
+	}
+}
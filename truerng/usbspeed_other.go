@@ -0,0 +1,15 @@
+//go:build !linux
+
+package truerng
+
+// USBSpeed is only implemented on Linux, where sysfs reports the negotiated
+// speed.
+func (d *DeviceInfo) USBSpeed() (Speed, error) {
+	return SpeedUnknown, ErrUnsupported
+}
+
+// usbProduct needs sysfs; elsewhere the enumerator's product string is all
+// there is.
+func usbProduct(port string) string {
+	return ""
+}
@@ -0,0 +1,45 @@
+package truerng
+
+import "testing"
+
+func TestParseSysfsSpeed(t *testing.T) {
+	for in, want := range map[string]Speed{
+		"1.5\n":   SpeedLow,
+		"12\n":    SpeedFull,
+		"480\n":   SpeedHigh,
+		"5000\n":  SpeedSuper,
+		"10000\n": SpeedSuperPlus,
+		"20000\n": SpeedSuperPlus,
+	} {
+		got, err := parseSysfsSpeed(in)
+		if err != nil || got != want {
+			t.Errorf("parseSysfsSpeed(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseSysfsSpeed("42"); err == nil {
+		t.Error("parseSysfsSpeed accepted 42")
+	}
+}
+
+func TestSpeedSustainsModelThroughput(t *testing.T) {
+	models := []DeviceModel{DeviceModelTrueRNG, DeviceModelTrueRNGpro, DeviceModelTrueRNGproV2}
+	for _, m := range models {
+		if SpeedLow.Sustains(m.Throughput()) {
+			t.Errorf("low speed sustains a %s", m)
+		}
+		for _, s := range []Speed{SpeedFull, SpeedHigh, SpeedSuper, SpeedSuperPlus} {
+			if !s.Sustains(m.Throughput()) {
+				t.Errorf("%s does not sustain a %s", s, m)
+			}
+		}
+	}
+	if !SpeedFull.Sustains(9_728_000) || SpeedFull.Sustains(9_728_001) {
+		t.Error("full speed bulk limit is not 19 64-byte packets per frame")
+	}
+	if !SpeedUnknown.Sustains(1 << 40) {
+		t.Error("an unknown speed is reported as too slow")
+	}
+	if !SpeedLow.Sustains(DeviceModelUnknown.Throughput()) {
+		t.Error("an unknown model is reported as too fast for its link")
+	}
+}
@@ -0,0 +1,71 @@
+package truerng
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// hungPort is a fakePort whose reads block until the port is closed, like a
+// wedged driver that ignores the read timeout.
+type hungPort struct {
+	*fakePort
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newHungPort() *hungPort {
+	return &hungPort{fakePort: &fakePort{}, closed: make(chan struct{})}
+}
+
+func (p *hungPort) Read([]byte) (int, error) {
+	<-p.closed
+	return 0, os.ErrClosed
+}
+
+func (p *hungPort) Close() error {
+	p.once.Do(func() { close(p.closed) })
+	return nil
+}
+
+func TestWatchdogUnblocksHungRead(t *testing.T) {
+	const port = "/dev/ttyFAKE0"
+	fakePorts(t, trueRNGPort(port, "A1"))
+	hung := newHungPort()
+	old := serialOpen
+	serialOpen = func(string, *serial.Mode) (serial.Port, error) { return hung, nil }
+	t.Cleanup(func() { serialOpen = old })
+
+	start := time.Now()
+	_, err := ReadBytesWithOptions(64, ModeNormal, ReadOptions{HardTimeout: 50 * time.Millisecond})
+	if !errors.Is(err, ErrReadTimeout) {
+		t.Fatalf("err = %v, want ErrReadTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("hung read returned after %v", elapsed)
+	}
+	select {
+	case <-hung.closed:
+	default:
+		t.Error("watchdog did not close the port")
+	}
+}
+
+func TestWatchdogQuietOnHealthyRead(t *testing.T) {
+	dev := randomPort(28)
+	buf := make([]byte, 32)
+	n, err := readWithWatchdog(dev, buf, 50*time.Millisecond)
+	if err != nil || n != len(buf) {
+		t.Fatalf("read %d, %v; want %d, nil", n, err, len(buf))
+	}
+	time.Sleep(100 * time.Millisecond)
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+	if dev.closed {
+		t.Error("watchdog closed the port after a read that finished in time")
+	}
+}
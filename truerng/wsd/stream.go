@@ -0,0 +1,89 @@
+//go:build wsd
+
+package wsd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"nhooyr.io/websocket"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// maxCloseReason is the longest reason a close frame can carry.
+const maxCloseReason = 123
+
+// StreamHandler returns an http.Handler that upgrades requests to WebSocket
+// and streams random bytes from the first detected TrueRNG in mode as binary
+// frames, at the rate given by the `rate` query parameter (bytes per second,
+// 1024 by default). All connections share one session, opened on first use
+// and reopened after a read error. The stream ends when the client
+// disconnects or sends a message of its own. When the device cannot be opened
+// or a read fails, the connection is closed with status 1011 (internal error)
+// and the error as the close reason.
+func StreamHandler(mode truerng.CaptureMode) http.Handler {
+	p := &pool{mode: mode}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rate, err := requestedRate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			// Accept has already written the error response.
+			return
+		}
+		p.stream(r.Context(), c, rate)
+	})
+}
+
+// stream sends rate bytes per second to c until the client goes away.
+func (p *pool) stream(ctx context.Context, c *websocket.Conn, rate int) {
+	defer c.CloseNow()
+	// The client sends nothing we need. CloseRead reads on our behalf, so its
+	// close frame or a dropped connection cancels ctx, also mid-read.
+	ctx = c.CloseRead(ctx)
+
+	s, err := p.get()
+	if err != nil {
+		closeWithError(c, fmt.Errorf("open device: %w", err))
+		return
+	}
+	frameSize := max(1, rate/framesPerSec)
+	ticker := time.NewTicker(time.Second * time.Duration(frameSize) / time.Duration(rate))
+	defer ticker.Stop()
+	for {
+		data, err := s.ReadContext(ctx, frameSize*8)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			p.discard(s)
+			closeWithError(c, fmt.Errorf("read device: %w", err))
+			return
+		}
+		if err := c.Write(ctx, websocket.MessageBinary, data); err != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeWithError closes c with StatusInternalError and err's text as the
+// reason, cut to what fits in a close frame.
+func closeWithError(c *websocket.Conn, err error) {
+	reason := err.Error()
+	if len(reason) > maxCloseReason {
+		reason = strings.ToValidUTF8(reason[:maxCloseReason], "")
+	}
+	_ = c.Close(websocket.StatusInternalError, reason)
+}
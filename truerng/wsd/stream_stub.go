@@ -0,0 +1,17 @@
+//go:build !wsd
+
+package wsd
+
+import (
+	"net/http"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// StreamHandler stands in for the WebSocket handler in builds without the
+// wsd tag: it answers every request with 501 Not Implemented.
+func StreamHandler(truerng.CaptureMode) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "built without WebSocket support; rebuild with -tags wsd", http.StatusNotImplemented)
+	})
+}
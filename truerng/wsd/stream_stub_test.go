@@ -0,0 +1,19 @@
+//go:build !wsd
+
+package wsd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+func TestStreamHandlerWithoutTag(t *testing.T) {
+	rec := httptest.NewRecorder()
+	StreamHandler(truerng.ModeNormal).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status %d, want 501", rec.Code)
+	}
+}
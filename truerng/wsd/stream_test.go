@@ -0,0 +1,113 @@
+//go:build wsd
+
+package wsd
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// dial connects a WebSocket client to srv with query appended to the URL.
+func dial(t *testing.T, ctx context.Context, srv *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+	c, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestStreamHandlerFrames(t *testing.T) {
+	opened := fakeSources(t, nil)
+	srv := httptest.NewServer(StreamHandler(truerng.ModeNormal))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Two clients share the source: between them they see every byte once.
+	seen := map[byte]int{}
+	for range 2 {
+		c := dial(t, ctx, srv, "?rate=400")
+		for range 3 {
+			typ, data, err := c.Read(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if typ != websocket.MessageBinary || len(data) != 400/framesPerSec {
+				t.Fatalf("frame of type %v and %d bytes, want binary and %d", typ, len(data), 400/framesPerSec)
+			}
+			for _, b := range data {
+				seen[b]++
+			}
+		}
+		c.Close(websocket.StatusNormalClosure, "")
+	}
+	if len(*opened) != 1 {
+		t.Errorf("%d sources opened, want 1 shared", len(*opened))
+	}
+	for b, n := range seen {
+		if n != 1 {
+			t.Errorf("byte %d handed out %d times", b, n)
+		}
+	}
+}
+
+func TestStreamHandlerClosesWithError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fakeSources(t, errors.New("no TrueRNG device found"))
+	srv := httptest.NewServer(StreamHandler(truerng.ModeNormal))
+	defer srv.Close()
+	c := dial(t, ctx, srv, "")
+	_, _, err := c.Read(ctx)
+	var ce websocket.CloseError
+	if !errors.As(err, &ce) || ce.Code != websocket.StatusInternalError || !strings.Contains(ce.Reason, "no TrueRNG device found") {
+		t.Errorf("open failure: err = %v, want a 1011 close naming the error", err)
+	}
+}
+
+func TestStreamHandlerReadError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opened := fakeSources(t, nil)
+	srv := httptest.NewServer(StreamHandler(truerng.ModeNormal))
+	defer srv.Close()
+	c := dial(t, ctx, srv, "?rate=100")
+	if _, _, err := c.Read(ctx); err != nil {
+		t.Fatal(err)
+	}
+	(*opened)[0].mu.Lock()
+	(*opened)[0].broken = true
+	(*opened)[0].mu.Unlock()
+
+	var ce websocket.CloseError
+	if _, _, err := c.Read(ctx); !errors.As(err, &ce) || ce.Code != websocket.StatusInternalError || !strings.Contains(ce.Reason, "device unplugged") {
+		t.Fatalf("read failure: err = %v, want a 1011 close naming the error", err)
+	}
+
+	// The broken source was dropped; the next client gets a fresh one.
+	c = dial(t, ctx, srv, "?rate=100")
+	defer c.CloseNow()
+	if _, _, err := c.Read(ctx); err != nil || len(*opened) != 2 || !(*opened)[0].closed {
+		t.Errorf("after a read error: err %v, %d sources opened", err, len(*opened))
+	}
+}
+
+func TestStreamHandlerRejectsBadRate(t *testing.T) {
+	srv := httptest.NewServer(StreamHandler(truerng.ModeNormal))
+	defer srv.Close()
+	_, resp, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")+"?rate=0", nil)
+	if err == nil || resp == nil || resp.StatusCode != 400 {
+		t.Errorf("rate=0: err %v, response %v; want 400", err, resp)
+	}
+}
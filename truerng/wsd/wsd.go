@@ -0,0 +1,88 @@
+// Package wsd streams TrueRNG random bytes over WebSocket, e.g. to a browser
+// visualizer. A client connects with `?rate=N` (bytes per second) and
+// receives binary frames until it disconnects.
+//
+// The WebSocket support uses nhooyr.io/websocket and is only compiled in with
+// the wsd build tag (`go build -tags wsd`); without it StreamHandler answers
+// every request with 501 Not Implemented.
+package wsd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+const (
+	defaultRate = 1024
+	maxRate     = 1 << 20
+	// framesPerSec is how often frames are sent at rates high enough to
+	// fill a frame of at least one byte each time.
+	framesPerSec = 10
+)
+
+// requestedRate parses the `rate` query parameter.
+func requestedRate(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("rate")
+	if v == "" {
+		return defaultRate, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 || n > maxRate {
+		return 0, fmt.Errorf("rate must be between 1 and %d", maxRate)
+	}
+	return n, nil
+}
+
+// source is what connections read from; *truerng.Session implements it.
+type source interface {
+	ReadContext(ctx context.Context, bitCount int) ([]byte, error)
+	Close() error
+}
+
+// openSource opens the source the pool shares. It is a variable so the
+// handler can be exercised without a device.
+var openSource = func(mode truerng.CaptureMode) (source, error) {
+	s, err := truerng.Open(mode)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// pool holds the source shared by all connections.
+type pool struct {
+	mode truerng.CaptureMode
+
+	mu sync.Mutex
+	s  source
+}
+
+// get returns the shared source, opening it if needed.
+func (p *pool) get() (source, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.s == nil {
+		s, err := openSource(p.mode)
+		if err != nil {
+			return nil, err
+		}
+		p.s = s
+	}
+	return p.s, nil
+}
+
+// discard closes s after a read error so the next connection reopens the
+// device, unless another connection already replaced it.
+func (p *pool) discard(s source) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.s == s {
+		_ = s.Close()
+		p.s = nil
+	}
+}
@@ -0,0 +1,100 @@
+package wsd
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Thiagojm/rng_cli_linux/truerng"
+)
+
+// fakeSource hands out counting bytes, or fails every read once broken.
+type fakeSource struct {
+	mu     sync.Mutex
+	next   byte
+	broken bool
+	closed bool
+}
+
+func (f *fakeSource) ReadContext(ctx context.Context, bitCount int) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.broken {
+		return nil, errors.New("device unplugged")
+	}
+	out := make([]byte, (bitCount+7)/8)
+	for i := range out {
+		out[i] = f.next
+		f.next++
+	}
+	return out, nil
+}
+
+func (f *fakeSource) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+// fakeSources replaces openSource for the rest of the test. Each open hands
+// out a new fakeSource, or fails with err if it is set.
+func fakeSources(t *testing.T, err error) *[]*fakeSource {
+	t.Helper()
+	var opened []*fakeSource
+	old := openSource
+	openSource = func(truerng.CaptureMode) (source, error) {
+		if err != nil {
+			return nil, err
+		}
+		s := &fakeSource{}
+		opened = append(opened, s)
+		return s, nil
+	}
+	t.Cleanup(func() { openSource = old })
+	return &opened
+}
+
+func TestRequestedRate(t *testing.T) {
+	for query, want := range map[string]int{"": defaultRate, "?rate=1": 1, "?rate=1048576": maxRate} {
+		got, err := requestedRate(httptest.NewRequest("GET", "/"+query, nil))
+		if err != nil || got != want {
+			t.Errorf("%q: rate %d, %v; want %d", query, got, err, want)
+		}
+	}
+	for _, query := range []string{"?rate=0", "?rate=-5", "?rate=fast", "?rate=1048577"} {
+		if _, err := requestedRate(httptest.NewRequest("GET", "/"+query, nil)); err == nil {
+			t.Errorf("%q accepted", query)
+		}
+	}
+}
+
+func TestPoolSharesAndReplacesSource(t *testing.T) {
+	opened := fakeSources(t, nil)
+	p := &pool{mode: truerng.ModeNormal}
+	a, err := p.get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := p.get(); b != a || len(*opened) != 1 {
+		t.Fatalf("second get opened a new source (%d opens)", len(*opened))
+	}
+
+	// A stale discard from a connection holding an older source is ignored.
+	p.discard(&fakeSource{})
+	if b, _ := p.get(); b != a {
+		t.Fatal("discard of another source dropped the shared one")
+	}
+	p.discard(a)
+	if !(*opened)[0].closed {
+		t.Error("discarded source not closed")
+	}
+	if b, _ := p.get(); b == a || len(*opened) != 2 {
+		t.Error("get after discard did not reopen")
+	}
+}